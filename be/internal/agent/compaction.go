@@ -0,0 +1,297 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"backboard-swarm/be/internal/backboard"
+	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/types"
+)
+
+const compactionPrompt = `MODE: COMPACT_CONTEXT
+
+This thread is about to be replaced by a fresh one. Summarize everything from the conversation so far that you will need to continue working:
+- the tasks you were given and the answers you returned
+- key facts, decisions, file paths, URLs and open questions
+- output_id handles you may still need to page through
+
+Do not call any tool other than finish. Call finish with the summary as plain markdown.`
+
+const (
+	maxTranscriptEntries = 60
+	extractiveBudget     = 6000
+	maxSettleRounds      = 3
+	maxResumeOutputBytes = 2000
+)
+
+type transcriptEntry struct {
+	Kind string
+	Text string
+}
+
+func approxTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+func (r *Runner) recordTurn(runID, agentID, kind, text string) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	key := sessionKey(runID, agentID)
+	r.sessionMu.Lock()
+	defer r.sessionMu.Unlock()
+	s, ok := r.sessions[key]
+	if !ok {
+		return
+	}
+	s.Tokens += approxTokens(text)
+	s.Transcript = append(s.Transcript, transcriptEntry{Kind: kind, Text: text})
+	if len(s.Transcript) > maxTranscriptEntries {
+		s.Transcript = append([]transcriptEntry(nil), s.Transcript[len(s.Transcript)-maxTranscriptEntries:]...)
+	}
+	r.sessions[key] = s
+}
+
+func (r *Runner) overCompactLimit(in TaskInput) bool {
+	r.sessionMu.Lock()
+	defer r.sessionMu.Unlock()
+	limit := r.cfg.ContextCompactTokens
+	return limit > 0 && r.sessions[sessionKey(in.RunID, in.AgentID)].Tokens >= limit
+}
+
+// compactIfNeeded moves the agent onto a fresh thread once its current one
+// grows past the compaction limit. The thread must not have a run waiting
+// for tool outputs.
+func (r *Runner) compactIfNeeded(ctx context.Context, in TaskInput, role types.Role) (agentSession, string, bool) {
+	if !r.overCompactLimit(in) {
+		return agentSession{}, "", false
+	}
+	r.sessionMu.Lock()
+	session := r.sessions[sessionKey(in.RunID, in.AgentID)]
+	r.sessionMu.Unlock()
+
+	source := "llm"
+	summary := r.summarizeThread(ctx, in, role, session)
+	if summary == "" {
+		source = "extractive"
+		summary = extractiveSummary(session.Transcript)
+	}
+
+	thread, err := r.client.CreateThread(ctx, session.AssistantID)
+	if err != nil {
+		r.emit(types.Event{
+			Type:      "agent_status",
+			RunID:     in.RunID,
+			AgentID:   in.AgentID,
+			Role:      role,
+			Status:    "compaction_failed",
+			Message:   fmt.Sprintf("could not start a fresh thread, continuing on %s: %v", session.ThreadID, err),
+			Timestamp: time.Now().UTC(),
+		})
+		return session, "", false
+	}
+
+	compacted := agentSession{AssistantID: session.AssistantID, ThreadID: thread.ThreadID}
+	r.sessionMu.Lock()
+	r.sessions[sessionKey(in.RunID, in.AgentID)] = compacted
+	r.sessionMu.Unlock()
+
+	r.emit(types.Event{
+		Type:      "agent_status",
+		RunID:     in.RunID,
+		AgentID:   in.AgentID,
+		Role:      role,
+		Status:    "compacted",
+		Message:   fmt.Sprintf("thread reached ~%d tokens; continuing on fresh thread %s with a %s summary", session.Tokens, thread.ThreadID, source),
+		Timestamp: time.Now().UTC(),
+		Meta: map[string]any{
+			"old_thread_id":  session.ThreadID,
+			"thread_id":      thread.ThreadID,
+			"approx_tokens":  session.Tokens,
+			"summary_source": source,
+		},
+	})
+
+	seed := "CONTEXT_SUMMARY (earlier turns of this session were compacted):\n" + summary
+	return compacted, seed, true
+}
+
+// summarizeThread asks the agent to summarize its own thread. Any tool call
+// other than finish is answered with an error so the run can settle.
+func (r *Runner) summarizeThread(ctx context.Context, in TaskInput, role types.Role, session agentSession) string {
+	provider, model := r.modelFor(in)
+	resp, err := r.addMessageWithRetry(ctx, in, role, backboard.AddMessageRequest{
+		ThreadID:    session.ThreadID,
+		Content:     compactionPrompt,
+		LLMProvider: provider,
+		ModelName:   model,
+		Memory:      r.cfg.MemoryMode,
+		WebSearch:   "off",
+		Stream:      false,
+		SendToLLM:   "true",
+	})
+	if err != nil {
+		return ""
+	}
+
+	switch normalizeStatus(resp.Status) {
+	case backboard.StatusCompleted:
+		return strings.TrimSpace(resp.Content)
+	case backboard.StatusRequiresAction:
+		summary := ""
+		outputs := make([]backboard.ToolOutput, len(resp.ToolCalls))
+		for i, call := range resp.ToolCalls {
+			out := `{"ok":false,"error":"tools are unavailable while compacting context"}`
+			if call.Function.Name == "finish" {
+				if args, err := call.ArgumentsMap(); err == nil {
+					if s, ok := args["summary"].(string); ok && summary == "" {
+						summary = strings.TrimSpace(s)
+					}
+				}
+				out = `{"ok":true}`
+			}
+			outputs[i] = backboard.ToolOutput{ToolCallID: call.ID, Output: out}
+		}
+		_, _ = r.client.SubmitToolOutputs(ctx, session.ThreadID, resp.RunID, outputs)
+		return summary
+	}
+	return ""
+}
+
+// compactMidTask moves a tool loop that outgrew its thread onto a fresh
+// one. The old thread's run is settled first by declining the calls it
+// still asks for; the fresh thread gets the summary, the last tool results
+// and the declined calls so the agent can repeat them. If the run does not
+// settle the loop carries on where it is.
+func (r *Runner) compactMidTask(ctx context.Context, in TaskInput, role types.Role, session agentSession, resp backboard.MessageResponse, calls []backboard.ToolCall, outputs []backboard.ToolOutput) (backboard.MessageResponse, agentSession, error) {
+	var declined []backboard.ToolCall
+	for i := 0; i < maxSettleRounds && normalizeStatus(resp.Status) == backboard.StatusRequiresAction; i++ {
+		refusals := make([]backboard.ToolOutput, len(resp.ToolCalls))
+		for j, call := range resp.ToolCalls {
+			declined = append(declined, call)
+			refusals[j] = backboard.ToolOutput{ToolCallID: call.ID, Output: `{"ok":false,"error":"not run: the conversation is moving to a fresh thread"}`}
+		}
+		next, err := r.submitToolOutputsWithRetry(ctx, in, role, session.ThreadID, resp.RunID, refusals)
+		if err != nil {
+			return backboard.MessageResponse{}, session, fmt.Errorf("submit tool outputs: %w", err)
+		}
+		resp = next
+	}
+	if normalizeStatus(resp.Status) == backboard.StatusRequiresAction {
+		return resp, session, nil
+	}
+
+	content := r.resumePrompt(in, calls, outputs, declined)
+	if compacted, seed, ok := r.compactIfNeeded(ctx, in, role); ok {
+		session = compacted
+		r.recordTurn(in.RunID, in.AgentID, "summary", seed)
+		content = seed + "\n\n" + content
+	}
+	r.recordTurn(in.RunID, in.AgentID, "task", in.Task)
+	r.recordTurn(in.RunID, in.AgentID, "tool", content)
+	provider, model := r.modelFor(in)
+	resp, err := r.addMessageWithRetry(ctx, in, role, backboard.AddMessageRequest{
+		ThreadID:    session.ThreadID,
+		Content:     content,
+		LLMProvider: provider,
+		ModelName:   model,
+		Memory:      r.cfg.MemoryMode,
+		WebSearch:   r.cfg.WebSearchMode,
+		Stream:      false,
+		SendToLLM:   "true",
+	})
+	if err != nil {
+		return backboard.MessageResponse{}, session, fmt.Errorf("add message: %w", err)
+	}
+	return resp, session, nil
+}
+
+// resumePrompt picks a tool loop back up after compaction: the task, the
+// results of the last tool round and the calls that were declined. Outputs
+// too long to repeat are left in the output store behind a read_output
+// handle.
+func (r *Runner) resumePrompt(in TaskInput, calls []backboard.ToolCall, outputs []backboard.ToolOutput, declined []backboard.ToolCall) string {
+	var builder strings.Builder
+	builder.WriteString("CURRENT_TASK:\n" + in.Task + "\n\nLAST_TOOL_RESULTS:\n")
+	for i, call := range calls {
+		out := outputs[i].Output
+		if len(out) > maxResumeOutputBytes {
+			preview := clip(out, maxResumeOutputBytes)
+			if r.stores.Outputs != nil {
+				preview += fmt.Sprintf("\n  (%d bytes in total; page through them with read_output output_id=%s)", len(out), r.stores.Outputs.Put(in.RunID, out))
+			}
+			out = preview
+		}
+		builder.WriteString(fmt.Sprintf("- %s %s\n  %s\n", call.Function.Name, clip(call.Function.Arguments, 300), out))
+	}
+	if len(declined) > 0 {
+		builder.WriteString("\nNOT_RUN (call these again if you still need them):\n")
+		for _, call := range declined {
+			builder.WriteString(fmt.Sprintf("- %s %s\n", call.Function.Name, clip(call.Function.Arguments, 300)))
+		}
+	}
+	builder.WriteString("\nContinue the task from here.")
+	return builder.String()
+}
+
+func extractiveSummary(entries []transcriptEntry) string {
+	toolCounts := map[string]int{}
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		switch e.Kind {
+		case "summary":
+			lines = append(lines, "- Earlier context: "+clip(e.Text, 2000))
+		case "task":
+			lines = append(lines, "- Task: "+clip(e.Text, 600))
+		case "result":
+			lines = append(lines, "  Result: "+clip(e.Text, 1200))
+		case "call":
+			name, _, _ := strings.Cut(e.Text, " ")
+			toolCounts[name]++
+		}
+	}
+
+	kept := make([]string, 0, len(lines))
+	used := 0
+	for i := len(lines) - 1; i >= 0; i-- {
+		if used+len(lines[i]) > extractiveBudget && len(kept) > 0 {
+			break
+		}
+		used += len(lines[i])
+		kept = append(kept, lines[i])
+	}
+
+	var builder strings.Builder
+	for i := len(kept) - 1; i >= 0; i-- {
+		builder.WriteString(kept[i])
+		builder.WriteString("\n")
+	}
+	if len(toolCounts) > 0 {
+		names := make([]string, 0, len(toolCounts))
+		for name := range toolCounts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			parts = append(parts, fmt.Sprintf("%s x%d", name, toolCounts[name]))
+		}
+		builder.WriteString("Tools used: " + strings.Join(parts, ", ") + "\n")
+	}
+	if builder.Len() == 0 {
+		return "No earlier context was recorded."
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+func clip(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return s[:runtime.RuneBoundary(s, n)] + "..."
+}
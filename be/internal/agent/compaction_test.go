@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backboard-swarm/be/internal/backboard"
+	"backboard-swarm/be/internal/config"
+	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/tools"
+	"backboard-swarm/be/internal/types"
+)
+
+func TestExtractiveSummaryKeepsTasksAndResults(t *testing.T) {
+	out := extractiveSummary([]transcriptEntry{
+		{Kind: "task", Text: "MODE: DECOMPOSE\n\nUSER_TASK:\nfind the release date"},
+		{Kind: "call", Text: `web_fetch {"url":"https://example.com"}`},
+		{Kind: "tool", Text: strings.Repeat("x", 50000)},
+		{Kind: "call", Text: `web_fetch {"url":"https://example.org"}`},
+		{Kind: "result", Text: "released on 2024-03-01"},
+	})
+	if !strings.Contains(out, "find the release date") || !strings.Contains(out, "released on 2024-03-01") {
+		t.Fatalf("expected task and result in summary, got %q", out)
+	}
+	if !strings.Contains(out, "web_fetch x2") {
+		t.Fatalf("expected tool usage counts, got %q", out)
+	}
+	if strings.Contains(out, "xxxx") {
+		t.Fatalf("expected raw tool output to be dropped, got %q", out)
+	}
+}
+
+type fakeBackboard struct {
+	mu       sync.Mutex
+	threads  int
+	messages []fakeMessage
+	submits  []backboard.SubmitToolOutputsRequest
+}
+
+type fakeMessage struct {
+	thread, provider, model, content string
+}
+
+// ServeHTTP has the model call big once, ask for it again after seeing the
+// output and answer everything else right away.
+func (f *fakeBackboard) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	bigCall := func(id string) backboard.MessageResponse {
+		return backboard.MessageResponse{RunID: "r", Status: backboard.StatusRequiresAction, ToolCalls: []backboard.ToolCall{
+			{ID: id, Type: "function", Function: backboard.ToolCallFunction{Name: "big", Arguments: "{}"}},
+		}}
+	}
+	switch {
+	case req.URL.Path == "/assistants":
+		_ = json.NewEncoder(w).Encode(backboard.Assistant{AssistantID: "a1"})
+	case strings.HasSuffix(req.URL.Path, "/threads"):
+		f.threads++
+		_ = json.NewEncoder(w).Encode(backboard.Thread{ThreadID: fmt.Sprintf("t%d", f.threads)})
+	case strings.HasSuffix(req.URL.Path, "/messages"):
+		msg := fakeMessage{
+			thread:   strings.Split(req.URL.Path, "/")[2],
+			provider: req.FormValue("llm_provider"),
+			model:    req.FormValue("model_name"),
+			content:  req.FormValue("content"),
+		}
+		f.messages = append(f.messages, msg)
+		resp := backboard.MessageResponse{RunID: "r", Status: backboard.StatusCompleted, Content: "done"}
+		if msg.content == "work" {
+			resp = bigCall("c1")
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	case strings.HasSuffix(req.URL.Path, "/submit-tool-outputs"):
+		var body backboard.SubmitToolOutputsRequest
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		f.submits = append(f.submits, body)
+		resp := backboard.MessageResponse{Status: backboard.StatusCompleted, Content: "ignored"}
+		if len(f.submits) == 1 {
+			resp = bigCall("c2")
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func TestRunnerCompactsAfterToolRoundsWithTheTaskModel(t *testing.T) {
+	fake := &fakeBackboard{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	registry := tools.NewRegistry()
+	registry.RegisterBuiltin(tools.Registration{
+		Name:       "big",
+		Parameters: map[string]any{"type": "object"},
+		Handler: func(context.Context, map[string]any, *tools.ExecutionContext) (any, error) {
+			return strings.Repeat("y", 3000), nil
+		},
+	})
+	cfg := config.Config{LLMProvider: "default", ModelName: "default-model", MaxIterations: 5, ContextCompactTokens: 100}
+	stores := Stores{Assistants: runtime.NewAssistantStore(), Outputs: runtime.NewOutputStore()}
+	r := NewRunner(backboard.NewClient(srv.URL, "key", 5*time.Second), cfg, registry, stores, PromptStore{}, nil)
+
+	in := TaskInput{RunID: "run", AgentID: "agent-1", Role: types.RoleCoder, Task: "work", Model: "other/task-model"}
+	if _, err := r.RunTask(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.submits) != 2 || !strings.Contains(fake.submits[0].ToolOutputs[0].Output, "yyyy") ||
+		fake.submits[1].ToolOutputs[0].ToolCallID != "c2" || !strings.Contains(fake.submits[1].ToolOutputs[0].Output, "not run") {
+		t.Fatalf("expected the outputs submitted and the next call declined on the old thread, got %+v", fake.submits)
+	}
+	if len(fake.messages) != 3 || fake.messages[1].thread != "t1" || !strings.HasPrefix(fake.messages[1].content, "MODE: COMPACT_CONTEXT") {
+		t.Fatalf("expected the settled thread to be summarized, got %+v", fake.messages)
+	}
+	resumed := fake.messages[2]
+	for _, want := range []string{"LAST_TOOL_RESULTS", "read_output output_id=", "NOT_RUN"} {
+		if resumed.thread != "t2" || !strings.Contains(resumed.content, want) {
+			t.Fatalf("expected %s on the fresh thread, got %+v", want, resumed)
+		}
+	}
+	if strings.Contains(resumed.content, strings.Repeat("y", 3000)) {
+		t.Fatal("expected the long output to stay behind its handle")
+	}
+
+	in.Task = "more"
+	if _, err := r.RunTask(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+	if summary := fake.messages[3]; !strings.HasPrefix(summary.content, "MODE: COMPACT_CONTEXT") || summary.thread != "t2" {
+		t.Fatalf("expected a compaction summary request on t2, got %+v", summary)
+	}
+	for _, msg := range fake.messages {
+		if msg.provider != "other" || msg.model != "task-model" {
+			t.Fatalf("expected every message to use the task model, got %+v", msg)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"backboard-swarm/be/internal/types"
+)
+
+var (
+	ErrMaxIterations = errors.New("agent exceeded max iterations")
+	ErrToolFailure   = errors.New("agent failed after a tool error")
+)
+
+// ClassifyFailure maps a RunTask error onto the failure kinds the
+// orchestrator uses to decide on retries and to report to the planner.
+func ClassifyFailure(err error) types.FailureKind {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrMaxIterations):
+		return types.FailureMaxIterations
+	case errors.Is(err, ErrToolFailure):
+		return types.FailureToolError
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return types.FailureTimeout
+	}
+	v := strings.ToLower(err.Error())
+	if strings.Contains(v, "backboard") || strings.Contains(v, "add message") || strings.Contains(v, "submit tool outputs") ||
+		strings.Contains(v, "create thread") || strings.Contains(v, "create assistant") || isTransient(err) {
+		return types.FailureBackboard
+	}
+	return types.FailureAgent
+}
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const maxPartialFindings = 5
+
+// partialResult collects what an agent reported for its current task before
+// it failed: assistant replies and message tool updates recorded since the
+// last task turn. It is empty when the agent said nothing.
+func (r *Runner) partialResult(in TaskInput) TaskResult {
+	r.sessionMu.Lock()
+	transcript := append([]transcriptEntry(nil), r.sessions[sessionKey(in.RunID, in.AgentID)].Transcript...)
+	r.sessionMu.Unlock()
+
+	start := 0
+	for i := len(transcript) - 1; i >= 0; i-- {
+		if transcript[i].Kind == "task" {
+			start = i + 1
+			break
+		}
+	}
+	notes := make([]string, 0)
+	for _, e := range transcript[start:] {
+		switch e.Kind {
+		case "assistant":
+			notes = append(notes, clip(e.Text, 600))
+		case "call":
+			if content, ok := messageContent(e.Text); ok {
+				notes = append(notes, clip(content, 600))
+			}
+		}
+	}
+	if len(notes) == 0 {
+		return TaskResult{}
+	}
+	if len(notes) > maxPartialFindings {
+		notes = notes[len(notes)-maxPartialFindings:]
+	}
+	payload, _ := json.Marshal(map[string]any{"summary": notes[len(notes)-1], "findings": notes})
+	return TaskResult{Summary: notes[len(notes)-1], Raw: strings.Join(notes, "\n"), Payload: payload, Partial: true}
+}
+
+func messageContent(call string) (string, bool) {
+	args, ok := strings.CutPrefix(call, "message ")
+	if !ok {
+		return "", false
+	}
+	var parsed struct {
+		Content string `json:"content"`
+	}
+	if json.Unmarshal([]byte(args), &parsed) != nil || strings.TrimSpace(parsed.Content) == "" {
+		return "", false
+	}
+	return parsed.Content, true
+}
@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPartialResultCollectsProgressSinceLastTask(t *testing.T) {
+	r := &Runner{sessions: map[string]agentSession{
+		sessionKey("run-1", "agent-1"): {Transcript: []transcriptEntry{
+			{Kind: "task", Text: "old task"},
+			{Kind: "assistant", Text: "old progress"},
+			{Kind: "task", Text: "new task"},
+			{Kind: "call", Text: `message {"content":"scanned 3 of 5 files"}`},
+			{Kind: "call", Text: `read {"path":"a.go"}`},
+			{Kind: "assistant", Text: "a.go uses the old API"},
+		}},
+	}}
+
+	res := r.partialResult(TaskInput{RunID: "run-1", AgentID: "agent-1"})
+	if !res.Partial || res.Summary != "a.go uses the old API" {
+		t.Fatalf("expected latest progress as summary, got %+v", res)
+	}
+	if strings.Contains(res.Raw, "old progress") || !strings.Contains(res.Raw, "scanned 3 of 5 files") {
+		t.Fatalf("expected only current task progress, got %q", res.Raw)
+	}
+	if empty := r.partialResult(TaskInput{RunID: "run-1", AgentID: "agent-2"}); empty.Partial {
+		t.Fatalf("expected no partial result without a session, got %+v", empty)
+	}
+}
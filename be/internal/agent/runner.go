@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -10,7 +11,9 @@ import (
 
 	"backboard-swarm/be/internal/backboard"
 	"backboard-swarm/be/internal/config"
+	"backboard-swarm/be/internal/fetch"
 	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/search"
 	"backboard-swarm/be/internal/tools"
 	"backboard-swarm/be/internal/types"
 )
@@ -20,25 +23,54 @@ type EventSink interface {
 }
 
 type TaskInput struct {
-	RunID   string
-	AgentID string
-	Role    types.Role
-	Task    string
+	RunID      string
+	AgentID    string
+	Role       types.Role
+	Task       string
+	FinishMode types.FinishMode
+	// Model optionally overrides the configured model as "provider/model"
+	// or just "model".
+	Model string
+	// Delegator lets the agent spawn child subtasks through the delegate
+	// tool. Nil disables delegation.
+	Delegator tools.Delegator
+	// MaxIterations overrides cfg.MaxIterations when positive.
+	MaxIterations int
+	// WorkspaceRoot overrides cfg.WorkspaceRoot when set.
+	WorkspaceRoot string
+	// ReadOnly restricts the agent to tools without side effects.
+	ReadOnly bool
 }
 
 type TaskResult struct {
 	Summary string
 	Raw     string
+	Payload json.RawMessage
+	Sources []types.Source
+	// Partial is set alongside an error when the agent was cut off and
+	// Summary holds the progress it reported before stopping.
+	Partial bool
+}
+
+type Stores struct {
+	Assistants *runtime.AssistantStore
+	Todos      *runtime.TodoStore
+	Outputs    *runtime.OutputStore
+	Approvals  *runtime.ApprovalStore
+	Inputs     *runtime.InputStore
+	Sources    *runtime.SourceStore
+	Board      *runtime.BoardStore
 }
 
 type Runner struct {
-	client     *backboard.Client
-	cfg        config.Config
-	registry   *tools.Registry
-	assistants *runtime.AssistantStore
-	todos      *runtime.TodoStore
-	prompts    PromptStore
-	events     EventSink
+	client   *backboard.Client
+	cfg      config.Config
+	registry *tools.Registry
+	stores   Stores
+	prompts  PromptStore
+	events   EventSink
+	search   search.Provider
+	fetcher  fetch.Fetcher
 
 	ensureMu   sync.Mutex
 	sessionMu  sync.Mutex
@@ -49,14 +81,15 @@ type Runner struct {
 type agentSession struct {
 	AssistantID string
 	ThreadID    string
+	Tokens      int
+	Transcript  []transcriptEntry
 }
 
 func NewRunner(
 	client *backboard.Client,
 	cfg config.Config,
 	registry *tools.Registry,
-	assistants *runtime.AssistantStore,
-	todos *runtime.TodoStore,
+	stores Stores,
 	prompts PromptStore,
 	events EventSink,
 ) *Runner {
@@ -64,8 +97,7 @@ func NewRunner(
 		client:     client,
 		cfg:        cfg,
 		registry:   registry,
-		assistants: assistants,
-		todos:      todos,
+		stores:     stores,
 		prompts:    prompts,
 		events:     events,
 		sessions:   make(map[string]agentSession),
@@ -73,7 +105,81 @@ func NewRunner(
 	}
 }
 
+// SetSearch sets the providers websearch uses instead of Jina alone.
+func (r *Runner) SetSearch(provider search.Provider) {
+	r.search = provider
+}
+
+// SetFetcher sets the fetchers web_fetch uses instead of the native one.
+func (r *Runner) SetFetcher(fetcher fetch.Fetcher) {
+	r.fetcher = fetcher
+}
+
 func (r *Runner) RunTask(ctx context.Context, in TaskInput) (TaskResult, error) {
+	res, err := r.runTask(ctx, in)
+	if err != nil {
+		return r.partialResult(in), err
+	}
+	r.recordTurn(in.RunID, in.AgentID, "result", res.Summary)
+	res.Sources = r.resolveSources(in, res)
+	return res, nil
+}
+
+// resolveSources maps the sources listed in a finish report, plus any [S<n>]
+// markers in its text, onto the run's registered sources. Unknown URLs and
+// file paths are registered so they still get an id.
+func (r *Runner) resolveSources(in TaskInput, res TaskResult) []types.Source {
+	if r.stores.Sources == nil {
+		return nil
+	}
+	var report struct {
+		Findings []string `json:"findings"`
+		Sources  []string `json:"sources"`
+	}
+	if len(res.Payload) > 0 {
+		_ = json.Unmarshal(res.Payload, &report)
+	}
+	refs := append([]string{}, report.Sources...)
+	refs = append(refs, runtime.CitedSourceIDs(res.Summary)...)
+	for _, f := range report.Findings {
+		refs = append(refs, runtime.CitedSourceIDs(f)...)
+	}
+
+	out := make([]types.Source, 0, len(refs))
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+		src, ok := r.stores.Sources.Resolve(in.RunID, ref)
+		if !ok {
+			if runtime.IsSourceID(ref) {
+				continue
+			}
+			src = r.stores.Sources.Register(in.RunID, types.Source{URL: ref, Tool: "report", AgentID: in.AgentID})
+		}
+		if src.ID == "" || seen[src.ID] {
+			continue
+		}
+		seen[src.ID] = true
+		out = append(out, src)
+	}
+	return out
+}
+
+func (r *Runner) modelFor(in TaskInput) (string, string) {
+	m := strings.TrimSpace(in.Model)
+	if m == "" {
+		return r.cfg.LLMProvider, r.cfg.ModelName
+	}
+	if provider, model, ok := strings.Cut(m, "/"); ok && provider != "" && model != "" {
+		return provider, model
+	}
+	return r.cfg.LLMProvider, m
+}
+
+func (r *Runner) runTask(ctx context.Context, in TaskInput) (TaskResult, error) {
 	role := in.Role.Normalize()
 	session, created, err := r.getOrCreateSession(ctx, in.RunID, in.AgentID, role)
 	if err != nil {
@@ -108,11 +214,22 @@ func (r *Runner) RunTask(ctx context.Context, in TaskInput) (TaskResult, error)
 		})
 	}
 
+	content := in.Task
+	if !created {
+		if compacted, seed, ok := r.compactIfNeeded(ctx, in, role); ok {
+			session = compacted
+			content = seed + "\n\n" + in.Task
+			r.recordTurn(in.RunID, in.AgentID, "summary", seed)
+		}
+	}
+	r.recordTurn(in.RunID, in.AgentID, "task", in.Task)
+
+	provider, model := r.modelFor(in)
 	resp, err := r.addMessageWithRetry(ctx, in, role, backboard.AddMessageRequest{
 		ThreadID:    session.ThreadID,
-		Content:     in.Task,
-		LLMProvider: r.cfg.LLMProvider,
-		ModelName:   r.cfg.ModelName,
+		Content:     content,
+		LLMProvider: provider,
+		ModelName:   model,
 		Memory:      r.cfg.MemoryMode,
 		WebSearch:   r.cfg.WebSearchMode,
 		Stream:      false,
@@ -123,10 +240,18 @@ func (r *Runner) RunTask(ctx context.Context, in TaskInput) (TaskResult, error)
 	}
 
 	finishSummary := ""
+	var finishPayload json.RawMessage
 	finishSeen := false
-	for i := 0; i < r.cfg.MaxIterations; i++ {
+	invalidFinishes := 0
+	var lastToolErr error
+	maxIterations := r.cfg.MaxIterations
+	if in.MaxIterations > 0 {
+		maxIterations = in.MaxIterations
+	}
+	for i := 0; i < maxIterations; i++ {
 		iteration := i + 1
 		status := normalizeStatus(resp.Status)
+		r.recordTurn(in.RunID, in.AgentID, "assistant", resp.Content)
 		r.emit(types.Event{
 			Type:      "agent_status",
 			RunID:     in.RunID,
@@ -137,7 +262,7 @@ func (r *Runner) RunTask(ctx context.Context, in TaskInput) (TaskResult, error)
 			Timestamp: time.Now().UTC(),
 			Meta: map[string]any{
 				"iteration":      iteration,
-				"max_iterations": r.cfg.MaxIterations,
+				"max_iterations": maxIterations,
 				"tool_calls":     len(resp.ToolCalls),
 				"thread_id":      session.ThreadID,
 				"run_id":         resp.RunID,
@@ -151,14 +276,26 @@ func (r *Runner) RunTask(ctx context.Context, in TaskInput) (TaskResult, error)
 			}
 
 			baseExecCtx := tools.ExecutionContext{
-				RunID:          in.RunID,
-				AgentID:        in.AgentID,
-				Role:           role,
-				WorkspaceRoot:  r.cfg.WorkspaceRoot,
-				JinaAPIKey:     r.cfg.JinaAPIKey,
-				RequestTimeout: r.cfg.RequestTimeout,
-				Todos:          r.todos,
-				Emitter:        r.events,
+				RunID:                in.RunID,
+				AgentID:              in.AgentID,
+				Role:                 role,
+				FinishMode:           in.FinishMode,
+				SkipFinishValidation: invalidFinishes >= r.cfg.FinishRetries,
+				WorkspaceRoot:        firstNonEmpty(in.WorkspaceRoot, r.cfg.WorkspaceRoot),
+				JinaAPIKey:           r.cfg.JinaAPIKey,
+				Search:               r.search,
+				Fetcher:              r.fetcher,
+				RequestTimeout:       r.cfg.RequestTimeout,
+				Todos:                r.stores.Todos,
+				Outputs:              r.stores.Outputs,
+				Approvals:            r.stores.Approvals,
+				Inputs:               r.stores.Inputs,
+				Sources:              r.stores.Sources,
+				Board:                r.stores.Board,
+				AskUserTimeout:       r.cfg.AskUserTimeout,
+				Delegator:            in.Delegator,
+				Emitter:              r.events,
+				ReadOnly:             in.ReadOnly,
 			}
 
 			type toolExecResult struct {
@@ -167,6 +304,7 @@ func (r *Runner) RunTask(ctx context.Context, in TaskInput) (TaskResult, error)
 				out      backboard.ToolOutput
 				isFinish bool
 				summary  string
+				payload  json.RawMessage
 				err      error
 			}
 
@@ -174,6 +312,7 @@ func (r *Runner) RunTask(ctx context.Context, in TaskInput) (TaskResult, error)
 			var wg sync.WaitGroup
 			for idx, call := range resp.ToolCalls {
 				argsPreview := r.toolArgsPreview(call)
+				r.recordTurn(in.RunID, in.AgentID, "call", call.Function.Name+" "+call.Function.Arguments)
 				r.emit(types.Event{
 					Type:      "tool_call",
 					RunID:     in.RunID,
@@ -197,7 +336,7 @@ func (r *Runner) RunTask(ctx context.Context, in TaskInput) (TaskResult, error)
 					defer wg.Done()
 					execCtx := baseExecCtx
 					out, isFinish, summary, execErr := r.registry.Execute(ctx, call, &execCtx)
-					resultsCh <- toolExecResult{idx: idx, call: call, out: out, isFinish: isFinish, summary: summary, err: execErr}
+					resultsCh <- toolExecResult{idx: idx, call: call, out: out, isFinish: isFinish, summary: summary, payload: execCtx.FinishPayload, err: execErr}
 				}(idx, call)
 			}
 
@@ -206,12 +345,33 @@ func (r *Runner) RunTask(ctx context.Context, in TaskInput) (TaskResult, error)
 
 			outputs := make([]backboard.ToolOutput, len(resp.ToolCalls))
 			finishedInThisTurn := false
+			lastToolErr = nil
 			for result := range resultsCh {
 				displayOutput := result.out.Output
 				if result.call.Function.Name == "message" || result.call.Function.Name == "finish" {
 					displayOutput = `{"ok":true}`
 				}
+				if errors.Is(result.err, tools.ErrInvalidFinish) {
+					invalidFinishes++
+					r.emit(types.Event{
+						Type:      "agent_status",
+						RunID:     in.RunID,
+						AgentID:   in.AgentID,
+						Role:      role,
+						Status:    "finish_invalid",
+						Message:   fmt.Sprintf("finish rejected (attempt %d/%d): %v", invalidFinishes, r.cfg.FinishRetries, result.err),
+						Timestamp: time.Now().UTC(),
+						Meta: map[string]any{
+							"finish_mode":  in.FinishMode,
+							"tool_call_id": result.call.ID,
+							"iteration":    iteration,
+						},
+					})
+				}
 				if result.err != nil {
+					if !errors.Is(result.err, tools.ErrInvalidFinish) {
+						lastToolErr = fmt.Errorf("%s: %w", result.call.Function.Name, result.err)
+					}
 					r.emit(types.Event{
 						Type:      "tool_result",
 						RunID:     in.RunID,
@@ -253,15 +413,18 @@ func (r *Runner) RunTask(ctx context.Context, in TaskInput) (TaskResult, error)
 					})
 				}
 				outputs[result.idx] = result.out
+				r.recordTurn(in.RunID, in.AgentID, "tool", result.out.Output)
 				if result.isFinish {
 					finishedInThisTurn = true
 					finishSeen = true
 					if finishSummary == "" {
 						finishSummary = result.summary
+						finishPayload = result.payload
 					}
 				}
 			}
 
+			calls := resp.ToolCalls
 			resp, err = r.submitToolOutputsWithRetry(ctx, in, role, session.ThreadID, resp.RunID, outputs)
 			if err != nil {
 				return TaskResult{}, fmt.Errorf("submit tool outputs: %w", err)
@@ -271,7 +434,15 @@ func (r *Runner) RunTask(ctx context.Context, in TaskInput) (TaskResult, error)
 				if finishSummary != "" {
 					summary = finishSummary
 				}
-				return TaskResult{Summary: summary, Raw: resp.Content}, nil
+				return TaskResult{Summary: summary, Raw: resp.Content, Payload: finishPayload}, nil
+			}
+			// Long tool loops can outgrow the thread within a single task, so
+			// the size is checked again after every tool round.
+			if normalizeStatus(resp.Status) == backboard.StatusRequiresAction && r.overCompactLimit(in) {
+				resp, session, err = r.compactMidTask(ctx, in, role, session, resp, calls, outputs)
+				if err != nil {
+					return TaskResult{}, err
+				}
 			}
 
 		case backboard.StatusCompleted:
@@ -279,7 +450,7 @@ func (r *Runner) RunTask(ctx context.Context, in TaskInput) (TaskResult, error)
 			if finishSummary != "" {
 				summary = finishSummary
 			}
-			return TaskResult{Summary: summary, Raw: resp.Content}, nil
+			return TaskResult{Summary: summary, Raw: resp.Content, Payload: finishPayload}, nil
 
 		case backboard.StatusFailed, backboard.StatusCancelled:
 			if finishSummary != "" || strings.TrimSpace(resp.Content) != "" {
@@ -297,7 +468,10 @@ func (r *Runner) RunTask(ctx context.Context, in TaskInput) (TaskResult, error)
 						"run_id":    resp.RunID,
 					},
 				})
-				return TaskResult{Summary: summary, Raw: resp.Content}, nil
+				return TaskResult{Summary: summary, Raw: resp.Content, Payload: finishPayload}, nil
+			}
+			if lastToolErr != nil {
+				return TaskResult{}, fmt.Errorf("%w (%v): agent ended with status %s", ErrToolFailure, lastToolErr, resp.Status)
 			}
 			return TaskResult{}, fmt.Errorf("agent ended with status %s: %s", resp.Status, resp.Content)
 
@@ -321,14 +495,27 @@ func (r *Runner) RunTask(ctx context.Context, in TaskInput) (TaskResult, error)
 					},
 				})
 			}
-			time.Sleep(200 * time.Millisecond)
+			select {
+			case <-ctx.Done():
+				return TaskResult{}, ctx.Err()
+			case <-time.After(200 * time.Millisecond):
+			}
 		}
 	}
 
-	return TaskResult{}, fmt.Errorf("agent exceeded max iterations (%d)", r.cfg.MaxIterations)
+	return TaskResult{}, fmt.Errorf("%w (%d)", ErrMaxIterations, maxIterations)
 }
 
 func (r *Runner) EndRun(runID string) {
+	if r.stores.Outputs != nil {
+		r.stores.Outputs.EndRun(runID)
+	}
+	if r.stores.Sources != nil {
+		r.stores.Sources.EndRun(runID)
+	}
+	if r.stores.Board != nil {
+		r.stores.Board.EndRun(runID)
+	}
 	r.sessionMu.Lock()
 	defer r.sessionMu.Unlock()
 	prefix := runID + "::"
@@ -376,13 +563,13 @@ func sessionKey(runID, agentID string) string {
 }
 
 func (r *Runner) ensureAssistant(ctx context.Context, role types.Role) (string, error) {
-	if id, ok := r.assistants.Get(string(role)); ok && id != "" {
+	if id, ok := r.stores.Assistants.Get(string(role)); ok && id != "" {
 		return id, nil
 	}
 
 	r.ensureMu.Lock()
 	defer r.ensureMu.Unlock()
-	if id, ok := r.assistants.Get(string(role)); ok && id != "" {
+	if id, ok := r.stores.Assistants.Get(string(role)); ok && id != "" {
 		return id, nil
 	}
 
@@ -394,7 +581,7 @@ func (r *Runner) ensureAssistant(ctx context.Context, role types.Role) (string,
 	if err != nil {
 		return "", fmt.Errorf("create assistant for role %s: %w", role, err)
 	}
-	r.assistants.Set(string(role), a.AssistantID)
+	r.stores.Assistants.Set(string(role), a.AssistantID)
 	return a.AssistantID, nil
 }
 
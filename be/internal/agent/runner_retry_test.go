@@ -1,6 +1,12 @@
 package agent
 
-import "testing"
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"backboard-swarm/be/internal/types"
+)
 
 func TestIsRetryableSubmit(t *testing.T) {
 	tests := []struct {
@@ -27,3 +33,25 @@ func TestIsRetryableSubmit(t *testing.T) {
 type errString string
 
 func (e errString) Error() string { return string(e) }
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want types.FailureKind
+	}{
+		{name: "max iterations", err: fmt.Errorf("%w (24)", ErrMaxIterations), want: types.FailureMaxIterations},
+		{name: "tool error", err: fmt.Errorf("%w (read: boom): agent ended with status FAILED", ErrToolFailure), want: types.FailureToolError},
+		{name: "backboard outage", err: fmt.Errorf("add message: %w", errString("backboard add_message failed (503): down")), want: types.FailureBackboard},
+		{name: "timeout", err: fmt.Errorf("submit: %w", context.DeadlineExceeded), want: types.FailureTimeout},
+		{name: "other", err: errString("requires action with no tool calls"), want: types.FailureAgent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyFailure(tt.err); got != tt.want {
+				t.Fatalf("ClassifyFailure() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
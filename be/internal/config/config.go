@@ -2,55 +2,153 @@ package config
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"backboard-swarm/be/internal/types"
 )
 
 type Config struct {
-	BackboardAPIKey string
-	JinaAPIKey      string
-	BaseURL         string
-	LLMProvider     string
-	ModelName       string
-	MemoryMode      string
-	WebSearchMode   string
-	ServerAddr      string
-	ServerURL       string
-	WorkspaceRoot   string
-	RequestTimeout  time.Duration
-	MaxSubagents    int
-	MaxIterations   int
-	MaxOrchRounds   int
+	BackboardAPIKey  string
+	JinaAPIKey       string
+	BaseURL          string
+	LLMProvider      string
+	ModelName        string
+	MemoryMode       string
+	WebSearchMode    string
+	ServerAddr       string
+	ServerURL        string
+	WorkspaceRoot    string
+	DataDir          string
+	RequestTimeout   time.Duration
+	MaxSubagents     int
+	MaxIterations    int
+	MaxOrchRounds    int
+	MaxPlanSize      int
+	MaxTotalSubtasks int
+	Strategy         string
+	Verify           bool
+
+	EnsembleRoles  map[types.Role]int
+	EnsembleModels []string
+
+	MaxDelegateDepth  int
+	MaxDelegateFanout int
+
+	SubtaskRetries      int
+	SubtaskRetryBackoff time.Duration
+	EscalationModel     string
+
+	SubtaskTimeout    time.Duration
+	RoleTimeouts      map[types.Role]time.Duration
+	RoleMaxIterations map[types.Role]int
+
+	// SearchProviders are tried in order until one answers.
+	SearchProviders []string
+	SearXNGURL      string
+	SearchFixture   string
+	// Fetchers are tried in order by web_fetch.
+	Fetchers []string
+	// FetchAllowPrivate lets the native fetcher reach loopback, private
+	// and link-local addresses.
+	FetchAllowPrivate bool
+
+	// WorkspaceIsolation is shared, run or agent.
+	WorkspaceIsolation string
+	WorkspaceMerge     bool
+
+	ContextCompactTokens int
+	ToolOutputMaxBytes   int
+
+	ApprovalRules   []types.ApprovalRule
+	ApprovalTimeout time.Duration
+	AskUserTimeout  time.Duration
+	FinishRetries   int
 }
 
 func Load() (Config, error) {
 	_ = loadDotEnv(".env")
 
 	cfg := Config{
-		BackboardAPIKey: strings.TrimSpace(os.Getenv("BACKBOARD_API_KEY")),
-		JinaAPIKey:      strings.TrimSpace(os.Getenv("JINA_API_KEY")),
-		BaseURL:         getenvDefault("BACKBOARD_BASE_URL", "https://app.backboard.io/api"),
-		LLMProvider:     getenvDefault("BACKBOARD_LLM_PROVIDER", "openai"),
-		ModelName:       getenvDefault("BACKBOARD_MODEL_NAME", "gpt-4o"),
-		MemoryMode:      getenvDefault("BACKBOARD_MEMORY_MODE", "Auto"),
-		WebSearchMode:   getenvDefault("BACKBOARD_WEB_SEARCH_MODE", "off"),
-		ServerAddr:      getenvDefault("WUVO_SERVER_ADDR", ":8080"),
-		ServerURL:       getenvDefault("WUVO_SERVER_URL", "http://127.0.0.1:8080"),
-		WorkspaceRoot:   workspaceRoot(),
-		RequestTimeout:  durationDefault("WUVO_REQUEST_TIMEOUT", 120*time.Second),
-		MaxSubagents:    intDefault("WUVO_MAX_SUBAGENTS", 4),
-		MaxIterations:   intDefault("WUVO_MAX_ITERATIONS", 24),
-		MaxOrchRounds:   intDefault("WUVO_MAX_ORCH_ROUNDS", 3),
+		BackboardAPIKey:  strings.TrimSpace(os.Getenv("BACKBOARD_API_KEY")),
+		JinaAPIKey:       strings.TrimSpace(os.Getenv("JINA_API_KEY")),
+		BaseURL:          getenvDefault("BACKBOARD_BASE_URL", "https://app.backboard.io/api"),
+		LLMProvider:      getenvDefault("BACKBOARD_LLM_PROVIDER", "openai"),
+		ModelName:        getenvDefault("BACKBOARD_MODEL_NAME", "gpt-4o"),
+		MemoryMode:       getenvDefault("BACKBOARD_MEMORY_MODE", "Auto"),
+		WebSearchMode:    getenvDefault("BACKBOARD_WEB_SEARCH_MODE", "off"),
+		ServerAddr:       getenvDefault("WUVO_SERVER_ADDR", ":8080"),
+		ServerURL:        getenvDefault("WUVO_SERVER_URL", "http://127.0.0.1:8080"),
+		WorkspaceRoot:    workspaceRoot(),
+		DataDir:          getenvDefault("WUVO_DATA_DIR", ".wuvo"),
+		RequestTimeout:   durationDefault("WUVO_REQUEST_TIMEOUT", 120*time.Second),
+		MaxSubagents:     intDefault("WUVO_MAX_SUBAGENTS", 4),
+		MaxIterations:    intDefault("WUVO_MAX_ITERATIONS", 24),
+		MaxOrchRounds:    intDefault("WUVO_MAX_ORCH_ROUNDS", 3),
+		MaxPlanSize:      intDefault("WUVO_MAX_PLAN_SIZE", 6),
+		MaxTotalSubtasks: intDefault("WUVO_MAX_TOTAL_SUBTASKS", 24),
+		Strategy:         getenvDefault("WUVO_STRATEGY", "iterative"),
+		Verify:           boolDefault("WUVO_VERIFY", false),
+
+		ContextCompactTokens: intDefault("WUVO_CONTEXT_COMPACT_TOKENS", 32000),
+		ToolOutputMaxBytes:   intDefault("WUVO_TOOL_OUTPUT_MAX_BYTES", 32000),
+
+		ApprovalTimeout: durationDefault("WUVO_APPROVAL_TIMEOUT", 5*time.Minute),
+		AskUserTimeout:  durationDefault("WUVO_ASK_USER_TIMEOUT", 5*time.Minute),
+		FinishRetries:   intDefault("WUVO_FINISH_RETRIES", 2),
+
+		MaxDelegateDepth:  intDefault("WUVO_MAX_DELEGATE_DEPTH", 2),
+		MaxDelegateFanout: intDefault("WUVO_MAX_DELEGATE_FANOUT", 4),
+
+		SubtaskRetries:      countDefault("WUVO_SUBTASK_RETRIES", 1),
+		SubtaskRetryBackoff: durationDefault("WUVO_SUBTASK_RETRY_BACKOFF", 2*time.Second),
+		EscalationModel:     strings.TrimSpace(os.Getenv("WUVO_ESCALATION_MODEL")),
+
+		SubtaskTimeout: durationDefault("WUVO_SUBTASK_TIMEOUT", 5*time.Minute),
+
+		WorkspaceIsolation: getenvDefault("WUVO_WORKSPACE_ISOLATION", "shared"),
+		WorkspaceMerge:     boolDefault("WUVO_WORKSPACE_MERGE", false),
 	}
 
 	if cfg.BackboardAPIKey == "" {
 		return Config{}, fmt.Errorf("missing BACKBOARD_API_KEY")
 	}
 
+	rules, err := approvalRules(strings.TrimSpace(os.Getenv("WUVO_APPROVAL_POLICY")))
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.ApprovalRules = rules
+
+	ensembles, err := roleValues("WUVO_ENSEMBLE_ROLES", strconv.Atoi)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.EnsembleRoles = ensembles
+	if cfg.RoleTimeouts, err = roleValues("WUVO_ROLE_TIMEOUTS", time.ParseDuration); err != nil {
+		return Config{}, err
+	}
+	if cfg.RoleMaxIterations, err = roleValues("WUVO_ROLE_MAX_ITERATIONS", strconv.Atoi); err != nil {
+		return Config{}, err
+	}
+	cfg.EnsembleModels = listDefault("WUVO_ENSEMBLE_MODELS")
+	cfg.SearchProviders = listDefault("WUVO_SEARCH_PROVIDERS")
+	if len(cfg.SearchProviders) == 0 {
+		cfg.SearchProviders = []string{"jina"}
+	}
+	cfg.Fetchers = listDefault("WUVO_FETCHERS")
+	if len(cfg.Fetchers) == 0 {
+		cfg.Fetchers = []string{"native"}
+	}
+	cfg.FetchAllowPrivate = boolDefault("WUVO_FETCH_ALLOW_PRIVATE", false)
+	cfg.SearXNGURL = strings.TrimSpace(os.Getenv("WUVO_SEARXNG_URL"))
+	cfg.SearchFixture = strings.TrimSpace(os.Getenv("WUVO_SEARCH_FIXTURE"))
+
 	return cfg, nil
 }
 
@@ -74,6 +172,31 @@ func intDefault(key string, fallback int) int {
 	return n
 }
 
+// countDefault is like intDefault but accepts zero.
+func countDefault(key string, fallback int) int {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return fallback
+	}
+	return n
+}
+
+func boolDefault(key string, fallback bool) bool {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fallback
+	}
+	return b
+}
+
 func durationDefault(key string, fallback time.Duration) time.Duration {
 	v := strings.TrimSpace(os.Getenv(key))
 	if v == "" {
@@ -86,6 +209,57 @@ func durationDefault(key string, fallback time.Duration) time.Duration {
 	return d
 }
 
+// approvalRules accepts either an inline JSON array of rules or a path to a
+// JSON file containing one.
+func approvalRules(v string) ([]types.ApprovalRule, error) {
+	if v == "" {
+		return nil, nil
+	}
+	raw := []byte(v)
+	if !strings.HasPrefix(v, "[") {
+		b, err := os.ReadFile(v)
+		if err != nil {
+			return nil, fmt.Errorf("read WUVO_APPROVAL_POLICY: %w", err)
+		}
+		raw = b
+	}
+	var rules []types.ApprovalRule
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return nil, fmt.Errorf("parse WUVO_APPROVAL_POLICY: %w", err)
+	}
+	return rules, nil
+}
+
+// roleValues parses a comma separated list of role=value pairs, for example
+// "fact_checker=3,researcher=2" or "researcher=3m".
+func roleValues[T int | time.Duration](key string, parse func(string) (T, error)) (map[types.Role]T, error) {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return nil, nil
+	}
+	out := map[types.Role]T{}
+	for _, pair := range strings.Split(v, ",") {
+		name, raw, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		role := types.Role(strings.TrimSpace(name))
+		n, err := parse(strings.TrimSpace(raw))
+		if !ok || err != nil || n <= 0 || role.Normalize() != role {
+			return nil, fmt.Errorf("parse %s: invalid entry %q", key, pair)
+		}
+		out[role] = n
+	}
+	return out, nil
+}
+
+func listDefault(key string) []string {
+	out := make([]string, 0)
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 func workspaceRoot() string {
 	v := strings.TrimSpace(os.Getenv("WUVO_WORKSPACE_ROOT"))
 	if v != "" {
@@ -0,0 +1,344 @@
+// Package fetch retrieves web pages and converts them to markdown for
+// agents to read.
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"backboard-swarm/be/internal/runtime"
+)
+
+const (
+	// UserAgent identifies the native fetcher, and its first word is the
+	// token robots.txt groups are matched against.
+	UserAgent = "wuvo-fetch/1.0"
+
+	defaultTimeout   = 60 * time.Second
+	defaultMaxBytes  = 40000
+	maxBodyBytes     = 10 << 20
+	maxRobotsBytes   = 512 << 10
+	robotsCacheTTL   = time.Hour
+	maxErrorBodySize = 220
+	maxRedirects     = 10
+)
+
+// ErrDisallowed is returned for URLs robots.txt does not let us fetch.
+// Fallback does not try other fetchers for them.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// ErrPrivateAddress is returned for URLs that resolve to loopback, private
+// or link-local addresses, unless the fetcher allows them.
+var ErrPrivateAddress = errors.New("refusing to fetch a private or local address")
+
+type Page struct {
+	URL         string
+	StatusCode  int
+	ContentType string
+	Title       string
+	Markdown    string
+	Truncated   bool
+	Fetcher     string
+}
+
+type Fetcher interface {
+	Name() string
+	Fetch(ctx context.Context, rawURL string, maxBytes int) (Page, error)
+}
+
+// Settings holds what the fetchers need from the configuration.
+type Settings struct {
+	JinaAPIKey   string
+	Timeout      time.Duration
+	AllowPrivate bool
+}
+
+// New builds the named fetchers in order. With more than one, a fetch
+// falls back to the next fetcher when one fails.
+func New(names []string, s Settings) (Fetcher, error) {
+	fetchers := make(Fallback, 0, len(names))
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "native":
+			n := NewNative(s.Timeout)
+			n.SetAllowPrivate(s.AllowPrivate)
+			fetchers = append(fetchers, n)
+		case "jina":
+			fetchers = append(fetchers, NewJina(s.JinaAPIKey, s.Timeout))
+		default:
+			return nil, fmt.Errorf("unknown fetcher %q", name)
+		}
+	}
+	switch len(fetchers) {
+	case 0:
+		return nil, errors.New("no fetcher configured")
+	case 1:
+		return fetchers[0], nil
+	}
+	return fetchers, nil
+}
+
+// Fallback tries each fetcher in turn and returns the first page fetched,
+// or all their errors.
+type Fallback []Fetcher
+
+func (f Fallback) Name() string {
+	names := make([]string, len(f))
+	for i, p := range f {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (f Fallback) Fetch(ctx context.Context, rawURL string, maxBytes int) (Page, error) {
+	errs := make([]error, 0, len(f))
+	for _, p := range f {
+		page, err := p.Fetch(ctx, rawURL, maxBytes)
+		if err == nil {
+			return page, nil
+		}
+		if ctx.Err() != nil || errors.Is(err, ErrDisallowed) || errors.Is(err, ErrPrivateAddress) {
+			return Page{}, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	if len(errs) == 0 {
+		return Page{}, errors.New("no fetcher configured")
+	}
+	return Page{}, errors.Join(errs...)
+}
+
+// Native fetches pages itself. It honours robots.txt on every redirect
+// hop, refuses private and local addresses, and extracts the main content
+// of HTML pages and the text of PDFs, plain text and JSON.
+type Native struct {
+	client       *http.Client
+	robotsClient *http.Client
+	allowPrivate bool
+
+	mu     sync.Mutex
+	robots map[string]robotsEntry
+}
+
+type robotsEntry struct {
+	rules   robotsRules
+	fetched time.Time
+}
+
+func NewNative(timeout time.Duration) *Native {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	n := &Native{robots: make(map[string]robotsEntry)}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: n.checkAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	n.client = &http.Client{Timeout: timeout, Transport: transport, CheckRedirect: n.checkRedirect}
+	n.robotsClient = &http.Client{Timeout: timeout, Transport: transport}
+	return n
+}
+
+// SetAllowPrivate lets the fetcher reach loopback, private and link-local
+// addresses, such as an intranet or a local test server.
+func (n *Native) SetAllowPrivate(allow bool) {
+	n.allowPrivate = allow
+}
+
+// checkAddress runs before every connection, after DNS resolution, so a
+// public name resolving to a private address is refused too.
+func (n *Native) checkAddress(_, address string, _ syscall.RawConn) error {
+	if n.allowPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%s: %w", host, ErrPrivateAddress)
+	}
+	return nil
+}
+
+// checkRedirect applies robots.txt to each hop of a redirect chain.
+func (n *Native) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	allowed, err := n.allowed(req.Context(), req.URL)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("%s: %w", req.URL, ErrDisallowed)
+	}
+	return nil
+}
+
+func (n *Native) Name() string {
+	return "native"
+}
+
+func (n *Native) Fetch(ctx context.Context, rawURL string, maxBytes int) (Page, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Page{}, fmt.Errorf("invalid url %q", rawURL)
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+	allowed, err := n.allowed(ctx, u)
+	if err != nil {
+		return Page{}, err
+	}
+	if !allowed {
+		return Page{}, fmt.Errorf("%s: %w", rawURL, ErrDisallowed)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Page{}, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,application/json;q=0.9,application/pdf;q=0.8,*/*;q=0.1")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return Page{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes+1))
+	if err != nil {
+		return Page{}, err
+	}
+	cut := len(body) > maxBodyBytes
+	if cut {
+		body = body[:maxBodyBytes]
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Page{}, fmt.Errorf("fetch failed (%d): %s", resp.StatusCode, errorSnippet(body))
+	}
+
+	page := Page{URL: resp.Request.URL.String(), StatusCode: resp.StatusCode, Fetcher: n.Name()}
+	mediaType, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType, params, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+	page.ContentType = mediaType
+	text := func() string { return decodeCharset(body, params["charset"]) }
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		page.Title, page.Markdown = extractHTML(text(), resp.Request.URL)
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, body, "", "  "); err != nil {
+			pretty.Reset()
+			pretty.Write(body)
+		}
+		page.Markdown = "```json\n" + strings.TrimSpace(pretty.String()) + "\n```"
+	case mediaType == "application/pdf":
+		if cut {
+			return Page{}, fmt.Errorf("PDF is larger than %d bytes", maxBodyBytes)
+		}
+		if page.Markdown, err = pdfText(body); err != nil {
+			return Page{}, err
+		}
+	case strings.HasPrefix(mediaType, "text/"):
+		page.Markdown = strings.TrimSpace(text())
+	default:
+		return Page{}, fmt.Errorf("unsupported content type %s", mediaType)
+	}
+	page.Markdown, page.Truncated = clip(page.Markdown, maxBytes)
+	page.Truncated = page.Truncated || cut
+	return page, nil
+}
+
+// allowed checks the URL against the host's robots.txt, fetched once per
+// robotsCacheTTL. A missing robots.txt allows everything; one the server
+// fails to return disallows everything, as RFC 9309 asks.
+func (n *Native) allowed(ctx context.Context, u *url.URL) (bool, error) {
+	key := u.Scheme + "://" + u.Host
+	n.mu.Lock()
+	entry, ok := n.robots[key]
+	n.mu.Unlock()
+	if !ok || time.Since(entry.fetched) > robotsCacheTTL {
+		rules, err := n.fetchRobots(ctx, key)
+		if err != nil {
+			return false, err
+		}
+		entry = robotsEntry{rules: rules, fetched: time.Now()}
+		n.mu.Lock()
+		n.robots[key] = entry
+		n.mu.Unlock()
+	}
+	return entry.rules.allowed(u.RequestURI()), nil
+}
+
+func (n *Native) fetchRobots(ctx context.Context, origin string) (robotsRules, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	resp, err := n.robotsClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		return disallowAll, nil
+	case resp.StatusCode >= 300:
+		return allowAll, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsBytes))
+	if err != nil {
+		return nil, fmt.Errorf("fetch robots.txt: %w", err)
+	}
+	return parseRobots(string(body), strings.SplitN(UserAgent, "/", 2)[0]), nil
+}
+
+// decodeCharset converts Latin-1 and Windows-1252 bodies to UTF-8 and
+// replaces invalid sequences in anything else.
+func decodeCharset(body []byte, charset string) string {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "windows-1252", "cp1252", "us-ascii":
+		if !utf8.Valid(body) {
+			runes := make([]rune, len(body))
+			for i, c := range body {
+				runes[i] = rune(c)
+			}
+			return string(runes)
+		}
+	}
+	return strings.ToValidUTF8(string(body), "\uFFFD")
+}
+
+func clip(s string, maxBytes int) (string, bool) {
+	if len(s) <= maxBytes {
+		return s, false
+	}
+	return s[:runtime.RuneBoundary(s, maxBytes)], true
+}
+
+func errorSnippet(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if len(s) > maxErrorBodySize {
+		s = s[:runtime.RuneBoundary(s, maxErrorBodySize)] + "..."
+	}
+	return s
+}
@@ -0,0 +1,159 @@
+package fetch
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const articlePage = `<!DOCTYPE html>
+<html><head><title>Release &amp; Notes</title><style>p { color: red }</style><script>if (a < b) { document.write("<p>x</p>") }</script></head>
+<body>
+<nav><a href="/">Home</a> <a href="/blog">Blog</a></nav>
+<div class="cookie-banner">We use cookies</div>
+<article>
+<h1>Go 1.22 is released</h1>
+<p>The <b>latest</b> release adds <a href="/doc/go1.22">range over integers</a> and fixes loop variables.
+<p>Highlights:
+<ul><li>Loop variables are per-iteration<li>New <code>math/rand/v2</code> package
+<ol><li>nested one</ol></ul>
+<pre><code class="language-go">for i := range 10 {
+	fmt.Println(i)
+}</code></pre>
+<table><tr><th>Version<th>Date</tr><tr><td>1.22</td><td>2024-02-06</td></tr></table>
+<img src="gopher.png" alt="Gopher">
+<aside class="related">Related posts</aside>
+</article>
+<footer>Copyright</footer>
+</body></html>`
+
+func TestNativeFetchExtractsContent(t *testing.T) {
+	var pdf bytes.Buffer
+	zw := zlib.NewWriter(&pdf)
+	zw.Write([]byte("BT /F1 12 Tf 72 712 Td (Hello \\(PDF\\)) Tj 0 -14 Td [(Wor) -30 (ld) -400 (again)] TJ ET"))
+	zw.Close()
+	pdfDoc := fmt.Sprintf("%%PDF-1.4\n1 0 obj\n<< /Type /Page >>\nendobj\n4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n%%%%EOF\n", pdf.Len(), pdf.String())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /\n\nUser-agent: wuvo-fetch\nDisallow: /private\nAllow: /private/ok$\n")
+	})
+	handle := func(path, contentType, body string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("User-Agent") != UserAgent {
+				t.Errorf("unexpected user agent %q", r.Header.Get("User-Agent"))
+			}
+			w.Header().Set("Content-Type", contentType)
+			fmt.Fprint(w, body)
+		})
+	}
+	handle("/post", "text/html; charset=utf-8", articlePage)
+	handle("/data.json", "application/json", `{"a":[1,2]}`)
+	handle("/notes.txt", "text/plain; charset=iso-8859-1", "caf\xe9\n")
+	handle("/doc.pdf", "application/pdf", pdfDoc)
+	handle("/image.png", "image/png", "\x89PNG")
+	handle("/private/secret", "text/plain", "secret")
+	handle("/private/ok", "text/plain", "fine")
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/notes.txt", http.StatusFound) })
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/private/secret", http.StatusFound) })
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	if _, err := NewNative(0).Fetch(context.Background(), srv.URL+"/post", 0); !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("expected loopback to be refused by default, got %v", err)
+	}
+	n := NewNative(0)
+	n.SetAllowPrivate(true)
+	fetch := func(path string) Page {
+		page, err := n.Fetch(context.Background(), srv.URL+path, 0)
+		if err != nil {
+			t.Fatalf("fetch %s: %v", path, err)
+		}
+		return page
+	}
+
+	page := fetch("/post")
+	if page.Title != "Release & Notes" || page.ContentType != "text/html" || page.Fetcher != "native" {
+		t.Fatalf("unexpected page %+v", page)
+	}
+	for _, want := range []string{
+		"# Go 1.22 is released",
+		"The **latest** release adds [range over integers](" + srv.URL + "/doc/go1.22) and fixes loop variables.",
+		"- Loop variables are per-iteration\n- New `math/rand/v2` package\n  1. nested one",
+		"```go\nfor i := range 10 {\n\tfmt.Println(i)\n}\n```",
+		"| Version | Date |\n| --- | --- |\n| 1.22 | 2024-02-06 |",
+		"![Gopher](" + srv.URL + "/gopher.png)",
+	} {
+		if !strings.Contains(page.Markdown, want) {
+			t.Fatalf("expected %q in markdown:\n%s", want, page.Markdown)
+		}
+	}
+	for _, unwanted := range []string{"Home", "cookies", "Related", "Copyright", "document.write", "color"} {
+		if strings.Contains(page.Markdown, unwanted) {
+			t.Fatalf("expected %q to be dropped:\n%s", unwanted, page.Markdown)
+		}
+	}
+
+	if got := fetch("/data.json").Markdown; got != "```json\n{\n  \"a\": [\n    1,\n    2\n  ]\n}\n```" {
+		t.Fatalf("unexpected json markdown %q", got)
+	}
+	if got := fetch("/old"); got.Markdown != "café" || got.URL != srv.URL+"/notes.txt" {
+		t.Fatalf("unexpected text page %+v", got)
+	}
+	if got := fetch("/doc.pdf").Markdown; got != "Hello (PDF)\nWorld again" {
+		t.Fatalf("unexpected pdf text %q", got)
+	}
+	if got := fetch("/private/ok").Markdown; got != "fine" {
+		t.Fatalf("expected allow rule to win, got %q", got)
+	}
+	if page, _ := n.Fetch(context.Background(), srv.URL+"/post", 10); !page.Truncated || page.Markdown != "# Go 1.22 " {
+		t.Fatalf("expected markdown cut at max bytes, got %+v", page)
+	}
+	if _, err := n.Fetch(context.Background(), srv.URL+"/image.png", 0); err == nil || !strings.Contains(err.Error(), "unsupported content type image/png") {
+		t.Fatalf("expected image to be refused, got %v", err)
+	}
+
+	// A robots.txt refusal is final: the fallback does not route around it.
+	fallback := Fallback{n, NewNative(0)}
+	if _, err := fallback.Fetch(context.Background(), srv.URL+"/private/secret", 0); !errors.Is(err, ErrDisallowed) {
+		t.Fatalf("expected robots.txt to disallow, got %v", err)
+	}
+	if _, err := n.Fetch(context.Background(), srv.URL+"/moved", 0); !errors.Is(err, ErrDisallowed) {
+		t.Fatalf("expected robots.txt to apply to redirects, got %v", err)
+	}
+	if page, err := (Fallback{NewJina("", 0), n}).Fetch(context.Background(), srv.URL+"/notes.txt", 0); err != nil || page.Fetcher != "native" {
+		t.Fatalf("expected fallback to native, got %+v %v", page, err)
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	rules := parseRobots("User-agent: other\nDisallow: /\n\nUser-agent: *\nDisallow: /tmp/\nDisallow: /*.pdf$\nAllow: /tmp/public\n", "wuvo-fetch")
+	for path, want := range map[string]bool{
+		"/":                 true,
+		"/tmp/x":            false,
+		"/tmp/public/a":     true,
+		"/docs/a.pdf":       false,
+		"/docs/a.pdf?x=1":   true,
+		"/docs/a.pdfx":      true,
+		"/temporary/things": true,
+	} {
+		if got := rules.allowed(path); got != want {
+			t.Fatalf("allowed(%q) = %v, want %v", path, got, want)
+		}
+	}
+	if !parseRobots("User-agent: wuvo-fetch\nDisallow:\n\nUser-agent: *\nDisallow: /\n", "wuvo-fetch").allowed("/a") {
+		t.Fatal("expected our own empty group to win over *")
+	}
+	if !parseRobots("User-agent: Wuvo-Fetch/2.0\nDisallow:\n\nUser-agent: *\nDisallow: /\n", "wuvo-fetch").allowed("/a") {
+		t.Fatal("expected the product token to match case-insensitively")
+	}
+	if parseRobots("User-agent: fetch\nDisallow:\n\nUser-agent: *\nDisallow: /\n", "wuvo-fetch").allowed("/a") {
+		t.Fatal("expected a substring of our token not to match")
+	}
+}
@@ -0,0 +1,655 @@
+package fetch
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// node is an element, or a text node when tag is empty, of the loose tree
+// parseHTML builds. It only needs to be good enough to find the main content
+// of a page and render it as markdown.
+type node struct {
+	tag      string
+	text     string
+	attrs    map[string]string
+	parent   *node
+	children []*node
+}
+
+var (
+	voidTags = tagSet("area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "param", "source", "track", "wbr")
+	rawTags  = tagSet("script", "style", "textarea", "title", "noscript", "template")
+
+	blockTags = tagSet("address", "article", "aside", "blockquote", "body", "caption", "center", "dd", "details", "dialog", "div", "dl", "dt",
+		"fieldset", "figcaption", "figure", "footer", "form", "h1", "h2", "h3", "h4", "h5", "h6", "header", "hr", "html", "li", "main",
+		"nav", "ol", "p", "pre", "section", "summary", "table", "tbody", "td", "tfoot", "th", "thead", "tr", "ul")
+
+	// dropTags never hold a page's main content.
+	dropTags = tagSet("script", "style", "noscript", "template", "head", "nav", "aside", "form", "svg", "iframe", "button", "select", "input", "dialog")
+	// chromeTags are dropped outside of an article or main element.
+	chromeTags = tagSet("header", "footer")
+	dropRoles  = tagSet("navigation", "banner", "contentinfo", "complementary", "search", "dialog")
+
+	unlikelyRe = regexp.MustCompile(`(?i)\b(nav|navbar|menu|footer|sidebar|comments?|cookies?|consent|banner|ads?|advert\w*|promo|social|share|popup|modal|subscribe|newsletter|related|breadcrumbs?)\b`)
+	likelyRe   = regexp.MustCompile(`(?i)\b(article|content|main|post|entry|story|body)\b`)
+	spaceRe    = regexp.MustCompile(`[ \t\r\n\f]+`)
+)
+
+func tagSet(tags ...string) map[string]bool {
+	out := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		out[t] = true
+	}
+	return out
+}
+
+// impliedEnd lists, for tags that close an open sibling implicitly, the
+// tags they close and the tags that stop the search.
+var impliedEnd = map[string]struct{ closes, stop map[string]bool }{
+	"li":     {tagSet("li"), tagSet("ul", "ol")},
+	"dt":     {tagSet("dt", "dd"), tagSet("dl")},
+	"dd":     {tagSet("dt", "dd"), tagSet("dl")},
+	"tr":     {tagSet("tr", "td", "th"), tagSet("table", "thead", "tbody", "tfoot")},
+	"td":     {tagSet("td", "th"), tagSet("tr", "table")},
+	"th":     {tagSet("td", "th"), tagSet("tr", "table")},
+	"option": {tagSet("option"), tagSet("select")},
+}
+
+// parseHTML builds a tree from src, tolerating unclosed and stray tags the
+// way pages in the wild need.
+func parseHTML(src string) *node {
+	root := &node{tag: "#root"}
+	cur := root
+	for i := 0; i < len(src); {
+		lt := strings.IndexByte(src[i:], '<')
+		if lt < 0 {
+			cur.appendText(src[i:])
+			break
+		}
+		cur.appendText(src[i : i+lt])
+		i += lt
+		rest := src[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				return root
+			}
+			i += 4 + end + 3
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return root
+			}
+			i += end + 1
+		case strings.HasPrefix(rest, "</"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return root
+			}
+			cur = closeElement(cur, tagName(rest[2:end]))
+			i += end + 1
+		case len(rest) > 1 && isLetter(rest[1]):
+			el, selfClosing, n := parseTag(rest)
+			if n < 0 {
+				cur.appendText(rest)
+				return root
+			}
+			i += n
+			cur = openElement(cur, el.tag)
+			el.parent = cur
+			cur.children = append(cur.children, el)
+			if rawTags[el.tag] {
+				end := indexFold(src[i:], "</"+el.tag)
+				if end < 0 {
+					end = len(src) - i
+				}
+				el.children = []*node{{text: src[i : i+end], parent: el}}
+				i += end
+				if gt := strings.IndexByte(src[i:], '>'); gt >= 0 {
+					i += gt + 1
+				} else {
+					i = len(src)
+				}
+				continue
+			}
+			if !selfClosing && !voidTags[el.tag] {
+				cur = el
+			}
+		default:
+			cur.appendText("<")
+			i++
+		}
+	}
+	return root
+}
+
+// openElement closes the elements a new tag ends implicitly and returns the
+// node the new element belongs under.
+func openElement(cur *node, tag string) *node {
+	if rule, ok := impliedEnd[tag]; ok {
+		for n := cur; n != nil && !rule.stop[n.tag]; n = n.parent {
+			if rule.closes[n.tag] {
+				return n.parent
+			}
+		}
+		return cur
+	}
+	if blockTags[tag] {
+		// A block closes an open paragraph, looking past unclosed inline
+		// elements inside it.
+		for n := cur; n != nil; n = n.parent {
+			if n.tag == "p" {
+				return n.parent
+			}
+			if blockTags[n.tag] {
+				break
+			}
+		}
+	}
+	return cur
+}
+
+func closeElement(cur *node, tag string) *node {
+	for n := cur; n != nil; n = n.parent {
+		if n.tag == tag {
+			if n.parent == nil {
+				return n
+			}
+			return n.parent
+		}
+	}
+	return cur
+}
+
+// parseTag reads a start tag at the start of s and returns it with the
+// number of bytes it spans, or -1 when the tag is not terminated.
+func parseTag(s string) (*node, bool, int) {
+	i := 1
+	for i < len(s) && !isSpace(s[i]) && s[i] != '>' && s[i] != '/' {
+		i++
+	}
+	el := &node{tag: strings.ToLower(s[1:i]), attrs: map[string]string{}}
+	for i < len(s) {
+		for i < len(s) && (isSpace(s[i]) || s[i] == '/') {
+			if s[i] == '/' && i+1 < len(s) && s[i+1] == '>' {
+				return el, true, i + 2
+			}
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		if s[i] == '>' {
+			return el, false, i + 1
+		}
+		start := i
+		for i < len(s) && !isSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		name := strings.ToLower(s[start:i])
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		value := ""
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				end := strings.IndexByte(s[i+1:], s[i])
+				if end < 0 {
+					return nil, false, -1
+				}
+				value = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(s) && !isSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				value = s[start:i]
+			}
+		}
+		if name != "" {
+			el.attrs[name] = html.UnescapeString(value)
+		} else if i < len(s) && s[i] != '>' {
+			i++
+		}
+	}
+	return nil, false, -1
+}
+
+func tagName(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexFunc(s, func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' || r == '/' }); i >= 0 {
+		s = s[:i]
+	}
+	return strings.ToLower(s)
+}
+
+func (n *node) appendText(s string) {
+	if s == "" {
+		return
+	}
+	s = html.UnescapeString(s)
+	if last := len(n.children) - 1; last >= 0 && n.children[last].tag == "" {
+		n.children[last].text += s
+		return
+	}
+	n.children = append(n.children, &node{text: s, parent: n})
+}
+
+func (n *node) find(match func(*node) bool) *node {
+	if match(n) {
+		return n
+	}
+	for _, c := range n.children {
+		if found := c.find(match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func (n *node) walk(visit func(*node)) {
+	visit(n)
+	for _, c := range n.children {
+		c.walk(visit)
+	}
+}
+
+// textLen returns the length of n's text with whitespace collapsed, and how
+// much of it is link text.
+func (n *node) textLen() (int, int) {
+	if n.tag == "" {
+		return len(strings.TrimSpace(spaceRe.ReplaceAllString(n.text, " "))), 0
+	}
+	total, links := 0, 0
+	for _, c := range n.children {
+		t, l := c.textLen()
+		total += t
+		links += l
+	}
+	if n.tag == "a" {
+		links = total
+	}
+	return total, links
+}
+
+func (n *node) rawText() string {
+	if n.tag == "" {
+		return n.text
+	}
+	var b strings.Builder
+	for _, c := range n.children {
+		b.WriteString(c.rawText())
+	}
+	return b.String()
+}
+
+// extractHTML returns the page title and its main content as markdown.
+// Links and images are resolved against base.
+func extractHTML(src string, base *url.URL) (string, string) {
+	root := parseHTML(src)
+	title := ""
+	if t := root.find(func(n *node) bool { return n.tag == "title" }); t != nil {
+		title = strings.TrimSpace(spaceRe.ReplaceAllString(html.UnescapeString(t.rawText()), " "))
+	}
+	if b := root.find(func(n *node) bool { return n.tag == "base" && n.attrs["href"] != "" }); b != nil {
+		if u, err := base.Parse(b.attrs["href"]); err == nil {
+			base = u
+		}
+	}
+	prune(root, false)
+	content := mainContent(root)
+	if title == "" {
+		if h := content.find(func(n *node) bool { return n.tag == "h1" }); h != nil {
+			title = strings.TrimSpace(spaceRe.ReplaceAllString(h.rawText(), " "))
+		}
+	}
+	c := converter{base: base}
+	return title, strings.Join(c.blocks(content), "\n\n")
+}
+
+// prune removes scripts, navigation, page chrome and elements whose class
+// or id mark them as boilerplate.
+func prune(n *node, inContent bool) {
+	kept := n.children[:0]
+	for _, c := range n.children {
+		if c.tag != "" && boilerplate(c, inContent) {
+			continue
+		}
+		prune(c, inContent || c.tag == "article" || c.tag == "main")
+		kept = append(kept, c)
+	}
+	n.children = kept
+}
+
+func boilerplate(n *node, inContent bool) bool {
+	if dropTags[n.tag] || dropRoles[n.attrs["role"]] || (!inContent && chromeTags[n.tag]) {
+		return true
+	}
+	if _, hidden := n.attrs["hidden"]; hidden || n.attrs["aria-hidden"] == "true" {
+		return true
+	}
+	if n.tag == "body" || n.tag == "html" || n.tag == "article" || n.tag == "main" {
+		return false
+	}
+	marks := n.attrs["class"] + " " + n.attrs["id"]
+	return unlikelyRe.MatchString(marks) && !likelyRe.MatchString(marks)
+}
+
+// mainContent picks the article or main element with the most text. Without
+// one it scores containers by the paragraph text they hold, discounted by
+// link density, and falls back to the body.
+func mainContent(root *node) *node {
+	var best *node
+	bestLen := 0
+	root.walk(func(n *node) {
+		if n.tag == "article" || n.tag == "main" || n.attrs["role"] == "main" {
+			if l, _ := n.textLen(); l > bestLen {
+				best, bestLen = n, l
+			}
+		}
+	})
+	if best != nil {
+		return best
+	}
+
+	scores := map[*node]float64{}
+	root.walk(func(n *node) {
+		if n.tag != "p" && n.tag != "pre" && n.tag != "blockquote" {
+			return
+		}
+		l, _ := n.textLen()
+		if l < 25 {
+			return
+		}
+		score := 1 + float64(l)/100
+		if p := n.parent; p != nil {
+			scores[p] += score
+			if g := p.parent; g != nil {
+				scores[g] += score / 2
+			}
+		}
+	})
+	bestScore := 0.0
+	for n, score := range scores {
+		total, links := n.textLen()
+		if total > 0 {
+			score *= 1 - float64(links)/float64(total)
+		}
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best != nil && bestScore >= 3 {
+		return best
+	}
+	if body := root.find(func(n *node) bool { return n.tag == "body" }); body != nil {
+		return body
+	}
+	return root
+}
+
+type converter struct {
+	base *url.URL
+}
+
+// blocks renders n's children as markdown blocks, gathering runs of inline
+// content into paragraphs.
+func (c converter) blocks(n *node) []string {
+	var out []string
+	var para strings.Builder
+	flush := func() {
+		if s := cleanInline(para.String()); s != "" {
+			out = append(out, s)
+		}
+		para.Reset()
+	}
+	for _, ch := range n.children {
+		if ch.tag == "" || !blockTags[ch.tag] {
+			para.WriteString(c.inline(ch))
+			continue
+		}
+		flush()
+		out = append(out, c.block(ch)...)
+	}
+	flush()
+	return out
+}
+
+func (c converter) block(n *node) []string {
+	switch n.tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level, _ := strconv.Atoi(n.tag[1:])
+		if text := strings.ReplaceAll(c.inlineText(n), "\n", " "); text != "" {
+			return []string{strings.Repeat("#", level) + " " + text}
+		}
+		return nil
+	case "pre":
+		lang := ""
+		if code := n.find(func(m *node) bool { return m.tag == "code" }); code != nil {
+			for _, class := range strings.Fields(code.attrs["class"]) {
+				if l, ok := strings.CutPrefix(class, "language-"); ok {
+					lang = l
+				}
+			}
+		}
+		text := strings.Trim(n.rawText(), "\n")
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		return []string{"```" + lang + "\n" + text + "\n```"}
+	case "ul", "ol":
+		if list := c.list(n); list != "" {
+			return []string{list}
+		}
+		return nil
+	case "blockquote":
+		inner := strings.Join(c.blocks(n), "\n\n")
+		if inner == "" {
+			return nil
+		}
+		lines := strings.Split(inner, "\n")
+		for i, l := range lines {
+			lines[i] = strings.TrimRight("> "+l, " ")
+		}
+		return []string{strings.Join(lines, "\n")}
+	case "hr":
+		return []string{"---"}
+	case "table":
+		if table := c.table(n); table != "" {
+			return []string{table}
+		}
+		return nil
+	case "dt":
+		if text := c.inlineText(n); text != "" {
+			return []string{"**" + text + "**"}
+		}
+		return nil
+	}
+	return c.blocks(n)
+}
+
+func (c converter) list(n *node) string {
+	var lines []string
+	i := 0
+	for _, li := range n.children {
+		if li.tag != "li" {
+			continue
+		}
+		i++
+		marker := "- "
+		if n.tag == "ol" {
+			marker = strconv.Itoa(i) + ". "
+		}
+		indent := strings.Repeat(" ", len(marker))
+		for j, b := range c.blocks(li) {
+			for k, l := range strings.Split(b, "\n") {
+				switch {
+				case j == 0 && k == 0:
+					l = marker + l
+				case l != "":
+					l = indent + l
+				}
+				lines = append(lines, l)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c converter) table(n *node) string {
+	var rows [][]string
+	width := 0
+	n.walk(func(m *node) {
+		if m.tag != "tr" {
+			return
+		}
+		var row []string
+		for _, cell := range m.children {
+			if cell.tag == "td" || cell.tag == "th" {
+				row = append(row, strings.ReplaceAll(strings.ReplaceAll(c.inlineText(cell), "\n", " "), "|", `\|`))
+			}
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+			width = max(width, len(row))
+		}
+	})
+	if len(rows) == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(rows)+1)
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", width))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c converter) inlineText(n *node) string {
+	var b strings.Builder
+	for _, ch := range n.children {
+		b.WriteString(c.inline(ch))
+	}
+	return cleanInline(b.String())
+}
+
+// inline renders n as inline markdown. Whitespace is collapsed here and
+// trimmed by cleanInline once a paragraph is complete.
+func (c converter) inline(n *node) string {
+	if n.tag == "" {
+		return spaceRe.ReplaceAllString(n.text, " ")
+	}
+	switch n.tag {
+	case "br":
+		return "\n"
+	case "img":
+		src := c.resolve(n.attrs["src"])
+		alt := strings.TrimSpace(spaceRe.ReplaceAllString(n.attrs["alt"], " "))
+		if src == "" {
+			return alt
+		}
+		return "![" + alt + "](" + src + ")"
+	case "code", "kbd", "samp", "tt":
+		text := strings.TrimSpace(spaceRe.ReplaceAllString(n.rawText(), " "))
+		if text == "" {
+			return ""
+		}
+		return "`" + text + "`"
+	}
+	var b strings.Builder
+	for _, ch := range n.children {
+		b.WriteString(c.inline(ch))
+	}
+	text := b.String()
+	switch n.tag {
+	case "a":
+		href := c.resolve(n.attrs["href"])
+		if href == "" || strings.TrimSpace(text) == "" {
+			return text
+		}
+		return wrapInline(text, "[", "]("+href+")")
+	case "strong", "b":
+		return wrapInline(text, "**", "**")
+	case "em", "i":
+		return wrapInline(text, "*", "*")
+	case "del", "s", "strike":
+		return wrapInline(text, "~~", "~~")
+	}
+	if blockTags[n.tag] {
+		return " " + text + " "
+	}
+	return text
+}
+
+// resolve makes a link absolute, dropping in-page anchors and script links.
+func (c converter) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(strings.ToLower(ref), "javascript:") {
+		return ""
+	}
+	if c.base == nil {
+		return ref
+	}
+	u, err := c.base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return u.String()
+}
+
+// wrapInline puts open and close around text, keeping surrounding spaces
+// outside the markers.
+func wrapInline(text, open, close string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	lead := text[:strings.Index(text, trimmed)]
+	trail := text[len(lead)+len(trimmed):]
+	return lead + open + trimmed + close + trail
+}
+
+func cleanInline(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(spaceRe.ReplaceAllString(l, " "))
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// indexFold finds the ASCII string sub in s ignoring case.
+func indexFold(s, sub string) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		j := strings.IndexByte(s[i:], sub[0])
+		if j < 0 {
+			return -1
+		}
+		i += j
+		if i+len(sub) <= len(s) && strings.EqualFold(s[i:i+len(sub)], sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isLetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f'
+}
@@ -0,0 +1,64 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var jinaTitleRe = regexp.MustCompile(`(?m)^Title:\s*(.*)$`)
+
+// Jina fetches pages through the Jina Reader service, which renders
+// JavaScript-heavy pages the native fetcher cannot.
+type Jina struct {
+	apiKey string
+	client *http.Client
+}
+
+func NewJina(apiKey string, timeout time.Duration) *Jina {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Jina{apiKey: strings.TrimSpace(apiKey), client: &http.Client{Timeout: timeout}}
+}
+
+func (j *Jina) Name() string {
+	return "jina"
+}
+
+func (j *Jina) Fetch(ctx context.Context, rawURL string, maxBytes int) (Page, error) {
+	if j.apiKey == "" {
+		return Page{}, errors.New("JINA_API_KEY is required for the jina fetcher")
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://r.jina.ai/"+rawURL, nil)
+	if err != nil {
+		return Page{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+j.apiKey)
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return Page{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes+1)))
+	if err != nil {
+		return Page{}, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Page{}, fmt.Errorf("jina request failed (%d): %s", resp.StatusCode, errorSnippet(body))
+	}
+	page := Page{URL: rawURL, StatusCode: resp.StatusCode, ContentType: "text/markdown", Fetcher: j.Name()}
+	page.Markdown, page.Truncated = clip(string(body), maxBytes)
+	if m := jinaTitleRe.FindStringSubmatch(page.Markdown); m != nil {
+		page.Title = strings.TrimSpace(m[1])
+	}
+	return page, nil
+}
@@ -0,0 +1,297 @@
+package fetch
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const maxPDFStreamBytes = 16 << 20
+
+var blankLinesRe = regexp.MustCompile(`\n{3,}`)
+
+// pdfText extracts the text drawn by a PDF's content streams. It handles
+// uncompressed and Flate streams and simple font encodings, which covers
+// most generated documents; scanned pages and CID fonts without a Unicode
+// mapping yield no text.
+func pdfText(b []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(b, " \t\r\n"), []byte("%PDF-")) {
+		return "", errors.New("not a PDF document")
+	}
+	var out strings.Builder
+	for i := 0; ; {
+		s := bytes.Index(b[i:], []byte("stream"))
+		if s < 0 {
+			break
+		}
+		s += i
+		i = s + len("stream")
+		if s >= 3 && string(b[s-3:s]) == "end" {
+			continue
+		}
+		start := i
+		if start < len(b) && b[start] == '\r' {
+			start++
+		}
+		if start >= len(b) || b[start] != '\n' {
+			continue
+		}
+		start++
+		end := bytes.Index(b[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		data := b[start : start+end]
+		i = start + end + len("endstream")
+
+		dict := b[max(0, bytes.LastIndex(b[:s], []byte("obj"))):s]
+		if bytes.Contains(dict, []byte("/Image")) || bytes.Contains(dict, []byte("/XObject")) || bytes.Contains(dict, []byte("/FontFile")) {
+			continue
+		}
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			r, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				continue
+			}
+			data, _ = io.ReadAll(io.LimitReader(r, maxPDFStreamBytes))
+		} else if bytes.Contains(dict, []byte("/Filter")) {
+			continue
+		}
+		if text := contentText(data); strings.TrimSpace(text) != "" {
+			out.WriteString(text)
+			out.WriteString("\n\n")
+		}
+	}
+	lines := strings.Split(out.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " ")
+	}
+	text := strings.TrimSpace(blankLinesRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+	if text == "" {
+		return "", errors.New("no extractable text in PDF")
+	}
+	return text, nil
+}
+
+// contentText interprets the text operators of a content stream: strings
+// shown with Tj, TJ, ' and ", and line moves with Td, TD, T* and ET.
+func contentText(data []byte) string {
+	var out strings.Builder
+	var operands []any
+	inText := false
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case isPDFSpace(c):
+			i++
+		case c == '%':
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			s, n := pdfLiteral(data[i:])
+			operands = append(operands, s)
+			i += n
+		case c == '<' && i+1 < len(data) && data[i+1] == '<', c == '>' && i+1 < len(data) && data[i+1] == '>':
+			i += 2
+		case c == '<':
+			end := bytes.IndexByte(data[i:], '>')
+			if end < 0 {
+				return out.String()
+			}
+			operands = append(operands, pdfHex(data[i+1:i+end]))
+			i += end + 1
+		case c == '[':
+			operands = append(operands, '[')
+			i++
+		case c == ']':
+			// Collapse the array into the strings it shows, with wide
+			// negative kerning read as a word space.
+			open := len(operands) - 1
+			for open >= 0 && operands[open] != '[' {
+				open--
+			}
+			var b strings.Builder
+			for _, v := range operands[max(open+1, 0):] {
+				switch v := v.(type) {
+				case string:
+					b.WriteString(v)
+				case float64:
+					if v < -200 {
+						b.WriteByte(' ')
+					}
+				}
+			}
+			operands = append(operands[:max(open, 0)], pdfArray(b.String()))
+			i++
+		case c == '/' || c == '{' || c == '}' || c == ')':
+			i++
+			for i < len(data) && !isPDFSpace(data[i]) && !isPDFDelimiter(data[i]) {
+				i++
+			}
+		default:
+			start := i
+			for i < len(data) && !isPDFSpace(data[i]) && !isPDFDelimiter(data[i]) {
+				i++
+			}
+			if i == start {
+				i++
+				continue
+			}
+			word := string(data[start:i])
+			if f, err := strconv.ParseFloat(word, 64); err == nil {
+				operands = append(operands, f)
+				continue
+			}
+			switch word {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				out.WriteByte('\n')
+			case "Tj":
+				writeShown(&out, operands)
+			case "TJ":
+				writeShown(&out, operands)
+			case "'", "\"":
+				out.WriteByte('\n')
+				writeShown(&out, operands)
+			case "T*":
+				out.WriteByte('\n')
+			case "Td", "TD":
+				if len(operands) >= 2 {
+					if ty, ok := operands[len(operands)-1].(float64); ok && ty != 0 {
+						out.WriteByte('\n')
+					} else if inText {
+						out.WriteByte(' ')
+					}
+				}
+			}
+			operands = operands[:0]
+		}
+	}
+	return out.String()
+}
+
+// pdfArray marks text collected from a TJ array.
+type pdfArray string
+
+func writeShown(out *strings.Builder, operands []any) {
+	if len(operands) == 0 {
+		return
+	}
+	switch v := operands[len(operands)-1].(type) {
+	case string:
+		out.WriteString(v)
+	case pdfArray:
+		out.WriteString(string(v))
+	}
+}
+
+// pdfLiteral decodes a (string) at the start of b and returns it with the
+// number of bytes consumed.
+func pdfLiteral(b []byte) (string, int) {
+	var raw []byte
+	depth := 0
+	i := 0
+	for ; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c == '\\' && i+1 < len(b):
+			i++
+			switch e := b[i]; e {
+			case 'n':
+				raw = append(raw, '\n')
+			case 'r':
+				raw = append(raw, '\r')
+			case 't':
+				raw = append(raw, '\t')
+			case 'b':
+				raw = append(raw, '\b')
+			case 'f':
+				raw = append(raw, '\f')
+			case '\r', '\n':
+				if e == '\r' && i+1 < len(b) && b[i+1] == '\n' {
+					i++
+				}
+			default:
+				if e >= '0' && e <= '7' {
+					n := 0
+					j := i
+					for ; j < len(b) && j < i+3 && b[j] >= '0' && b[j] <= '7'; j++ {
+						n = n*8 + int(b[j]-'0')
+					}
+					raw = append(raw, byte(n))
+					i = j - 1
+				} else {
+					raw = append(raw, e)
+				}
+			}
+		case c == '(':
+			if depth > 0 {
+				raw = append(raw, c)
+			}
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return pdfString(raw), i + 1
+			}
+			raw = append(raw, c)
+		default:
+			raw = append(raw, c)
+		}
+	}
+	return pdfString(raw), i
+}
+
+func pdfHex(b []byte) string {
+	digits := make([]byte, 0, len(b))
+	for _, c := range b {
+		if !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	raw := make([]byte, 0, len(digits)/2)
+	for i := 0; i+1 < len(digits); i += 2 {
+		v, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			return ""
+		}
+		raw = append(raw, byte(v))
+	}
+	return pdfString(raw)
+}
+
+// pdfString decodes UTF-16 strings marked with a byte order mark and reads
+// anything else as Latin-1, close enough to the standard encodings.
+func pdfString(raw []byte) string {
+	if len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF {
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, len(raw))
+	for i, c := range raw {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
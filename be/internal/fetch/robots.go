@@ -0,0 +1,107 @@
+package fetch
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+)
+
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+// robotsRules are the rules of the robots.txt group that applies to us.
+type robotsRules []robotsRule
+
+var (
+	allowAll    = robotsRules{}
+	disallowAll = robotsRules{{allow: false, pattern: regexp.MustCompile(`^`)}}
+)
+
+// parseRobots reads robots.txt as RFC 9309 describes: the group naming
+// agent wins over the * group, and within a group the longest matching
+// rule decides, with allow winning ties.
+func parseRobots(body, agent string) robotsRules {
+	agent = strings.ToLower(agent)
+	var specific, wildcard robotsRules
+	matchedSpecific, matchedWildcard := false, false
+	var groupAgents []string
+	inRules := false
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if inRules {
+				groupAgents, inRules = nil, false
+			}
+			ua := productToken(value)
+			groupAgents = append(groupAgents, ua)
+			matchedWildcard = matchedWildcard || ua == "*"
+			matchedSpecific = matchedSpecific || ua == agent
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{allow: key == "allow", length: len(value), pattern: robotsPattern(value)}
+			for _, ua := range groupAgents {
+				switch {
+				case ua == "*":
+					wildcard = append(wildcard, rule)
+				case ua == agent:
+					specific = append(specific, rule)
+				}
+			}
+		}
+	}
+	if matchedSpecific {
+		return specific
+	}
+	if matchedWildcard {
+		return wildcard
+	}
+	return allowAll
+}
+
+// productToken lowercases a user-agent line's value and drops any version
+// or comment after the product name.
+func productToken(value string) string {
+	token, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(value)), "/")
+	token, _, _ = strings.Cut(token, " ")
+	return token
+}
+
+// robotsPattern turns a path pattern with * wildcards and an optional $
+// end anchor into a regexp.
+func robotsPattern(p string) *regexp.Regexp {
+	anchored := strings.HasSuffix(p, "$")
+	p = strings.TrimSuffix(p, "$")
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(p), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// allowed reports whether path, including any query, may be fetched.
+func (r robotsRules) allowed(path string) bool {
+	best, allow := -1, true
+	for _, rule := range r {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > best || rule.length == best && rule.allow {
+			best, allow = rule.length, rule.allow
+		}
+	}
+	return allow
+}
@@ -0,0 +1,56 @@
+package orchestrator
+
+import (
+	"fmt"
+	"time"
+
+	"backboard-swarm/be/internal/agent"
+	"backboard-swarm/be/internal/types"
+)
+
+// subtaskBudget resolves the wall-clock timeout and iteration budget for one
+// attempt: the planner's values first, then the role defaults, then the
+// global defaults.
+func (s *Swarm) subtaskBudget(task types.Subtask) (time.Duration, int) {
+	role := task.Role.Normalize()
+	timeout := time.Duration(task.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = s.cfg.RoleTimeouts[role]
+	}
+	if timeout <= 0 {
+		timeout = s.cfg.SubtaskTimeout
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+
+	iterations := task.MaxIterations
+	if iterations <= 0 {
+		iterations = s.cfg.RoleMaxIterations[role]
+	}
+	if iterations <= 0 || (s.cfg.MaxIterations > 0 && iterations > s.cfg.MaxIterations) {
+		iterations = s.cfg.MaxIterations
+	}
+	return timeout, iterations
+}
+
+// partialResult keeps what a cut-off agent reported so the orchestrator can
+// still use it. The result stays marked as failed.
+func (s *Swarm) partialResult(runID, agentID string, task types.Subtask, res agent.TaskResult, err error) types.SubtaskResult {
+	out := subtaskResult(task, res)
+	out.AgentID = agentID
+	out.Error = err.Error()
+	out.Failure = agent.ClassifyFailure(err)
+	out.Partial = true
+	s.emit(types.Event{
+		Type:      "agent_finished",
+		RunID:     runID,
+		AgentID:   agentID,
+		Role:      task.Role.Normalize(),
+		Status:    "partial",
+		Message:   fmt.Sprintf("cut off (%s); keeping %d partial finding(s)", out.Failure, len(out.Findings)),
+		Timestamp: time.Now().UTC(),
+		Meta:      map[string]any{"failure": out.Failure, "error": out.Error},
+	})
+	return out
+}
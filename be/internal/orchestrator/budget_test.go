@@ -0,0 +1,82 @@
+package orchestrator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"backboard-swarm/be/internal/agent"
+	"backboard-swarm/be/internal/config"
+	"backboard-swarm/be/internal/types"
+)
+
+func TestSubtaskBudgetPrecedence(t *testing.T) {
+	s := NewSwarm(&stuckRunner{}, config.Config{
+		MaxIterations:     24,
+		SubtaskTimeout:    time.Minute,
+		RoleTimeouts:      map[types.Role]time.Duration{types.RoleResearcher: 30 * time.Second},
+		RoleMaxIterations: map[types.Role]int{types.RoleResearcher: 8},
+	}, nil)
+
+	if timeout, iters := s.subtaskBudget(types.Subtask{Role: types.RoleResearcher}); timeout != 30*time.Second || iters != 8 {
+		t.Fatalf("expected role defaults, got %s %d", timeout, iters)
+	}
+	if timeout, iters := s.subtaskBudget(types.Subtask{Role: types.RoleResearcher, TimeoutSeconds: 5, MaxIterations: 99}); timeout != 5*time.Second || iters != 24 {
+		t.Fatalf("expected planner timeout and capped iterations, got %s %d", timeout, iters)
+	}
+	if timeout, iters := s.subtaskBudget(types.Subtask{Role: types.RoleCoder}); timeout != time.Minute || iters != 24 {
+		t.Fatalf("expected global defaults, got %s %d", timeout, iters)
+	}
+}
+
+func TestRunSubtasksCutsOffStuckAgentWithPartialFindings(t *testing.T) {
+	runner := &stuckRunner{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 2, MaxIterations: 24}, nil)
+
+	start := time.Now()
+	results := s.runSubtasks(context.Background(), "run-1", []types.Subtask{
+		{Role: types.RoleResearcher, Task: "stuck", TimeoutSeconds: 1, MaxIterations: 3},
+		{Role: types.RoleCoder, Task: "quick"},
+	})
+	if time.Since(start) > 3*time.Second {
+		t.Fatal("expected the stuck subtask to be cut off by its timeout")
+	}
+	stuck := results[0]
+	if !stuck.Partial || stuck.Failure != types.FailureTimeout || len(stuck.Findings) != 1 {
+		t.Fatalf("expected partial timeout result, got %+v", stuck)
+	}
+	if runner.maxIterations["stuck"] != 3 {
+		t.Fatalf("expected iteration budget to reach the runner, got %d", runner.maxIterations["stuck"])
+	}
+	if results[1].Error != "" {
+		t.Fatalf("expected quick subtask to succeed, got %+v", results[1])
+	}
+}
+
+type stuckRunner struct {
+	mu            sync.Mutex
+	maxIterations map[string]int
+}
+
+func (s *stuckRunner) RunTask(ctx context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	s.mu.Lock()
+	if s.maxIterations == nil {
+		s.maxIterations = map[string]int{}
+	}
+	s.maxIterations[in.Task] = in.MaxIterations
+	s.mu.Unlock()
+	if in.Task != "stuck" {
+		return agent.TaskResult{Summary: "done"}, nil
+	}
+	<-ctx.Done()
+	return agent.TaskResult{
+		Summary: "found two of three files",
+		Payload: []byte(`{"summary":"found two of three files","findings":["a.go and b.go use the old API"]}`),
+		Partial: true,
+	}, ctx.Err()
+}
+
+func (s *stuckRunner) EndRun(_ string) {}
+
+func (s *stuckRunner) ResetSession(_, _ string) {}
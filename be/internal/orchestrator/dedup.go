@@ -0,0 +1,129 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"backboard-swarm/be/internal/types"
+)
+
+// dedupThreshold is the token similarity at or above which two subtasks for
+// the same role count as the same work.
+const dedupThreshold = 0.8
+
+var dedupStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "of": true, "to": true, "in": true, "on": true,
+	"for": true, "with": true, "by": true, "is": true, "are": true, "be": true, "it": true, "its": true,
+	"this": true, "that": true, "from": true, "at": true, "as": true, "or": true, "please": true,
+}
+
+func (s *Swarm) recordExecuted(runID string, results []types.SubtaskResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.runs[runID]
+	if !ok {
+		return
+	}
+	for _, res := range results {
+		if res.Error == "" {
+			st.executed = append(st.executed, res)
+		}
+	}
+}
+
+// dedupeSubtasks drops subtasks that repeat work already done in this run;
+// earlier results stay in the run's findings, so nothing needs to be re-run.
+// A subtask that repeats an earlier item of the same plan is merged into it.
+func (s *Swarm) dedupeSubtasks(runID string, subtasks []types.Subtask) []types.Subtask {
+	s.mu.Lock()
+	var executed []types.SubtaskResult
+	if st, ok := s.runs[runID]; ok {
+		executed = append(executed, st.executed...)
+	}
+	s.mu.Unlock()
+
+	out := make([]types.Subtask, 0, len(subtasks))
+	for _, task := range subtasks {
+		if i, score, ok := findDuplicate(task, executed); ok {
+			s.emitDeduplicated(runID, task, executed[i].Subtask, executed[i].AgentID, score, "reused")
+			continue
+		}
+		kept := make([]types.SubtaskResult, 0, len(out))
+		for _, t := range out {
+			kept = append(kept, types.SubtaskResult{Subtask: t})
+		}
+		if i, score, ok := findDuplicate(task, kept); ok {
+			s.emitDeduplicated(runID, task, out[i], "", score, "merged")
+			out[i] = mergeSubtasks(out[i], task)
+			continue
+		}
+		out = append(out, task)
+	}
+	return out
+}
+
+// mergeSubtasks folds a near-duplicate into the subtask it repeats: any
+// words the duplicate adds are appended to the task, and its settings
+// raise the kept subtask's where they ask for more.
+func mergeSubtasks(kept, dup types.Subtask) types.Subtask {
+	tokens := subtaskTokens(kept.Task)
+	for tok := range subtaskTokens(dup.Task) {
+		if !tokens[tok] {
+			kept.Task = strings.TrimSpace(kept.Task) + "\nAlso: " + strings.TrimSpace(dup.Task)
+			break
+		}
+	}
+	kept.Ensemble = max(kept.Ensemble, dup.Ensemble)
+	kept.TimeoutSeconds = max(kept.TimeoutSeconds, dup.TimeoutSeconds)
+	kept.MaxIterations = max(kept.MaxIterations, dup.MaxIterations)
+	if dup.Retries != nil && (kept.Retries == nil || *dup.Retries > *kept.Retries) {
+		kept.Retries = dup.Retries
+	}
+	if kept.EscalateRole == "" {
+		kept.EscalateRole = dup.EscalateRole
+	}
+	return kept
+}
+
+// findDuplicate returns the index of the first candidate that repeats task.
+func findDuplicate(task types.Subtask, candidates []types.SubtaskResult) (int, float64, bool) {
+	tokens := subtaskTokens(task.Task)
+	for i, c := range candidates {
+		if c.Subtask.Role.Normalize() != task.Role.Normalize() {
+			continue
+		}
+		if score := jaccard(tokens, subtaskTokens(c.Subtask.Task)); score >= dedupThreshold {
+			return i, score, true
+		}
+	}
+	return 0, 0, false
+}
+
+func subtaskTokens(text string) map[string]bool {
+	out := map[string]bool{}
+	for _, tok := range answerTokens(text) {
+		if !dedupStopwords[tok] {
+			out[tok] = true
+		}
+	}
+	return out
+}
+
+func (s *Swarm) emitDeduplicated(runID string, task, original types.Subtask, agentID string, score float64, action string) {
+	s.emit(types.Event{
+		Type:      "subtask_deduplicated",
+		RunID:     runID,
+		AgentID:   "agent-0",
+		Role:      types.RoleOrchestrator,
+		Status:    action,
+		Message:   fmt.Sprintf("skipped %s subtask similar to earlier work (%.2f)", task.Role.Normalize(), score),
+		Timestamp: time.Now().UTC(),
+		Meta: map[string]any{
+			"subtask":        task,
+			"duplicate_of":   original,
+			"duplicate_from": agentID,
+			"similarity":     score,
+		},
+	})
+}
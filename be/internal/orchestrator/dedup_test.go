@@ -0,0 +1,96 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"backboard-swarm/be/internal/agent"
+	"backboard-swarm/be/internal/config"
+	"backboard-swarm/be/internal/types"
+)
+
+func TestDedupeSkipsRepeatedSubtasksAcrossRounds(t *testing.T) {
+	runner := &repeatingRunner{}
+	events := &eventLog{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 2}, events)
+
+	out, err := s.Execute(context.Background(), Request{RunID: "run-1", Task: "Go 1.22", Limits: Limits{MaxRounds: 3}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Summary != "final" {
+		t.Fatalf("expected forced finalize, got %q", out.Summary)
+	}
+	if strings.Join(runner.workerTasks, "|") != "Find the release date of Go 1.22|List the language changes in Go 1.22" {
+		t.Fatalf("expected each piece of work once, got %v", runner.workerTasks)
+	}
+	if n := events.count("subtask_deduplicated"); n != 2 {
+		t.Fatalf("expected 2 deduplication events, got %d", n)
+	}
+}
+
+func TestDedupeRequiresSameRole(t *testing.T) {
+	s := NewSwarm(&repeatingRunner{}, config.Config{}, nil)
+	s.startRun(Request{RunID: "run-1"})
+	s.recordExecuted("run-1", []types.SubtaskResult{{Subtask: types.Subtask{Role: types.RoleResearcher, Task: "check the changelog"}}})
+
+	got := s.dedupeSubtasks("run-1", []types.Subtask{
+		{Role: types.RoleFactChecker, Task: "check the changelog"},
+		{Role: types.RoleResearcher, Task: "Check the changelog."},
+	})
+	if len(got) != 1 || got[0].Role != types.RoleFactChecker {
+		t.Fatalf("expected only the other-role subtask to remain, got %+v", got)
+	}
+}
+
+func TestDedupeMergesDuplicatesWithinAPlan(t *testing.T) {
+	s := NewSwarm(&repeatingRunner{}, config.Config{}, nil)
+	s.startRun(Request{RunID: "run-1"})
+	retries := 2
+
+	got := s.dedupeSubtasks("run-1", []types.Subtask{
+		{Role: types.RoleResearcher, Task: "list the language changes in go 1.22 release"},
+		{Role: types.RoleResearcher, Task: "list the language changes in go 1.22 release notes", Retries: &retries, Ensemble: 3},
+	})
+	if len(got) != 1 {
+		t.Fatalf("expected the duplicate merged into one subtask, got %+v", got)
+	}
+	if !strings.Contains(got[0].Task, "Also: list the language changes in go 1.22 release notes") {
+		t.Fatalf("expected the duplicate's wording kept, got %q", got[0].Task)
+	}
+	if got[0].Ensemble != 3 || got[0].Retries == nil || *got[0].Retries != 2 {
+		t.Fatalf("expected the duplicate's settings merged, got %+v", got[0])
+	}
+}
+
+type repeatingRunner struct {
+	mu          sync.Mutex
+	decisions   int
+	workerTasks []string
+}
+
+func (r *repeatingRunner) RunTask(_ context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch in.FinishMode {
+	case types.FinishPlan:
+		return agent.TaskResult{Payload: []byte(`{"subtasks":[{"role":"researcher","task":"Find the release date of Go 1.22"}]}`)}, nil
+	case types.FinishDecision:
+		r.decisions++
+		switch {
+		case r.decisions == 1:
+			return agent.TaskResult{Payload: []byte(`{"action":"decompose","subtasks":[{"role":"researcher","task":"find the release date of Go 1.22."},{"role":"researcher","task":"List the language changes in Go 1.22"}]}`)}, nil
+		case strings.Contains(in.Task, "ROUND=2\n"):
+			return agent.TaskResult{Payload: []byte(`{"action":"decompose","subtasks":[{"role":"researcher","task":"List the language changes in Go 1.22"}]}`)}, nil
+		}
+		return agent.TaskResult{Payload: []byte(`{"action":"finalize","summary":"final"}`)}, nil
+	}
+	r.workerTasks = append(r.workerTasks, in.Task)
+	return agent.TaskResult{Summary: "ok"}, nil
+}
+
+func (r *repeatingRunner) EndRun(_ string) {}
+
+func (r *repeatingRunner) ResetSession(_, _ string) {}
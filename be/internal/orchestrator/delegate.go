@@ -0,0 +1,142 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"backboard-swarm/be/internal/types"
+)
+
+// delegator runs child subtasks for one agent. Children are numbered under
+// their parent (agent-2 spawns agent-2.1, agent-2.2, ...) and run one level
+// deeper, so the tree is visible from agent ids alone.
+type delegator struct {
+	s       *Swarm
+	runID   string
+	agentID string
+	depth   int
+
+	mu   sync.Mutex
+	next int
+}
+
+func (d *delegator) Delegate(ctx context.Context, subtasks []types.Subtask) ([]types.SubtaskResult, error) {
+	maxDepth, maxFanout := d.s.delegateLimits()
+	if d.depth >= maxDepth {
+		return nil, fmt.Errorf("delegation depth limit reached (%d)", maxDepth)
+	}
+	if len(subtasks) == 0 {
+		return nil, fmt.Errorf("no subtasks to delegate")
+	}
+	if len(subtasks) > maxFanout {
+		return nil, fmt.Errorf("too many subtasks: %d exceeds the fan-out limit of %d", len(subtasks), maxFanout)
+	}
+
+	requested := subtasks
+	subtasks = d.s.claimSubtasks(d.runID, d.agentID, "", subtasks)
+	if len(subtasks) == 0 {
+		return nil, fmt.Errorf("the run's subtask limit is used up")
+	}
+
+	d.mu.Lock()
+	ids := make([]string, len(subtasks))
+	for i := range subtasks {
+		d.next++
+		ids[i] = fmt.Sprintf("%s.%d", d.agentID, d.next)
+	}
+	d.mu.Unlock()
+
+	d.s.emit(types.Event{
+		Type:      "delegation_started",
+		RunID:     d.runID,
+		AgentID:   d.agentID,
+		Status:    "running",
+		Message:   fmt.Sprintf("delegating %d subtask(s)", len(subtasks)),
+		Timestamp: time.Now().UTC(),
+		Meta: map[string]any{
+			"parent_agent_id": d.agentID,
+			"children":        ids,
+			"depth":           d.depth + 1,
+		},
+	})
+
+	slots := d.s.childSlots(d.depth + 1)
+	results := make([]types.SubtaskResult, len(subtasks))
+	var wg sync.WaitGroup
+	for i := range subtasks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			task := types.Subtask{Role: subtasks[i].Role, Task: subtasks[i].Task}
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				results[i] = d.s.agentFailed(d.runID, ids[i], task, ctx.Err())
+				return
+			}
+			defer func() { <-slots }()
+
+			d.s.startAgent(d.runID, ids[i])
+			results[i] = d.s.runWithRetry(ctx, d.runID, ids[i], task, "", d.depth+1)
+			d.s.settleWorkspace(d.runID, ids[i], results[i])
+		}(i)
+	}
+	wg.Wait()
+	d.s.recordExecuted(d.runID, results)
+	for _, task := range requested[len(subtasks):] {
+		results = append(results, types.SubtaskResult{Subtask: task, Error: "not run: the run's subtask limit is used up"})
+	}
+
+	failed := 0
+	for _, res := range results {
+		if res.Error != "" {
+			failed++
+		}
+	}
+	d.s.emit(types.Event{
+		Type:      "delegation_finished",
+		RunID:     d.runID,
+		AgentID:   d.agentID,
+		Status:    "completed",
+		Message:   fmt.Sprintf("%d/%d delegated subtask(s) succeeded", len(results)-failed, len(results)),
+		Timestamp: time.Now().UTC(),
+		Meta: map[string]any{
+			"parent_agent_id": d.agentID,
+			"children":        ids,
+			"depth":           d.depth + 1,
+			"failed":          failed,
+		},
+	})
+	return results, nil
+}
+
+// childSlots returns the semaphore that bounds how many delegated agents at
+// one depth run at once across the swarm, MaxSubagents per depth. Parents
+// wait on their children, so each depth gets its own slots rather than
+// sharing the ones their parents hold.
+func (s *Swarm) childSlots(depth int) chan struct{} {
+	s.slotsMu.Lock()
+	defer s.slotsMu.Unlock()
+	if s.childSlotsByDepth == nil {
+		s.childSlotsByDepth = make(map[int]chan struct{})
+	}
+	slots, ok := s.childSlotsByDepth[depth]
+	if !ok {
+		slots = make(chan struct{}, max(s.cfg.MaxSubagents, 1))
+		s.childSlotsByDepth[depth] = slots
+	}
+	return slots
+}
+
+func (s *Swarm) delegateLimits() (int, int) {
+	depth, fanout := s.cfg.MaxDelegateDepth, s.cfg.MaxDelegateFanout
+	if depth <= 0 {
+		depth = 2
+	}
+	if fanout <= 0 {
+		fanout = 4
+	}
+	return depth, fanout
+}
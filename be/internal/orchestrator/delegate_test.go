@@ -0,0 +1,133 @@
+package orchestrator
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backboard-swarm/be/internal/agent"
+	"backboard-swarm/be/internal/config"
+	"backboard-swarm/be/internal/types"
+)
+
+func TestDelegationBuildsAgentTreeWithinLimits(t *testing.T) {
+	runner := &delegatingRunner{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 2, MaxDelegateDepth: 2, MaxDelegateFanout: 2}, nil)
+
+	results := s.runSubtasks(context.Background(), "run-1", []types.Subtask{{Role: types.RoleCoder, Task: "analyse files"}})
+	if results[0].Error != "" {
+		t.Fatalf("unexpected error: %s", results[0].Error)
+	}
+
+	want := []string{"agent-1", "agent-1.1", "agent-1.1.1", "agent-1.1.2", "agent-1.2", "agent-1.2.1", "agent-1.2.2"}
+	if got := runner.sortedIDs(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected agent tree: %v", got)
+	}
+	for _, id := range []string{"agent-1.1.1", "agent-1.2.2"} {
+		if !strings.Contains(runner.errs[id], "depth limit") {
+			t.Fatalf("expected %s to hit the depth limit, got %q", id, runner.errs[id])
+		}
+	}
+	if !strings.Contains(runner.errs["agent-1.overflow"], "fan-out limit") {
+		t.Fatalf("expected fan-out error, got %q", runner.errs["agent-1.overflow"])
+	}
+}
+
+func TestDelegatedChildrenShareTheSubagentLimit(t *testing.T) {
+	runner := &concurrencyRunner{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 2, MaxDelegateDepth: 1, MaxDelegateFanout: 4}, nil)
+
+	results := s.runSubtasks(context.Background(), "run-1", []types.Subtask{
+		{Role: types.RoleResearcher, Task: "first"},
+		{Role: types.RoleResearcher, Task: "second"},
+	})
+	for _, res := range results {
+		if res.Error != "" {
+			t.Fatalf("unexpected error: %s", res.Error)
+		}
+	}
+	if runner.children != 8 {
+		t.Fatalf("expected 8 children, got %d", runner.children)
+	}
+	if runner.peak > 2 {
+		t.Fatalf("expected at most 2 children at once, got %d", runner.peak)
+	}
+}
+
+type concurrencyRunner struct {
+	mu       sync.Mutex
+	active   int
+	peak     int
+	children int
+}
+
+func (c *concurrencyRunner) RunTask(ctx context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	if !strings.Contains(in.AgentID, ".") {
+		child := types.Subtask{Role: types.RoleResearcher, Task: "child of " + in.AgentID}
+		_, err := in.Delegator.Delegate(ctx, []types.Subtask{child, child, child, child})
+		return agent.TaskResult{Summary: "done"}, err
+	}
+	c.mu.Lock()
+	c.active++
+	c.children++
+	c.peak = max(c.peak, c.active)
+	c.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	c.mu.Lock()
+	c.active--
+	c.mu.Unlock()
+	return agent.TaskResult{Summary: "child done"}, nil
+}
+
+func (c *concurrencyRunner) EndRun(_ string) {}
+
+func (c *concurrencyRunner) ResetSession(_, _ string) {}
+
+type delegatingRunner struct {
+	mu   sync.Mutex
+	ids  []string
+	errs map[string]string
+}
+
+func (d *delegatingRunner) RunTask(ctx context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	d.mu.Lock()
+	d.ids = append(d.ids, in.AgentID)
+	if d.errs == nil {
+		d.errs = map[string]string{}
+	}
+	d.mu.Unlock()
+
+	child := types.Subtask{Role: types.RoleCoder, Task: "child of " + in.AgentID}
+	if in.AgentID == "agent-1" {
+		_, err := in.Delegator.Delegate(ctx, []types.Subtask{child, child, child})
+		d.record(in.AgentID+".overflow", err)
+	}
+	results, err := in.Delegator.Delegate(ctx, []types.Subtask{child, child})
+	d.record(in.AgentID, err)
+	return agent.TaskResult{Summary: in.AgentID + " done with " + strconv.Itoa(len(results)) + " children"}, nil
+}
+
+func (d *delegatingRunner) record(key string, err error) {
+	if err == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.errs[key] = err.Error()
+}
+
+func (d *delegatingRunner) sortedIDs() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := append([]string{}, d.ids...)
+	sort.Strings(out)
+	return out
+}
+
+func (d *delegatingRunner) EndRun(_ string) {}
+
+func (d *delegatingRunner) ResetSession(_, _ string) {}
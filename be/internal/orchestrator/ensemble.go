@@ -0,0 +1,234 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"backboard-swarm/be/internal/types"
+)
+
+const maxEnsemble = 5
+
+// ensembleSize is the number of agents a subtask runs on: the subtask's own
+// ensemble setting, else the configured size for its role, capped at
+// maxEnsemble.
+func (s *Swarm) ensembleSize(task types.Subtask) int {
+	n := task.Ensemble
+	if n <= 0 {
+		n = s.cfg.EnsembleRoles[task.Role.Normalize()]
+	}
+	if n <= 1 {
+		return 1
+	}
+	if n > maxEnsemble {
+		return maxEnsemble
+	}
+	return n
+}
+
+// ensembleModel spreads ensemble members across the configured models
+// round-robin. Single agents always use the default model.
+func (s *Swarm) ensembleModel(member, size int) string {
+	if size <= 1 || len(s.cfg.EnsembleModels) == 0 {
+		return ""
+	}
+	return s.cfg.EnsembleModels[member%len(s.cfg.EnsembleModels)]
+}
+
+// reconcile merges the answers of an ensemble. A strict majority of
+// equivalent answers wins outright; otherwise a judge agent merges them. The
+// agreement level is the mean pairwise similarity of the successful answers.
+// Only the workspace of the winning member is merged: the majority's, or the
+// one the read-only judge names as best. Every other member's is discarded.
+func (s *Swarm) reconcile(ctx context.Context, runID, agentID string, task types.Subtask, members []types.SubtaskResult) types.SubtaskResult {
+	ok := make([]types.SubtaskResult, 0, len(members))
+	for _, m := range members {
+		if m.Error == "" {
+			ok = append(ok, m)
+		}
+	}
+	if len(ok) == 0 {
+		s.settleMembers(runID, members, "")
+		out := members[0]
+		out.Subtask = task
+		out.Members = len(members)
+		return out
+	}
+
+	agreement := agreementLevel(ok)
+	method := "majority"
+	out, found := majorityAnswer(ok)
+	chosen := out.AgentID
+	if !found {
+		method = "judge"
+		judgeID := agentID + "-judge"
+		s.startAgent(runID, judgeID)
+		out = s.runAgentWith(ctx, runID, judgeID, types.Subtask{Role: task.Role, Task: ensembleJudgePrompt(task, ok)}, agentOptions{readOnly: true})
+		s.discardWorkspace(runID, judgeID)
+		if out.Error != "" {
+			method = "confidence"
+			out = mostConfident(ok)
+			chosen = out.AgentID
+		} else {
+			out.Summary, chosen = judgeChoice(out.Summary, ok)
+		}
+	}
+	s.settleMembers(runID, members, chosen)
+
+	seen := map[string]bool{}
+	out.Sources = nil
+	for _, m := range append(append([]types.SubtaskResult{}, ok...), out) {
+		for _, src := range m.Sources {
+			if src.ID != "" && !seen[src.ID] {
+				seen[src.ID] = true
+				out.Sources = append(out.Sources, src)
+			}
+		}
+	}
+	out.Subtask = task
+	out.AgentID = agentID
+	out.Members = len(members)
+	out.Agreement = agreement
+	out.Unsourced = unsourcedClaims(task.Role.Normalize(), out)
+
+	s.emit(types.Event{
+		Type:      "ensemble_reconciled",
+		RunID:     runID,
+		AgentID:   agentID,
+		Role:      task.Role.Normalize(),
+		Status:    method,
+		Message:   fmt.Sprintf("%d/%d agents answered, agreement %.2f", len(ok), len(members), agreement),
+		Timestamp: time.Now().UTC(),
+		Meta: map[string]any{
+			"agreement": agreement,
+			"members":   len(members),
+			"succeeded": len(ok),
+			"method":    method,
+		},
+	})
+	return out
+}
+
+func majorityAnswer(results []types.SubtaskResult) (types.SubtaskResult, bool) {
+	counts := map[string]int{}
+	first := map[string]int{}
+	for i, res := range results {
+		key := answerKey(res)
+		if _, ok := first[key]; !ok {
+			first[key] = i
+		}
+		counts[key]++
+	}
+	for key, n := range counts {
+		if key != "" && n*2 > len(results) {
+			return results[first[key]], true
+		}
+	}
+	return types.SubtaskResult{}, false
+}
+
+// answerKey normalizes an answer so trivially different phrasings of the
+// same structured answer (case, punctuation, finding order) compare equal.
+func answerKey(res types.SubtaskResult) string {
+	if len(res.Findings) == 0 {
+		return strings.Join(answerTokens(res.Summary), " ")
+	}
+	parts := make([]string, 0, len(res.Findings))
+	for _, f := range res.Findings {
+		parts = append(parts, strings.Join(answerTokens(f), " "))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "|")
+}
+
+func answerTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func agreementLevel(results []types.SubtaskResult) float64 {
+	if len(results) < 2 {
+		return 1
+	}
+	sets := make([]map[string]bool, len(results))
+	for i, res := range results {
+		sets[i] = map[string]bool{}
+		for _, tok := range answerTokens(res.Summary + " " + strings.Join(res.Findings, " ")) {
+			sets[i][tok] = true
+		}
+	}
+	total, pairs := 0.0, 0
+	for i := range sets {
+		for j := i + 1; j < len(sets); j++ {
+			total += jaccard(sets[i], sets[j])
+			pairs++
+		}
+	}
+	return total / float64(pairs)
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	inter := 0
+	for k := range a {
+		if b[k] {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+func mostConfident(results []types.SubtaskResult) types.SubtaskResult {
+	best := results[0]
+	for _, res := range results[1:] {
+		if res.Confidence > best.Confidence {
+			best = res
+		}
+	}
+	return best
+}
+
+var judgeBestRe = regexp.MustCompile(`(?mi)^\s*BEST:\s*(\d+)\s*$`)
+
+// judgeChoice strips the judge's BEST line from its summary and returns the
+// member it names, falling back to the most confident one.
+func judgeChoice(summary string, answers []types.SubtaskResult) (string, string) {
+	chosen := mostConfident(answers).AgentID
+	if m := judgeBestRe.FindStringSubmatch(summary); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil && n >= 1 && n <= len(answers) {
+			chosen = answers[n-1].AgentID
+		}
+		summary = strings.TrimSpace(judgeBestRe.ReplaceAllString(summary, ""))
+	}
+	return summary, chosen
+}
+
+func ensembleJudgePrompt(task types.Subtask, answers []types.SubtaskResult) string {
+	var builder strings.Builder
+	builder.WriteString("Several agents answered the same task independently. Reconcile their answers: keep what they agree on, resolve disagreements on the evidence, and say which points remain disputed.\n\nTASK:\n")
+	builder.WriteString(task.Task)
+	builder.WriteString("\n\nANSWERS:\n")
+	for i, res := range answers {
+		builder.WriteString(fmt.Sprintf("%d) %s\n", i+1, strings.TrimSpace(res.Summary)))
+		for _, f := range res.Findings {
+			builder.WriteString("- " + strings.TrimSpace(f) + "\n")
+		}
+		if res.Confidence > 0 {
+			builder.WriteString(fmt.Sprintf("confidence: %.2f\n", res.Confidence))
+		}
+		for _, src := range res.Sources {
+			builder.WriteString(fmt.Sprintf("source: [%s] %s\n", src.ID, src.URL))
+		}
+	}
+	builder.WriteString("\nYou cannot change files. End your summary with a line \"BEST: <n>\" naming the answer whose work should be kept.\n")
+	return builder.String()
+}
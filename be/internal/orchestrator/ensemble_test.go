@@ -0,0 +1,69 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"backboard-swarm/be/internal/agent"
+	"backboard-swarm/be/internal/config"
+	"backboard-swarm/be/internal/types"
+)
+
+func TestEnsembleMajorityAcrossModels(t *testing.T) {
+	runner := &ensembleRunner{answers: map[string]string{"m1": "Paris.", "m2": "paris", "m3": "Lyon"}}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 2, EnsembleModels: []string{"m1", "m2", "m3"}}, nil)
+
+	results := s.runSubtasks(context.Background(), "run-1", []types.Subtask{{Role: types.RoleResearcher, Task: "capital of France?", Ensemble: 3}})
+	if len(results) != 1 {
+		t.Fatalf("expected one reconciled result, got %d", len(results))
+	}
+	res := results[0]
+	if !strings.EqualFold(strings.Trim(res.Summary, "."), "paris") || res.Members != 3 {
+		t.Fatalf("expected majority answer from 3 members, got %+v", res)
+	}
+	if res.Agreement <= 0 || res.Agreement >= 1 {
+		t.Fatalf("expected partial agreement, got %v", res.Agreement)
+	}
+	if runner.judged {
+		t.Fatal("expected majority to skip the judge")
+	}
+}
+
+func TestEnsembleFallsBackToJudgeWithoutMajority(t *testing.T) {
+	runner := &ensembleRunner{answers: map[string]string{"m1": "Paris", "m2": "Lyon"}}
+	s := NewSwarm(runner, config.Config{
+		MaxSubagents:   4,
+		EnsembleRoles:  map[types.Role]int{types.RoleFactChecker: 2},
+		EnsembleModels: []string{"m1", "m2"},
+	}, nil)
+
+	results := s.runSubtasks(context.Background(), "run-1", []types.Subtask{{Role: types.RoleFactChecker, Task: "capital of France?"}})
+	if !runner.judged || results[0].Summary != "judged: Paris" {
+		t.Fatalf("expected judge merge, got %+v", results[0])
+	}
+	if results[0].Agreement != 0 {
+		t.Fatalf("expected no agreement, got %v", results[0].Agreement)
+	}
+}
+
+type ensembleRunner struct {
+	mu      sync.Mutex
+	answers map[string]string
+	judged  bool
+}
+
+func (e *ensembleRunner) RunTask(_ context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if strings.HasSuffix(in.AgentID, "-judge") {
+		e.judged = true
+		return agent.TaskResult{Summary: "judged: Paris\nBEST: 1"}, nil
+	}
+	return agent.TaskResult{Summary: e.answers[in.Model]}, nil
+}
+
+func (e *ensembleRunner) EndRun(_ string) {}
+
+func (e *ensembleRunner) ResetSession(_, _ string) {}
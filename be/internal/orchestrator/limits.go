@@ -0,0 +1,192 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"backboard-swarm/be/internal/agent"
+	"backboard-swarm/be/internal/types"
+)
+
+const (
+	defaultMaxPlanSize      = 6
+	defaultMaxTotalSubtasks = 24
+	defaultMaxRounds        = 3
+)
+
+// Limits bound how much work the planner may create. Zero fields fall back
+// to the configured defaults.
+type Limits struct {
+	MaxPlanSize      int
+	MaxTotalSubtasks int
+	MaxRounds        int
+}
+
+type runState struct {
+	limits   Limits
+	used     int
+	executed []types.SubtaskResult
+}
+
+func (s *Swarm) startRun(req Request) {
+	limits := Limits{
+		MaxPlanSize:      firstPositive(req.Limits.MaxPlanSize, s.cfg.MaxPlanSize, defaultMaxPlanSize),
+		MaxTotalSubtasks: firstPositive(req.Limits.MaxTotalSubtasks, s.cfg.MaxTotalSubtasks, defaultMaxTotalSubtasks),
+		MaxRounds:        firstPositive(req.Limits.MaxRounds, s.cfg.MaxOrchRounds, defaultMaxRounds),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs[req.RunID] = &runState{limits: limits}
+}
+
+func (s *Swarm) endRun(runID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.runs, runID)
+}
+
+func (s *Swarm) limits(runID string) Limits {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.runs[runID]; ok {
+		return st.limits
+	}
+	return Limits{
+		MaxPlanSize:      firstPositive(s.cfg.MaxPlanSize, defaultMaxPlanSize),
+		MaxTotalSubtasks: firstPositive(s.cfg.MaxTotalSubtasks, defaultMaxTotalSubtasks),
+		MaxRounds:        firstPositive(s.cfg.MaxOrchRounds, defaultMaxRounds),
+	}
+}
+
+// planBudget is the number of subtasks the next plan may contain: the plan
+// size limit, further capped by what is left of the run's total.
+func (s *Swarm) planBudget(runID string) int {
+	return max(min(s.limits(runID).MaxPlanSize, s.remainingSubtasks(runID)), 0)
+}
+
+// remainingSubtasks is what is left of the run's total subtask limit.
+func (s *Swarm) remainingSubtasks(runID string) int {
+	limits := s.limits(runID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remaining := limits.MaxTotalSubtasks
+	if st, ok := s.runs[runID]; ok {
+		remaining -= st.used
+	}
+	return max(remaining, 0)
+}
+
+// claimSubtasks counts subtasks that do not come from a plan, such as
+// map-reduce inputs and delegated children, against the run's total limit.
+// Whatever does not fit is dropped and reported with a plan_truncated event.
+func (s *Swarm) claimSubtasks(runID, agentID string, role types.Role, subtasks []types.Subtask) []types.Subtask {
+	s.mu.Lock()
+	st, ok := s.runs[runID]
+	if !ok {
+		s.mu.Unlock()
+		return subtasks
+	}
+	remaining := max(st.limits.MaxTotalSubtasks-st.used, 0)
+	n := min(len(subtasks), remaining)
+	st.used += n
+	s.mu.Unlock()
+
+	if n < len(subtasks) {
+		s.emitTruncated(runID, agentID, role, remaining, subtasks[n:])
+	}
+	return subtasks[:n]
+}
+
+func (s *Swarm) emitTruncated(runID, agentID string, role types.Role, limit int, dropped []types.Subtask) {
+	s.emit(types.Event{
+		Type:      "plan_truncated",
+		RunID:     runID,
+		AgentID:   agentID,
+		Role:      role,
+		Status:    "truncated",
+		Message:   fmt.Sprintf("dropped %d subtask(s) over the limit of %d", len(dropped), limit),
+		Timestamp: time.Now().UTC(),
+		Meta:      map[string]any{"limit": limit, "dropped": dropped},
+	})
+}
+
+func (s *Swarm) useSubtasks(runID string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.runs[runID]; ok {
+		st.used += n
+	}
+}
+
+// limitsHeader is the block of limit variables prepended to orchestrator
+// prompts.
+func (s *Swarm) limitsHeader(runID string) string {
+	limits := s.limits(runID)
+	return fmt.Sprintf("MAX_PLAN_SIZE=%d\nREMAINING_SUBTASKS=%d\n", limits.MaxPlanSize, s.planBudget(runID))
+}
+
+// fitPlan enforces the plan budget. An oversized plan is sent back to the
+// planner once to be merged; whatever still does not fit is dropped and
+// reported with a plan_truncated event.
+func (s *Swarm) fitPlan(ctx context.Context, runID, task string, subtasks []types.Subtask) []types.Subtask {
+	budget := s.planBudget(runID)
+	if len(subtasks) > budget && budget > 0 {
+		s.emit(types.Event{
+			Type:      "agent_status",
+			RunID:     runID,
+			AgentID:   "agent-0",
+			Role:      types.RoleOrchestrator,
+			Status:    "merging_plan",
+			Message:   fmt.Sprintf("plan has %d subtask(s), limit is %d; asking the planner to merge", len(subtasks), budget),
+			Timestamp: time.Now().UTC(),
+		})
+		if merged, err := s.mergePlan(ctx, runID, task, subtasks, budget); err == nil && len(merged) > 0 {
+			subtasks = merged
+		}
+	}
+	if len(subtasks) > budget {
+		s.emitTruncated(runID, "agent-0", types.RoleOrchestrator, budget, subtasks[budget:])
+		subtasks = subtasks[:budget]
+	}
+	s.useSubtasks(runID, len(subtasks))
+	return subtasks
+}
+
+func (s *Swarm) mergePlan(ctx context.Context, runID, task string, subtasks []types.Subtask, budget int) ([]types.Subtask, error) {
+	plan, _ := json.MarshalIndent(map[string]any{"subtasks": subtasks}, "", "  ")
+	var builder strings.Builder
+	builder.WriteString("MODE: DECOMPOSE\n")
+	builder.WriteString(fmt.Sprintf("MAX_PLAN_SIZE=%d\n", budget))
+	builder.WriteString(fmt.Sprintf("\nYour plan has %d subtasks but at most %d can run. Merge related subtasks so that no work is lost and the plan has at most %d subtasks.\n", len(subtasks), budget, budget))
+	builder.WriteString("\nUSER_TASK:\n")
+	builder.WriteString(task)
+	builder.WriteString("\n\nPLAN:\n")
+	builder.Write(plan)
+
+	res, err := s.runner.RunTask(ctx, agent.TaskInput{
+		RunID:      runID,
+		AgentID:    "agent-0",
+		Role:       types.RoleOrchestrator,
+		Task:       builder.String(),
+		FinishMode: types.FinishPlan,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if merged := parseSubtasks(string(res.Payload)); len(merged) > 0 {
+		return merged, nil
+	}
+	return parseSubtasks(firstNonEmpty(res.Summary, res.Raw)), nil
+}
+
+func firstPositive(values ...int) int {
+	for _, v := range values {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
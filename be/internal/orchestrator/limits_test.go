@@ -0,0 +1,228 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"backboard-swarm/be/internal/agent"
+	"backboard-swarm/be/internal/config"
+	"backboard-swarm/be/internal/types"
+)
+
+func TestPlanLimitsMergeTruncateAndBudget(t *testing.T) {
+	runner := &limitsRunner{}
+	events := &eventLog{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 4}, events)
+
+	_, err := s.Execute(context.Background(), Request{
+		RunID:  "run-1",
+		Task:   "audit the repo",
+		Limits: Limits{MaxPlanSize: 3, MaxTotalSubtasks: 4, MaxRounds: 2},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(runner.prompts[0], "MAX_PLAN_SIZE=3\nREMAINING_SUBTASKS=3") {
+		t.Fatalf("expected limits in decompose prompt, got %s", runner.prompts[0])
+	}
+	if !strings.Contains(runner.prompts[1], "at most 3 can run") {
+		t.Fatalf("expected merge request, got %s", runner.prompts[1])
+	}
+	if got := runner.workers; got != 3+1 {
+		t.Fatalf("expected 3 first-round and 1 second-round subtasks, got %d", got)
+	}
+	truncations := events.count("plan_truncated")
+	if truncations != 2 {
+		t.Fatalf("expected both rounds to report truncation, got %d", truncations)
+	}
+}
+
+type limitsRunner struct {
+	mu      sync.Mutex
+	prompts []string
+	workers int
+}
+
+func (l *limitsRunner) RunTask(_ context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch in.FinishMode {
+	case types.FinishPlan:
+		l.prompts = append(l.prompts, in.Task)
+		n := 8
+		if strings.Contains(in.Task, "PLAN:") {
+			n = 5
+		}
+		return agent.TaskResult{Payload: plan(n)}, nil
+	case types.FinishDecision:
+		l.prompts = append(l.prompts, in.Task)
+		if strings.Contains(in.Task, "ROUND=1\n") {
+			return agent.TaskResult{Payload: []byte(`{"action":"decompose","subtasks":[{"role":"coder","task":"x"},{"role":"coder","task":"y"}]}`)}, nil
+		}
+		return agent.TaskResult{Payload: []byte(`{"action":"finalize","summary":"done"}`)}, nil
+	}
+	l.workers++
+	return agent.TaskResult{Summary: "ok"}, nil
+}
+
+func plan(n int) []byte {
+	items := make([]string, n)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"role":"coder","task":"part %d"}`, i+1)
+	}
+	return []byte(`{"subtasks":[` + strings.Join(items, ",") + `]}`)
+}
+
+func (l *limitsRunner) EndRun(_ string) {}
+
+func (l *limitsRunner) ResetSession(_, _ string) {}
+
+type eventLog struct {
+	mu     sync.Mutex
+	events []types.Event
+}
+
+func (e *eventLog) Emit(evt types.Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, evt)
+}
+
+func (e *eventLog) count(eventType string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	n := 0
+	for _, evt := range e.events {
+		if evt.Type == eventType {
+			n++
+		}
+	}
+	return n
+}
+
+func (e *eventLog) has(eventType, message string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, evt := range e.events {
+		if evt.Type == eventType && evt.Message == message {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEmptyPlanFallbackCountsAgainstBudget(t *testing.T) {
+	runner := &emptyPlanRunner{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 2}, nil)
+
+	if _, err := s.Execute(context.Background(), Request{
+		RunID:  "run-1",
+		Task:   "fix the bug",
+		Limits: Limits{MaxPlanSize: 3, MaxTotalSubtasks: 1, MaxRounds: 2},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runner.workers != 1 {
+		t.Fatalf("expected only the fallback coder to run, got %d workers", runner.workers)
+	}
+	if !strings.Contains(runner.decision, "REMAINING_SUBTASKS=0") {
+		t.Fatalf("expected the fallback to use up the budget, got %s", runner.decision)
+	}
+}
+
+type emptyPlanRunner struct {
+	mu       sync.Mutex
+	workers  int
+	decision string
+}
+
+func (e *emptyPlanRunner) RunTask(_ context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch in.FinishMode {
+	case types.FinishPlan:
+		return agent.TaskResult{Payload: []byte(`{"subtasks":[]}`)}, nil
+	case types.FinishDecision:
+		if e.decision == "" {
+			e.decision = in.Task
+			return agent.TaskResult{Payload: []byte(`{"action":"decompose","subtasks":[{"role":"coder","task":"more"}]}`)}, nil
+		}
+		return agent.TaskResult{Payload: []byte(`{"action":"finalize","summary":"done"}`)}, nil
+	}
+	e.workers++
+	return agent.TaskResult{Summary: "ok"}, nil
+}
+
+func (e *emptyPlanRunner) EndRun(_ string) {}
+
+func (e *emptyPlanRunner) ResetSession(_, _ string) {}
+
+func TestStrategiesAndDelegationCountAgainstBudget(t *testing.T) {
+	limits := Limits{MaxPlanSize: 3, MaxTotalSubtasks: 3, MaxRounds: 3}
+
+	runner := &limitsRunner{}
+	events := &eventLog{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 4}, events)
+	if _, err := s.Execute(context.Background(), Request{RunID: "map", Task: "summarize", Strategy: StrategyMapReduce, Inputs: []string{"a", "b", "c", "d", "e"}, Limits: limits}); err != nil {
+		t.Fatalf("map-reduce: %v", err)
+	}
+	if runner.workers != 3 || !events.has("plan_truncated", "dropped 2 subtask(s) over the limit of 3") {
+		t.Fatalf("expected map-reduce trimmed to 3 subtasks, got %d workers and %+v", runner.workers, events.events)
+	}
+
+	runner = &limitsRunner{}
+	s = NewSwarm(runner, config.Config{MaxSubagents: 4}, &eventLog{})
+	if _, err := s.Execute(context.Background(), Request{RunID: "debate", Task: "tabs or spaces", Strategy: StrategyDebate, Limits: limits}); err != nil {
+		t.Fatalf("debate: %v", err)
+	}
+	if runner.workers != 2 {
+		t.Fatalf("expected the debate cut to one turn, got %d workers", runner.workers)
+	}
+
+	delegating := &budgetDelegateRunner{}
+	s = NewSwarm(delegating, config.Config{MaxSubagents: 4, MaxDelegateFanout: 4}, &eventLog{})
+	if _, err := s.Execute(context.Background(), Request{RunID: "tree", Task: "fix it", Limits: Limits{MaxPlanSize: 3, MaxTotalSubtasks: 2, MaxRounds: 1}}); err != nil {
+		t.Fatalf("delegation: %v", err)
+	}
+	if delegating.children != 1 || len(delegating.results) != 3 || delegating.results[2].Error == "" {
+		t.Fatalf("expected one child to run and two to be refused, got %d children and %+v", delegating.children, delegating.results)
+	}
+}
+
+type budgetDelegateRunner struct {
+	mu       sync.Mutex
+	children int
+	results  []types.SubtaskResult
+}
+
+func (b *budgetDelegateRunner) RunTask(ctx context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	switch in.FinishMode {
+	case types.FinishPlan:
+		return agent.TaskResult{Payload: plan(1)}, nil
+	case types.FinishDecision:
+		return agent.TaskResult{Payload: []byte(`{"action":"finalize","summary":"done"}`)}, nil
+	}
+	if in.AgentID == "agent-1" {
+		child := types.Subtask{Role: types.RoleCoder, Task: "child"}
+		results, err := in.Delegator.Delegate(ctx, []types.Subtask{child, child, child})
+		if err != nil {
+			return agent.TaskResult{}, err
+		}
+		b.mu.Lock()
+		b.results = results
+		b.mu.Unlock()
+		return agent.TaskResult{Summary: "delegated"}, nil
+	}
+	b.mu.Lock()
+	b.children++
+	b.mu.Unlock()
+	return agent.TaskResult{Summary: "child done"}, nil
+}
+
+func (b *budgetDelegateRunner) EndRun(_ string) {}
+
+func (b *budgetDelegateRunner) ResetSession(_, _ string) {}
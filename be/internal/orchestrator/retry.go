@@ -0,0 +1,79 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"backboard-swarm/be/internal/types"
+)
+
+const maxSubtaskRetries = 3
+
+// runWithRetry runs a subtask and retries failures with linear backoff before
+// the orchestrator sees them. Outages and timeouts are retried as-is; other
+// failures escalate to the configured stronger model and the subtask's
+// escalation role, when set. A failed attempt's workspace is discarded, so
+// each retry starts clean and the caller settles only the last attempt.
+func (s *Swarm) runWithRetry(ctx context.Context, runID, agentID string, task types.Subtask, model string, depth int) types.SubtaskResult {
+	retries := s.cfg.SubtaskRetries
+	if task.Retries != nil {
+		retries = *task.Retries
+	}
+	retries = min(max(retries, 0), maxSubtaskRetries)
+
+	res := s.runAgentWith(ctx, runID, agentID, task, agentOptions{model: model, depth: depth})
+	attempt := 1
+	for res.Error != "" && attempt <= retries && ctx.Err() == nil {
+		task, model = s.escalate(task, model, res.Failure)
+		delay := time.Duration(attempt) * s.cfg.SubtaskRetryBackoff
+		s.emit(types.Event{
+			Type:      "subtask_retry",
+			RunID:     runID,
+			AgentID:   agentID,
+			Role:      task.Role.Normalize(),
+			Status:    string(res.Failure),
+			Message:   fmt.Sprintf("retrying after %s (attempt %d/%d) in %s", res.Failure, attempt+1, retries+1, delay),
+			Timestamp: time.Now().UTC(),
+			Meta: map[string]any{
+				"attempt": attempt + 1,
+				"failure": res.Failure,
+				"error":   res.Error,
+				"model":   model,
+				"role":    task.Role.Normalize(),
+			},
+		})
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		s.discardWorkspace(runID, agentID)
+		s.runner.ResetSession(runID, agentID)
+		prev := res
+		res = s.runAgentWith(ctx, runID, agentID, task, agentOptions{model: model, depth: depth})
+		if res.Error != "" && !res.Partial && prev.Partial {
+			// Keep the earlier partial findings rather than an empty failure.
+			prev.Error, prev.Failure = res.Error, res.Failure
+			res = prev
+		}
+		attempt++
+	}
+	res.Attempts = attempt
+	return res
+}
+
+func (s *Swarm) escalate(task types.Subtask, model string, failure types.FailureKind) (types.Subtask, string) {
+	if failure == types.FailureBackboard || failure == types.FailureTimeout {
+		return task, model
+	}
+	if s.cfg.EscalationModel != "" {
+		model = s.cfg.EscalationModel
+	}
+	if task.EscalateRole != "" {
+		task.Role = task.EscalateRole.Normalize()
+	}
+	return task, model
+}
@@ -0,0 +1,63 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"backboard-swarm/be/internal/agent"
+	"backboard-swarm/be/internal/config"
+	"backboard-swarm/be/internal/types"
+)
+
+func TestRunSubtasksRetriesAndEscalates(t *testing.T) {
+	runner := &flakyRunner{failures: 1}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 1, SubtaskRetries: 2, EscalationModel: "openai/gpt-4.1"}, nil)
+
+	res := s.runSubtasks(context.Background(), "run-1", []types.Subtask{{Role: types.RoleResearcher, Task: "dig", EscalateRole: types.RoleCoder}})[0]
+	if res.Error != "" || res.Attempts != 2 {
+		t.Fatalf("expected success on second attempt, got %+v", res)
+	}
+	if len(runner.calls) != 2 || runner.calls[1].Model != "openai/gpt-4.1" || runner.calls[1].Role != types.RoleCoder {
+		t.Fatalf("expected escalated retry, got %+v", runner.calls)
+	}
+}
+
+func TestRunSubtasksReportsFailureKindAfterRetries(t *testing.T) {
+	zero := 0
+	runner := &flakyRunner{failures: 5}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 1, SubtaskRetries: 2}, nil)
+
+	res := s.runSubtasks(context.Background(), "run-1", []types.Subtask{{Role: types.RoleCoder, Task: "dig"}})[0]
+	if res.Failure != types.FailureMaxIterations || res.Attempts != 3 {
+		t.Fatalf("expected classified failure after 3 attempts, got %+v", res)
+	}
+
+	runner = &flakyRunner{failures: 5}
+	s = NewSwarm(runner, config.Config{MaxSubagents: 1, SubtaskRetries: 2}, nil)
+	res = s.runSubtasks(context.Background(), "run-1", []types.Subtask{{Role: types.RoleCoder, Task: "dig", Retries: &zero}})[0]
+	if res.Attempts != 1 || len(runner.calls) != 1 {
+		t.Fatalf("expected per-subtask override to disable retries, got %+v", res)
+	}
+}
+
+type flakyRunner struct {
+	mu       sync.Mutex
+	failures int
+	calls    []agent.TaskInput
+}
+
+func (f *flakyRunner) RunTask(_ context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, in)
+	if len(f.calls) <= f.failures {
+		return agent.TaskResult{}, fmt.Errorf("%w (24)", agent.ErrMaxIterations)
+	}
+	return agent.TaskResult{Summary: "done"}, nil
+}
+
+func (f *flakyRunner) EndRun(_ string) {}
+
+func (f *flakyRunner) ResetSession(_, _ string) {}
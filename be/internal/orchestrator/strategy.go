@@ -0,0 +1,314 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"backboard-swarm/be/internal/types"
+)
+
+const (
+	StrategyIterative         = "iterative"
+	StrategySingle            = "single"
+	StrategyMapReduce         = "map_reduce"
+	StrategyDebate            = "debate"
+	StrategyPlanExecuteVerify = "plan_execute_verify"
+)
+
+// Strategy decides how a run is split across agents. Every strategy ends by
+// calling Swarm.finish so the swarm_finished event is emitted exactly once.
+type Strategy interface {
+	Name() string
+	Run(ctx context.Context, s *Swarm, req Request) (Outcome, error)
+}
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = map[string]Strategy{
+		StrategyIterative:         iterativeStrategy{},
+		StrategySingle:            singleStrategy{},
+		StrategyMapReduce:         mapReduceStrategy{},
+		StrategyDebate:            debateStrategy{},
+		StrategyPlanExecuteVerify: planExecuteVerifyStrategy{},
+	}
+)
+
+func RegisterStrategy(strategy Strategy) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	strategies[normalizeStrategyName(strategy.Name())] = strategy
+}
+
+func LookupStrategy(name string) (Strategy, bool) {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	strategy, ok := strategies[normalizeStrategyName(name)]
+	return strategy, ok
+}
+
+func normalizeStrategyName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+}
+
+// iterativeStrategy decomposes the task, runs the plan in parallel and lets
+// the orchestrator either refine with another round or finalize.
+type iterativeStrategy struct{}
+
+func (iterativeStrategy) Name() string { return StrategyIterative }
+
+func (iterativeStrategy) Run(ctx context.Context, s *Swarm, req Request) (Outcome, error) {
+	runID, task := req.RunID, req.Task
+	subtasks, err := s.decompose(ctx, runID, task)
+	if err != nil {
+		return Outcome{}, fmt.Errorf("decompose task: %w", err)
+	}
+	// An empty plan falls back to one coder on the whole task, as long as
+	// the run's subtask budget still has room for it.
+	if len(subtasks) == 0 && s.planBudget(runID) > 0 {
+		subtasks = []types.Subtask{{Role: types.RoleCoder, Task: task}}
+		s.useSubtasks(runID, 1)
+	}
+
+	maxRounds := s.maxRounds(req.RunID)
+	allResults := make([]types.SubtaskResult, 0, len(subtasks))
+	for round := 1; round <= maxRounds; round++ {
+		// After a failed verification the round starts with no new work and
+		// goes straight back to the orchestrator.
+		if len(subtasks) > 0 {
+			s.emit(types.Event{
+				Type:      "agent_status",
+				RunID:     runID,
+				AgentID:   "agent-0",
+				Role:      types.RoleOrchestrator,
+				Status:    "plan_ready",
+				Message:   fmt.Sprintf("round %d/%d: running %d subtask(s)", round, maxRounds, len(subtasks)),
+				Timestamp: time.Now().UTC(),
+			})
+
+			roundResults := s.runSubtasks(ctx, runID, subtasks)
+			allResults = append(allResults, roundResults...)
+		}
+
+		decision, raw, decisionErr := s.decideNextStep(ctx, runID, task, round, maxRounds, allResults)
+		if decisionErr != nil {
+			return Outcome{}, fmt.Errorf("decide next step: %w", decisionErr)
+		}
+
+		if decision.Action == "decompose" && len(decision.Subtasks) > 0 && round < maxRounds {
+			subtasks = decision.Subtasks
+			s.emit(types.Event{
+				Type:      "agent_status",
+				RunID:     runID,
+				AgentID:   "agent-0",
+				Role:      types.RoleOrchestrator,
+				Status:    "refining",
+				Message:   fmt.Sprintf("round %d/%d requested deeper decomposition into %d subtask(s)", round, maxRounds, len(subtasks)),
+				Timestamp: time.Now().UTC(),
+			})
+			continue
+		}
+
+		summary := finalSummary(task, decision, raw, allResults)
+		if !s.verifyEnabled(req) {
+			return s.finish(runID, summary, allResults), nil
+		}
+		verdicts := s.verifySummary(ctx, runID, task, summary, allResults)
+		if len(refutedClaims(verdicts)) > 0 && round < maxRounds {
+			allResults = append(allResults, verificationResult(verdicts))
+			subtasks = nil
+			continue
+		}
+		return s.finishVerified(runID, summary, allResults, verdicts), nil
+	}
+
+	return s.finish(runID, localFallbackSummary(task, allResults), allResults), nil
+}
+
+// singleStrategy hands the whole task to one worker agent.
+type singleStrategy struct{}
+
+func (singleStrategy) Name() string { return StrategySingle }
+
+func (singleStrategy) Run(ctx context.Context, s *Swarm, req Request) (Outcome, error) {
+	s.startAgent(req.RunID, "agent-1")
+	res := s.runAgent(ctx, req.RunID, "agent-1", types.Subtask{Role: req.Role.Normalize(), Task: req.Task})
+	if res.Error != "" {
+		return Outcome{}, fmt.Errorf("single agent: %s", res.Error)
+	}
+	return s.finish(req.RunID, res.Summary, []types.SubtaskResult{res}), nil
+}
+
+// mapReduceStrategy runs the task once per input in parallel and has the
+// orchestrator merge the results.
+type mapReduceStrategy struct{}
+
+func (mapReduceStrategy) Name() string { return StrategyMapReduce }
+
+func (mapReduceStrategy) Run(ctx context.Context, s *Swarm, req Request) (Outcome, error) {
+	if len(req.Inputs) == 0 {
+		return Outcome{}, fmt.Errorf("strategy %s requires inputs", StrategyMapReduce)
+	}
+	subtasks := make([]types.Subtask, 0, len(req.Inputs))
+	for i, input := range req.Inputs {
+		subtasks = append(subtasks, types.Subtask{
+			Role: req.Role.Normalize(),
+			Task: fmt.Sprintf("%s\n\nApply the task above to this input only (%d/%d):\n%s", req.Task, i+1, len(req.Inputs), input),
+		})
+	}
+
+	subtasks = s.claimSubtasks(req.RunID, "agent-0", types.RoleOrchestrator, subtasks)
+	if len(subtasks) == 0 {
+		return Outcome{}, fmt.Errorf("strategy %s: no subtasks left in the run's limit", StrategyMapReduce)
+	}
+	results := s.runSubtasks(ctx, req.RunID, subtasks)
+	decision, raw, err := s.decideNextStep(ctx, req.RunID, req.Task, 1, 1, results)
+	if err != nil {
+		return Outcome{}, fmt.Errorf("reduce results: %w", err)
+	}
+	return s.finish(req.RunID, finalSummary(req.Task, decision, raw, results), results), nil
+}
+
+// debateStrategy has two agents argue opposite positions over several turns
+// while the orchestrator judges the exchange.
+type debateStrategy struct{}
+
+func (debateStrategy) Name() string { return StrategyDebate }
+
+func (debateStrategy) Run(ctx context.Context, s *Swarm, req Request) (Outcome, error) {
+	role := types.RoleResearcher
+	if req.Role != "" {
+		role = req.Role.Normalize()
+	}
+	sides := []struct {
+		agentID string
+		stance  string
+	}{
+		{agentID: "agent-1", stance: "FOR"},
+		{agentID: "agent-2", stance: "AGAINST"},
+	}
+	for _, side := range sides {
+		s.startAgent(req.RunID, side.agentID)
+	}
+
+	turns := s.maxRounds(req.RunID)
+	// Every turn runs one subtask per side against the run's total limit.
+	if remaining := s.remainingSubtasks(req.RunID); remaining < turns*len(sides) {
+		turns = remaining / len(sides)
+		s.emit(types.Event{
+			Type:      "plan_truncated",
+			RunID:     req.RunID,
+			AgentID:   "agent-0",
+			Role:      types.RoleOrchestrator,
+			Status:    "truncated",
+			Message:   fmt.Sprintf("debate cut to %d turn(s) by the limit of %d subtask(s)", turns, remaining),
+			Timestamp: time.Now().UTC(),
+			Meta:      map[string]any{"limit": remaining, "turns": turns},
+		})
+	}
+	if turns == 0 {
+		return Outcome{}, fmt.Errorf("strategy %s: no subtasks left in the run's limit", StrategyDebate)
+	}
+	s.useSubtasks(req.RunID, turns*len(sides))
+	transcript := make([]types.SubtaskResult, 0, turns*len(sides))
+	last := make([]string, len(sides))
+	for turn := 1; turn <= turns; turn++ {
+		turnResults := make([]types.SubtaskResult, len(sides))
+		var wg sync.WaitGroup
+		for i, side := range sides {
+			opponent := last[(i+1)%len(sides)]
+			task := debatePrompt(req.Task, side.stance, turn, turns, opponent)
+			wg.Add(1)
+			go func(i int, agentID string) {
+				defer wg.Done()
+				turnResults[i] = s.runAgent(ctx, req.RunID, agentID, types.Subtask{Role: role, Task: task})
+			}(i, side.agentID)
+		}
+		wg.Wait()
+
+		for i, res := range turnResults {
+			last[i] = firstNonEmpty(res.Summary, res.Error)
+		}
+		transcript = append(transcript, turnResults...)
+	}
+
+	judgeTask := "Two agents debated the task below from opposite positions. Weigh the strongest arguments on each side and give the best supported answer.\n\n" + req.Task
+	decision, raw, err := s.decideNextStep(ctx, req.RunID, judgeTask, 1, 1, transcript)
+	if err != nil {
+		return Outcome{}, fmt.Errorf("judge debate: %w", err)
+	}
+	return s.finish(req.RunID, finalSummary(req.Task, decision, raw, transcript), transcript), nil
+}
+
+func debatePrompt(task, stance string, turn, turns int, opponent string) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("DEBATE turn %d/%d. Argue %s the most defensible answer to the task below.\n", turn, turns, stance))
+	builder.WriteString("Back every argument with evidence and concede points you cannot defend.\n\nTASK:\n")
+	builder.WriteString(task)
+	if strings.TrimSpace(opponent) != "" {
+		builder.WriteString("\n\nOPPONENT'S LAST ARGUMENT:\n")
+		builder.WriteString(strings.TrimSpace(opponent))
+		builder.WriteString("\n\nRebut it directly.")
+	}
+	return builder.String()
+}
+
+// planExecuteVerifyStrategy runs a single planned round, has a fact checker
+// audit the findings and then finalizes.
+type planExecuteVerifyStrategy struct{}
+
+func (planExecuteVerifyStrategy) Name() string { return StrategyPlanExecuteVerify }
+
+func (planExecuteVerifyStrategy) Run(ctx context.Context, s *Swarm, req Request) (Outcome, error) {
+	subtasks, err := s.decompose(ctx, req.RunID, req.Task)
+	if err != nil {
+		return Outcome{}, fmt.Errorf("decompose task: %w", err)
+	}
+	if len(subtasks) == 0 && s.planBudget(req.RunID) > 0 {
+		subtasks = []types.Subtask{{Role: types.RoleCoder, Task: req.Task}}
+		s.useSubtasks(req.RunID, 1)
+	}
+	results := s.runSubtasks(ctx, req.RunID, subtasks)
+
+	verification := s.runChecks(ctx, req.RunID, []types.Subtask{{
+		Role: types.RoleFactChecker,
+		Task: verificationPrompt(req.Task, results),
+	}})
+	results = append(results, verification...)
+
+	decision, raw, err := s.decideNextStep(ctx, req.RunID, req.Task, 1, 1, results)
+	if err != nil {
+		return Outcome{}, fmt.Errorf("decide next step: %w", err)
+	}
+	return s.finish(req.RunID, finalSummary(req.Task, decision, raw, results), results), nil
+}
+
+func verificationPrompt(task string, results []types.SubtaskResult) string {
+	var builder strings.Builder
+	builder.WriteString("Verify the findings below for the user task. For each finding report supported, refuted or unverified with the [S#] sources you checked.\n\nUSER_TASK:\n")
+	builder.WriteString(task)
+	builder.WriteString("\n\nFINDINGS:\n")
+	for i, res := range results {
+		if res.Error != "" {
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("%d) %s\n", i+1, strings.TrimSpace(res.Summary)))
+		for _, f := range res.Findings {
+			builder.WriteString("- " + strings.TrimSpace(f) + "\n")
+		}
+		for _, src := range res.Sources {
+			builder.WriteString(fmt.Sprintf("  source: [%s] %s\n", src.ID, src.URL))
+		}
+	}
+	return builder.String()
+}
+
+func finalSummary(task string, decision orchestrationDecision, raw string, results []types.SubtaskResult) string {
+	summary := strings.TrimSpace(firstNonEmpty(decision.Summary, raw))
+	if summary == "" || isDecompositionSummary(summary) {
+		return localFallbackSummary(task, results)
+	}
+	return summary
+}
@@ -0,0 +1,130 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"backboard-swarm/be/internal/agent"
+	"backboard-swarm/be/internal/config"
+	"backboard-swarm/be/internal/types"
+)
+
+func TestSingleStrategyRunsOneAgent(t *testing.T) {
+	runner := &recordingRunner{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 2, MaxOrchRounds: 2}, nil)
+
+	out, err := s.Execute(context.Background(), Request{RunID: "run-1", Task: "fix the bug", Strategy: StrategySingle})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Summary != "worked on: fix the bug" {
+		t.Fatalf("expected worker summary, got %q", out.Summary)
+	}
+	if got := runner.agentIDs(); len(got) != 1 || got[0] != "agent-1" {
+		t.Fatalf("expected exactly one worker call, got %v", got)
+	}
+}
+
+func TestMapReduceStrategyFansOutInputs(t *testing.T) {
+	runner := &recordingRunner{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 3, MaxOrchRounds: 2}, nil)
+
+	out, err := s.Execute(context.Background(), Request{RunID: "run-1", Task: "summarize", Strategy: "map-reduce", Inputs: []string{"a.go", "b.go", "c.go"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Summary != "merged" {
+		t.Fatalf("expected reduced summary, got %q", out.Summary)
+	}
+	tasks := runner.workerTasks()
+	if len(tasks) != 3 || !strings.Contains(strings.Join(tasks, "\n"), "(2/3):\nb.go") {
+		t.Fatalf("expected one map task per input, got %v", tasks)
+	}
+	if !strings.Contains(runner.lastDecision, "ROUND=1\nMAX_ROUNDS=1") {
+		t.Fatalf("expected reduce step to force finalize, got %s", runner.lastDecision)
+	}
+
+	if _, err := s.Execute(context.Background(), Request{RunID: "run-2", Task: "summarize", Strategy: StrategyMapReduce}); err == nil {
+		t.Fatal("expected error without inputs")
+	}
+}
+
+func TestDebateStrategyExchangesArguments(t *testing.T) {
+	runner := &recordingRunner{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 2, MaxOrchRounds: 2}, nil)
+
+	if _, err := s.Execute(context.Background(), Request{RunID: "run-1", Task: "tabs or spaces?", Strategy: StrategyDebate}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tasks := runner.workerTasks()
+	if len(tasks) != 4 {
+		t.Fatalf("expected 2 turns x 2 debaters, got %d", len(tasks))
+	}
+	rebuttals := 0
+	for _, task := range tasks {
+		if strings.Contains(task, "OPPONENT'S LAST ARGUMENT") {
+			rebuttals++
+		}
+	}
+	if rebuttals != 2 {
+		t.Fatalf("expected second turn to quote the opponent, got %d rebuttals", rebuttals)
+	}
+	if !strings.Contains(runner.lastDecision, "Two agents debated") {
+		t.Fatalf("expected judge prompt, got %s", runner.lastDecision)
+	}
+}
+
+func TestUnknownStrategyFails(t *testing.T) {
+	s := NewSwarm(&recordingRunner{}, config.Config{MaxSubagents: 1}, nil)
+	if _, err := s.Execute(context.Background(), Request{RunID: "run-1", Task: "x", Strategy: "nope"}); err == nil {
+		t.Fatal("expected unknown strategy error")
+	}
+}
+
+type recordingRunner struct {
+	mu           sync.Mutex
+	calls        []agent.TaskInput
+	lastDecision string
+}
+
+func (r *recordingRunner) RunTask(_ context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, in)
+	switch in.FinishMode {
+	case types.FinishPlan:
+		return agent.TaskResult{Payload: []byte(`{"subtasks":[{"role":"coder","task":"inspect"}]}`)}, nil
+	case types.FinishDecision:
+		r.lastDecision = in.Task
+		return agent.TaskResult{Payload: []byte(`{"action":"finalize","summary":"merged"}`)}, nil
+	}
+	return agent.TaskResult{Summary: "worked on: " + in.Task}, nil
+}
+
+func (r *recordingRunner) workerTasks() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]string, 0)
+	for _, c := range r.calls {
+		if c.AgentID != "agent-0" {
+			out = append(out, c.Task)
+		}
+	}
+	return out
+}
+
+func (r *recordingRunner) agentIDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]string, 0, len(r.calls))
+	for _, c := range r.calls {
+		out = append(out, c.AgentID)
+	}
+	return out
+}
+
+func (r *recordingRunner) EndRun(_ string) {}
+
+func (r *recordingRunner) ResetSession(_, _ string) {}
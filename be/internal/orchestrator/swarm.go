@@ -4,12 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"backboard-swarm/be/internal/agent"
 	"backboard-swarm/be/internal/config"
+	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/tools"
 	"backboard-swarm/be/internal/types"
 )
 
@@ -27,105 +31,151 @@ type Swarm struct {
 	runner TaskRunner
 	cfg    config.Config
 	events EventSink
+
+	todos      *runtime.TodoStore
+	workspaces *runtime.WorkspaceManager
+
+	mu   sync.Mutex
+	runs map[string]*runState
+
+	slotsMu           sync.Mutex
+	childSlotsByDepth map[int]chan struct{}
 }
 
 func NewSwarm(runner TaskRunner, cfg config.Config, events EventSink) *Swarm {
-	return &Swarm{runner: runner, cfg: cfg, events: events}
+	return &Swarm{runner: runner, cfg: cfg, events: events, runs: make(map[string]*runState)}
+}
+
+type Request struct {
+	RunID    string
+	Task     string
+	Strategy string
+	Role     types.Role
+	Inputs   []string
+	// Verify overrides cfg.Verify for this run when set.
+	Verify *bool
+	Limits Limits
+}
+
+type Outcome struct {
+	Summary      string
+	Bibliography []types.Source
+	Unsourced    []string
+	Verification []types.ClaimVerdict
+}
+
+// SetTodos lets the orchestrator see worker todo progress when deciding the
+// next step.
+func (s *Swarm) SetTodos(todos *runtime.TodoStore) {
+	s.todos = todos
 }
 
 func (s *Swarm) Run(ctx context.Context, runID, task string) (string, error) {
-	defer s.runner.EndRun(runID)
+	out, err := s.Execute(ctx, Request{RunID: runID, Task: task})
+	return out.Summary, err
+}
+
+func (s *Swarm) Execute(ctx context.Context, req Request) (Outcome, error) {
+	defer s.runner.EndRun(req.RunID)
+
+	strategy, err := s.strategyFor(req)
+	if err != nil {
+		return Outcome{}, err
+	}
+	s.startRun(req)
+	defer s.endRun(req.RunID)
+	defer s.endWorkspace(req.RunID)
 
 	s.emit(types.Event{
 		Type:      "swarm_started",
-		RunID:     runID,
-		Message:   task,
+		RunID:     req.RunID,
+		Message:   req.Task,
 		Timestamp: time.Now().UTC(),
+		Meta:      map[string]any{"strategy": strategy.Name()},
 	})
+	return strategy.Run(ctx, s, req)
+}
 
-	subtasks, err := s.decompose(ctx, runID, task)
-	if err != nil {
-		return "", fmt.Errorf("decompose task: %w", err)
-	}
-	if len(subtasks) == 0 {
-		subtasks = []types.Subtask{{Role: types.RoleCoder, Task: task}}
-	}
-
-	maxRounds := s.cfg.MaxOrchRounds
-	if maxRounds <= 0 {
-		maxRounds = 3
+func (s *Swarm) strategyFor(req Request) (Strategy, error) {
+	name := firstNonEmpty(req.Strategy, s.cfg.Strategy, StrategyIterative)
+	strategy, ok := LookupStrategy(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q", name)
 	}
+	return strategy, nil
+}
 
-	allResults := make([]types.SubtaskResult, 0, len(subtasks))
-	for round := 1; round <= maxRounds; round++ {
-		s.emit(types.Event{
-			Type:      "agent_status",
-			RunID:     runID,
-			AgentID:   "agent-0",
-			Role:      types.RoleOrchestrator,
-			Status:    "plan_ready",
-			Message:   fmt.Sprintf("round %d/%d: running %d subtask(s)", round, maxRounds, len(subtasks)),
-			Timestamp: time.Now().UTC(),
-		})
-
-		roundResults := s.runSubtasks(ctx, runID, subtasks)
-		allResults = append(allResults, roundResults...)
-
-		decision, raw, decisionErr := s.decideNextStep(ctx, runID, task, round, maxRounds, allResults)
-		if decisionErr != nil {
-			return "", fmt.Errorf("decide next step: %w", decisionErr)
-		}
+func (s *Swarm) maxRounds(runID string) int {
+	return s.limits(runID).MaxRounds
+}
 
-		if decision.Action == "decompose" && len(decision.Subtasks) > 0 && round < maxRounds {
-			subtasks = decision.Subtasks
-			s.emit(types.Event{
-				Type:      "agent_status",
-				RunID:     runID,
-				AgentID:   "agent-0",
-				Role:      types.RoleOrchestrator,
-				Status:    "refining",
-				Message:   fmt.Sprintf("round %d/%d requested deeper decomposition into %d subtask(s)", round, maxRounds, len(subtasks)),
-				Timestamp: time.Now().UTC(),
-			})
-			continue
-		}
+func (s *Swarm) finish(runID, summary string, results []types.SubtaskResult) Outcome {
+	return s.finishVerified(runID, summary, results, nil)
+}
 
-		summary := strings.TrimSpace(firstNonEmpty(decision.Summary, raw))
-		if summary == "" || isDecompositionSummary(summary) {
-			summary = localFallbackSummary(task, allResults)
-		}
-		s.emit(types.Event{
-			Type:      "swarm_finished",
-			RunID:     runID,
-			Status:    "completed",
-			Message:   summary,
-			Timestamp: time.Now().UTC(),
-		})
-		return summary, nil
+func (s *Swarm) finishVerified(runID, summary string, results []types.SubtaskResult, verdicts []types.ClaimVerdict) Outcome {
+	out := Outcome{Summary: annotateSummary(summary, verdicts), Bibliography: bibliography(results), Verification: verdicts}
+	for _, res := range results {
+		out.Unsourced = append(out.Unsourced, res.Unsourced...)
 	}
-
-	summary := localFallbackSummary(task, allResults)
 	s.emit(types.Event{
 		Type:      "swarm_finished",
 		RunID:     runID,
 		Status:    "completed",
-		Message:   summary,
+		Message:   out.Summary,
 		Timestamp: time.Now().UTC(),
+		Meta: map[string]any{
+			"bibliography":     out.Bibliography,
+			"unsourced_claims": out.Unsourced,
+			"verification":     out.Verification,
+		},
 	})
-	return summary, nil
+	return out
+}
+
+// bibliography collects the sources cited across all results, deduplicated
+// by source id and ordered by id.
+func bibliography(results []types.SubtaskResult) []types.Source {
+	byID := map[string]types.Source{}
+	for _, res := range results {
+		for _, src := range res.Sources {
+			if src.ID != "" {
+				byID[src.ID] = src
+			}
+		}
+	}
+	out := make([]types.Source, 0, len(byID))
+	for _, src := range byID {
+		out = append(out, src)
+	}
+	sort.Slice(out, func(i, j int) bool { return sourceOrdinal(out[i].ID) < sourceOrdinal(out[j].ID) })
+	return out
+}
+
+func sourceOrdinal(id string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(id), "S"))
+	if err != nil {
+		return 0
+	}
+	return n
 }
 
 func (s *Swarm) decompose(ctx context.Context, runID, task string) ([]types.Subtask, error) {
 	plan, err := s.runner.RunTask(ctx, agent.TaskInput{
-		RunID:   runID,
-		AgentID: "agent-0",
-		Role:    types.RoleOrchestrator,
-		Task:    fmt.Sprintf("MODE: DECOMPOSE\n\nUSER_TASK:\n%s", task),
+		RunID:      runID,
+		AgentID:    "agent-0",
+		Role:       types.RoleOrchestrator,
+		Task:       fmt.Sprintf("MODE: DECOMPOSE\n%s\nUSER_TASK:\n%s", s.limitsHeader(runID), task),
+		FinishMode: types.FinishPlan,
 	})
 	if err != nil {
 		return nil, err
 	}
-	return parseSubtasks(firstNonEmpty(plan.Summary, plan.Raw)), nil
+	subtasks := parseSubtasks(string(plan.Payload))
+	if len(subtasks) == 0 {
+		subtasks = parseSubtasks(firstNonEmpty(plan.Summary, plan.Raw))
+	}
+	return s.fitPlan(ctx, runID, task, s.dedupeSubtasks(runID, subtasks)), nil
 }
 
 type orchestrationDecision struct {
@@ -136,70 +186,218 @@ type orchestrationDecision struct {
 
 func (s *Swarm) decideNextStep(ctx context.Context, runID, task string, round, maxRounds int, results []types.SubtaskResult) (orchestrationDecision, string, error) {
 	res, err := s.runner.RunTask(ctx, agent.TaskInput{
-		RunID:   runID,
-		AgentID: "agent-0",
-		Role:    types.RoleOrchestrator,
-		Task:    decisionPrompt(task, round, maxRounds, results),
+		RunID:      runID,
+		AgentID:    "agent-0",
+		Role:       types.RoleOrchestrator,
+		Task:       decisionPrompt(task, round, maxRounds, s.limitsHeader(runID), results, s.todoProgress(runID)),
+		FinishMode: types.FinishDecision,
 	})
 	if err != nil {
 		return orchestrationDecision{}, "", err
 	}
+	d, raw := s.parseNextStep(res)
+	if d.Action == "decompose" {
+		d.Subtasks = s.fitPlan(ctx, runID, task, s.dedupeSubtasks(runID, d.Subtasks))
+		if len(d.Subtasks) == 0 && round < maxRounds {
+			// Everything requested was already done; ask for the final answer.
+			return s.decideNextStep(ctx, runID, task, maxRounds, maxRounds, results)
+		}
+		if len(d.Subtasks) == 0 {
+			d = orchestrationDecision{Action: "finalize"}
+		}
+	}
+	return d, raw, nil
+}
+
+func (s *Swarm) parseNextStep(res agent.TaskResult) (orchestrationDecision, string) {
 	raw := strings.TrimSpace(firstNonEmpty(res.Summary, res.Raw))
+	if d, ok := parseDecision(string(res.Payload)); ok {
+		return d, raw
+	}
 	if raw == "" {
-		return orchestrationDecision{Action: "finalize", Summary: ""}, raw, nil
+		return orchestrationDecision{Action: "finalize", Summary: ""}, raw
 	}
 	if d, ok := parseDecision(raw); ok {
-		return d, raw, nil
+		return d, raw
 	}
 	if subtasks := parseSubtasks(raw); len(subtasks) > 0 {
-		return orchestrationDecision{Action: "decompose", Subtasks: subtasks}, raw, nil
+		return orchestrationDecision{Action: "decompose", Subtasks: subtasks}, raw
 	}
-	return orchestrationDecision{Action: "finalize", Summary: raw}, raw, nil
+	return orchestrationDecision{Action: "finalize", Summary: raw}, raw
 }
 
+// runSubtasks runs every subtask, and every ensemble member of a subtask, in
+// parallel up to MaxSubagents, then reconciles ensembles into one result.
 func (s *Swarm) runSubtasks(ctx context.Context, runID string, subtasks []types.Subtask) []types.SubtaskResult {
 	sem := make(chan struct{}, s.cfg.MaxSubagents)
-	results := make([]types.SubtaskResult, len(subtasks))
+	members := make([][]types.SubtaskResult, len(subtasks))
 	var wg sync.WaitGroup
 
 	for i := range subtasks {
-		i := i
 		task := subtasks[i]
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			sem <- struct{}{}
-			defer func() { <-sem }()
-
+		size := s.ensembleSize(task)
+		members[i] = make([]types.SubtaskResult, size)
+		for k := 0; k < size; k++ {
 			agentID := fmt.Sprintf("agent-%d", i+1)
-			s.runner.ResetSession(runID, agentID)
-			res, err := s.runner.RunTask(ctx, agent.TaskInput{
-				RunID:   runID,
-				AgentID: agentID,
-				Role:    task.Role.Normalize(),
-				Task:    task.Task,
-			})
-			if err != nil {
-				results[i] = types.SubtaskResult{Subtask: task, Error: err.Error()}
-				s.emit(types.Event{
-					Type:      "agent_finished",
-					RunID:     runID,
-					AgentID:   agentID,
-					Role:      task.Role.Normalize(),
-					Status:    "failed",
-					Message:   err.Error(),
-					Timestamp: time.Now().UTC(),
-				})
-				return
+			if size > 1 {
+				agentID = fmt.Sprintf("agent-%d-v%d", i+1, k+1)
 			}
-			results[i] = types.SubtaskResult{Subtask: task, Summary: strings.TrimSpace(firstNonEmpty(res.Summary, res.Raw))}
-		}()
+			model := s.ensembleModel(k, size)
+			wg.Add(1)
+			go func(i, k int) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				s.startAgent(runID, agentID)
+				members[i][k] = s.runWithRetry(ctx, runID, agentID, task, model, 0)
+				if size == 1 {
+					s.settleWorkspace(runID, agentID, members[i][k])
+				}
+			}(i, k)
+		}
 	}
 
 	wg.Wait()
+
+	results := make([]types.SubtaskResult, len(subtasks))
+	for i, task := range subtasks {
+		if len(members[i]) == 1 {
+			results[i] = members[i][0]
+			continue
+		}
+		results[i] = s.reconcile(ctx, runID, fmt.Sprintf("agent-%d", i+1), task, members[i])
+	}
+	s.recordExecuted(runID, results)
 	return results
 }
 
+// runAgent runs one subtask on the given agent's current session and turns
+// the outcome into a SubtaskResult.
+func (s *Swarm) runAgent(ctx context.Context, runID, agentID string, task types.Subtask) types.SubtaskResult {
+	res := s.runAgentWith(ctx, runID, agentID, task, agentOptions{})
+	s.settleWorkspace(runID, agentID, res)
+	return res
+}
+
+// agentOptions are the per-run settings of an agent. Top-level workers run
+// at depth 0; read-only agents cannot delegate or use tools with side
+// effects.
+type agentOptions struct {
+	model    string
+	depth    int
+	readOnly bool
+}
+
+// runAgentWith runs a subtask with the given options. The agent's workspace
+// is left for the caller to settle.
+func (s *Swarm) runAgentWith(ctx context.Context, runID, agentID string, task types.Subtask, opts agentOptions) types.SubtaskResult {
+	timeout, maxIterations := s.subtaskBudget(task)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	workspace, err := s.workspaceFor(runID, agentID, task.Role.Normalize())
+	if err != nil {
+		return s.agentFailed(runID, agentID, task, err)
+	}
+	var delegate tools.Delegator
+	if !opts.readOnly {
+		delegate = &delegator{s: s, runID: runID, agentID: agentID, depth: opts.depth}
+	}
+	res, err := s.runner.RunTask(ctx, agent.TaskInput{
+		RunID:         runID,
+		AgentID:       agentID,
+		Role:          task.Role.Normalize(),
+		Task:          task.Task,
+		FinishMode:    types.FinishReport,
+		Model:         opts.model,
+		Delegator:     delegate,
+		MaxIterations: maxIterations,
+		WorkspaceRoot: workspace,
+		ReadOnly:      opts.readOnly,
+	})
+	if err != nil && res.Partial {
+		return s.partialResult(runID, agentID, task, res, err)
+	}
+	if err != nil {
+		return s.agentFailed(runID, agentID, task, err)
+	}
+	result := subtaskResult(task, res)
+	result.AgentID = agentID
+	if len(result.Unsourced) > 0 {
+		s.emit(types.Event{
+			Type:      "claims_unsourced",
+			RunID:     runID,
+			AgentID:   agentID,
+			Role:      task.Role.Normalize(),
+			Status:    "flagged",
+			Message:   fmt.Sprintf("%d finding(s) cite no source", len(result.Unsourced)),
+			Timestamp: time.Now().UTC(),
+			Meta:      map[string]any{"claims": result.Unsourced},
+		})
+	}
+	return result
+}
+
+func (s *Swarm) agentFailed(runID, agentID string, task types.Subtask, err error) types.SubtaskResult {
+	s.emit(types.Event{
+		Type:      "agent_finished",
+		RunID:     runID,
+		AgentID:   agentID,
+		Role:      task.Role.Normalize(),
+		Status:    "failed",
+		Message:   err.Error(),
+		Timestamp: time.Now().UTC(),
+	})
+	return types.SubtaskResult{Subtask: task, AgentID: agentID, Error: err.Error(), Failure: agent.ClassifyFailure(err)}
+}
+
+type reportPayload struct {
+	Summary    string   `json:"summary"`
+	Findings   []string `json:"findings"`
+	Confidence float64  `json:"confidence"`
+}
+
+func subtaskResult(task types.Subtask, res agent.TaskResult) types.SubtaskResult {
+	out := types.SubtaskResult{
+		Subtask: task,
+		Summary: strings.TrimSpace(firstNonEmpty(res.Summary, res.Raw)),
+		Sources: res.Sources,
+	}
+	var report reportPayload
+	if len(res.Payload) > 0 && json.Unmarshal(res.Payload, &report) == nil {
+		if summary := strings.TrimSpace(report.Summary); summary != "" {
+			out.Summary = summary
+		}
+		out.Findings = report.Findings
+		out.Confidence = report.Confidence
+	}
+	out.Unsourced = unsourcedClaims(task.Role.Normalize(), out)
+	return out
+}
+
+// unsourcedClaims flags research and fact-checking findings that carry no
+// [S<n>] citation. Reports without findings are flagged as a whole when they
+// list no sources at all.
+func unsourcedClaims(role types.Role, res types.SubtaskResult) []string {
+	if role != types.RoleResearcher && role != types.RoleFactChecker {
+		return nil
+	}
+	if len(res.Findings) == 0 {
+		if len(res.Sources) == 0 && res.Summary != "" {
+			return []string{res.Summary}
+		}
+		return nil
+	}
+	out := make([]string, 0)
+	for _, f := range res.Findings {
+		if len(runtime.CitedSourceIDs(f)) == 0 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
 func parseSubtasks(raw string) []types.Subtask {
 	clean := strings.TrimSpace(raw)
 	if clean == "" {
@@ -232,10 +430,12 @@ func normalizeSubtasks(in []types.Subtask) []types.Subtask {
 		if task == "" {
 			continue
 		}
-		out = append(out, types.Subtask{Role: t.Role.Normalize(), Task: task})
-	}
-	if len(out) > 6 {
-		out = out[:6]
+		t.Role = t.Role.Normalize()
+		t.Task = task
+		if t.EscalateRole != "" {
+			t.EscalateRole = t.EscalateRole.Normalize()
+		}
+		out = append(out, t)
 	}
 	return out
 }
@@ -249,11 +449,59 @@ func firstNonEmpty(values ...string) string {
 	return ""
 }
 
-func decisionPrompt(task string, round, maxRounds int, results []types.SubtaskResult) string {
+// startAgent gives agentID a fresh session and todo list before it takes on
+// a new subtask. Agent ids repeat every round, so without this a worker
+// would inherit the previous holder's todos; those are archived instead.
+func (s *Swarm) startAgent(runID, agentID string) {
+	s.runner.ResetSession(runID, agentID)
+	if s.todos == nil {
+		return
+	}
+	if archived := s.todos.Archive(runID, agentID); len(archived) > 0 {
+		s.emit(types.Event{
+			Type:      "todo_changed",
+			RunID:     runID,
+			AgentID:   agentID,
+			Status:    "cleared",
+			Message:   fmt.Sprintf("archived %d todo(s) from the previous subtask", len(archived)),
+			Timestamp: time.Now().UTC(),
+			Meta:      map[string]any{"todos": archived},
+		})
+	}
+}
+
+// todoProgress lists the workers' todos for the decision prompt so the
+// orchestrator can see what was planned but not finished.
+func (s *Swarm) todoProgress(runID string) string {
+	if s.todos == nil {
+		return ""
+	}
+	items := s.todos.ListRun(runID)
+	if len(items) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	builder.WriteString("WORKER_TODOS:\n")
+	for _, item := range items {
+		indent := ""
+		if item.ParentID != "" {
+			indent = "  "
+		}
+		builder.WriteString(fmt.Sprintf("%s- [%s] %s %s: %s", indent, item.Status, item.AgentID, item.ID, item.Title))
+		if len(item.DependsOn) > 0 {
+			builder.WriteString(" (after " + strings.Join(item.DependsOn, ", ") + ")")
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+func decisionPrompt(task string, round, maxRounds int, limits string, results []types.SubtaskResult, todos string) string {
 	var builder strings.Builder
 	builder.WriteString("MODE: DECIDE_NEXT_STEP\n")
 	builder.WriteString(fmt.Sprintf("ROUND=%d\n", round))
 	builder.WriteString(fmt.Sprintf("MAX_ROUNDS=%d\n", maxRounds))
+	builder.WriteString(limits)
 	builder.WriteString("\nUSER_TASK:\n")
 	builder.WriteString(task)
 	builder.WriteString("\n\nCURRENT_FINDINGS:\n")
@@ -261,12 +509,37 @@ func decisionPrompt(task string, round, maxRounds int, results []types.SubtaskRe
 		builder.WriteString(fmt.Sprintf("%d) role=%s\n", i+1, res.Subtask.Role))
 		builder.WriteString("task: " + strings.TrimSpace(res.Subtask.Task) + "\n")
 		if res.Error != "" {
-			builder.WriteString("error: " + res.Error + "\n\n")
+			builder.WriteString("error: " + res.Error + "\n")
+			builder.WriteString(fmt.Sprintf("failure: %s after %d attempt(s)\n", firstNonEmpty(string(res.Failure), "unknown"), max(res.Attempts, 1)))
+			if res.Partial {
+				builder.WriteString("partial_result (cut off, incomplete): " + strings.TrimSpace(res.Summary) + "\n")
+				for _, f := range res.Findings {
+					builder.WriteString("partial_finding: " + strings.TrimSpace(f) + "\n")
+				}
+			}
+			builder.WriteString("\n")
 			continue
 		}
 		builder.WriteString("result: " + strings.TrimSpace(res.Summary))
-		builder.WriteString("\n\n")
+		builder.WriteString("\n")
+		for _, f := range res.Findings {
+			builder.WriteString("finding: " + strings.TrimSpace(f) + "\n")
+		}
+		if res.Confidence > 0 {
+			builder.WriteString(fmt.Sprintf("confidence: %.2f\n", res.Confidence))
+		}
+		if res.Members > 1 {
+			builder.WriteString(fmt.Sprintf("ensemble_agreement: %.2f across %d agents\n", res.Agreement, res.Members))
+		}
+		for _, src := range res.Sources {
+			builder.WriteString(fmt.Sprintf("source: [%s] %s\n", src.ID, src.URL))
+		}
+		if len(res.Unsourced) > 0 {
+			builder.WriteString(fmt.Sprintf("unsourced_claims: %d (treat as unverified)\n", len(res.Unsourced)))
+		}
+		builder.WriteString("\n")
 	}
+	builder.WriteString(todos)
 	builder.WriteString("\nReturn via finish.")
 	return builder.String()
 }
@@ -10,6 +10,7 @@ import (
 
 	"backboard-swarm/be/internal/agent"
 	"backboard-swarm/be/internal/config"
+	"backboard-swarm/be/internal/runtime"
 	"backboard-swarm/be/internal/types"
 )
 
@@ -46,6 +47,36 @@ func TestRunSubtasksParallel(t *testing.T) {
 	}
 }
 
+func TestRunSubtasksArchivesTodosOfReusedAgentIDs(t *testing.T) {
+	events := &eventLog{}
+	s := NewSwarm(&fakeRunner{}, config.Config{MaxSubagents: 2}, events)
+	todos := runtime.NewTodoStore()
+	s.SetTodos(todos)
+	if _, err := todos.Create("run-1", "agent-1", runtime.TodoItem{Title: "old round work"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := todos.Create("run-1", "agent-3", runtime.TodoItem{Title: "idle agent work"}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.runSubtasks(context.Background(), "run-1", []types.Subtask{{Role: types.RoleCoder, Task: "new"}})
+
+	if got := todos.List("run-1", "agent-1"); len(got) != 0 {
+		t.Fatalf("expected agent-1 todos to be cleared, got %+v", got)
+	}
+	progress := s.todoProgress("run-1")
+	if strings.Contains(progress, "old round work") || !strings.Contains(progress, "idle agent work") {
+		t.Fatalf("unexpected todo progress:\n%s", progress)
+	}
+	history := todos.History("run-1")
+	if len(history) != 2 || history[0].Title != "old round work" || !history[0].Archived {
+		t.Fatalf("expected the old todos in the run history, got %+v", history)
+	}
+	if !events.has("todo_changed", "archived 1 todo(s) from the previous subtask") {
+		t.Fatalf("expected a cleared event, got %+v", events.events)
+	}
+}
+
 func TestRunInterleavesDecompositionThenFinalize(t *testing.T) {
 	runner := &scriptedRunner{}
 	s := NewSwarm(runner, config.Config{MaxSubagents: 3, MaxOrchRounds: 3}, nil)
@@ -129,3 +160,86 @@ func (s *scriptedRunner) ResetSession(_, _ string) {
 	defer s.mu.Unlock()
 	s.resetCalls++
 }
+
+func TestRunUsesStructuredFinishPayloads(t *testing.T) {
+	runner := &payloadRunner{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 2, MaxOrchRounds: 2}, nil)
+
+	summary, err := s.Run(context.Background(), "run-1", "compare the two APIs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary != "Both APIs are equivalent." {
+		t.Fatalf("expected summary from decision payload, got %q", summary)
+	}
+	if !strings.Contains(runner.decisionTask, "finding: v2 adds pagination") || !strings.Contains(runner.decisionTask, "confidence: 0.80") {
+		t.Fatalf("expected report findings in decision prompt, got %s", runner.decisionTask)
+	}
+}
+
+type payloadRunner struct {
+	mu           sync.Mutex
+	decisionTask string
+}
+
+func (p *payloadRunner) RunTask(_ context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch in.FinishMode {
+	case types.FinishPlan:
+		return agent.TaskResult{Summary: "plan ready", Payload: []byte(`{"subtasks":[{"role":"researcher","task":"read both API docs"}]}`)}, nil
+	case types.FinishReport:
+		return agent.TaskResult{Summary: "raw", Payload: []byte(`{"summary":"docs read","findings":["v2 adds pagination"],"confidence":0.8}`)}, nil
+	case types.FinishDecision:
+		p.decisionTask = in.Task
+		return agent.TaskResult{Summary: "not json", Payload: []byte(`{"action":"finalize","summary":"Both APIs are equivalent."}`)}, nil
+	}
+	return agent.TaskResult{}, fmt.Errorf("unexpected finish mode %q", in.FinishMode)
+}
+
+func (p *payloadRunner) EndRun(_ string) {}
+
+func (p *payloadRunner) ResetSession(_, _ string) {}
+
+func TestExecuteBuildsBibliographyAndFlagsOrphans(t *testing.T) {
+	runner := &citingRunner{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 2, MaxOrchRounds: 1}, nil)
+
+	out, err := s.Execute(context.Background(), Request{RunID: "run-1", Task: "when was go 1.22 released?"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Bibliography) != 2 || out.Bibliography[0].ID != "S1" || out.Bibliography[1].ID != "S2" {
+		t.Fatalf("expected deduplicated, ordered bibliography, got %+v", out.Bibliography)
+	}
+	if len(out.Unsourced) != 1 || out.Unsourced[0] != "it was well received" {
+		t.Fatalf("expected one orphan claim, got %+v", out.Unsourced)
+	}
+}
+
+type citingRunner struct{}
+
+func (c *citingRunner) RunTask(_ context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	s1 := types.Source{ID: "S1", URL: "https://go.dev/doc/go1.22"}
+	s2 := types.Source{ID: "S2", URL: "https://go.dev/blog/go1.22"}
+	switch in.FinishMode {
+	case types.FinishPlan:
+		return agent.TaskResult{Payload: []byte(`{"subtasks":[{"role":"researcher","task":"release date"},{"role":"fact_checker","task":"confirm date"}]}`)}, nil
+	case types.FinishReport:
+		if in.Role == types.RoleResearcher {
+			return agent.TaskResult{
+				Payload: []byte(`{"summary":"Feb 2024","findings":["released 2024-02-06 [S2]","it was well received"],"confidence":0.9}`),
+				Sources: []types.Source{s2},
+			}, nil
+		}
+		return agent.TaskResult{
+			Payload: []byte(`{"summary":"confirmed","findings":["release notes dated Feb 2024 [S1][S2]"],"confidence":0.9}`),
+			Sources: []types.Source{s1, s2},
+		}, nil
+	}
+	return agent.TaskResult{Payload: []byte(`{"action":"finalize","summary":"Go 1.22 was released on 2024-02-06 [S1]."}`)}, nil
+}
+
+func (c *citingRunner) EndRun(_ string) {}
+
+func (c *citingRunner) ResetSession(_, _ string) {}
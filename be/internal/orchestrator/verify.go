@@ -0,0 +1,251 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/types"
+)
+
+const maxVerifyClaims = 12
+
+var (
+	bulletPrefix = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s+`)
+	verdictLine  = regexp.MustCompile(`(?i)^\W*C(\d+)\W+(supported|refuted|unverified)\b\W*(.*)$`)
+)
+
+func (s *Swarm) verifyEnabled(req Request) bool {
+	if req.Verify != nil {
+		return *req.Verify
+	}
+	return s.cfg.Verify
+}
+
+// verifySummary splits the final summary into claims and has read-only
+// fact_checker agents judge each one against the sources collected during
+// the run. Claims a checker did not answer for are reported as unverified.
+func (s *Swarm) verifySummary(ctx context.Context, runID, task, summary string, results []types.SubtaskResult) []types.ClaimVerdict {
+	claims := extractClaims(summary)
+	if len(claims) == 0 {
+		return nil
+	}
+	s.emit(types.Event{
+		Type:      "agent_status",
+		RunID:     runID,
+		AgentID:   "agent-0",
+		Role:      types.RoleOrchestrator,
+		Status:    "verifying",
+		Message:   fmt.Sprintf("verifying %d claim(s) in the final summary", len(claims)),
+		Timestamp: time.Now().UTC(),
+	})
+
+	groups := claimGroups(len(claims), s.cfg.MaxSubagents)
+	sources := bibliography(results)
+	subtasks := make([]types.Subtask, 0, len(groups))
+	for _, g := range groups {
+		subtasks = append(subtasks, types.Subtask{
+			Role: types.RoleFactChecker,
+			Task: claimCheckPrompt(task, claims, g[0], g[1], sources),
+		})
+	}
+	checks := s.runChecks(ctx, runID, subtasks)
+
+	verdicts := make([]types.ClaimVerdict, len(claims))
+	for i, claim := range claims {
+		verdicts[i] = types.ClaimVerdict{Claim: claim, Verdict: types.VerdictUnverified}
+	}
+	for _, check := range checks {
+		if check.Error != "" {
+			continue
+		}
+		lines := append([]string{}, check.Findings...)
+		lines = append(lines, strings.Split(check.Summary, "\n")...)
+		for _, line := range lines {
+			n, verdict, ok := parseVerdictLine(line)
+			if !ok || n < 1 || n > len(claims) || verdicts[n-1].Evidence != "" {
+				continue
+			}
+			verdicts[n-1] = verdict
+			verdicts[n-1].Claim = claims[n-1]
+		}
+	}
+
+	refuted := refutedClaims(verdicts)
+	status := "passed"
+	if len(refuted) > 0 {
+		status = "failed"
+	}
+	s.emit(types.Event{
+		Type:      "verification_finished",
+		RunID:     runID,
+		AgentID:   "agent-0",
+		Role:      types.RoleOrchestrator,
+		Status:    status,
+		Message:   fmt.Sprintf("%d of %d claim(s) refuted", len(refuted), len(claims)),
+		Timestamp: time.Now().UTC(),
+		Meta:      map[string]any{"verdicts": verdicts},
+	})
+	return verdicts
+}
+
+// runChecks runs verification subtasks in parallel up to MaxSubagents as
+// read-only verify-N agents. Unlike plan work they are not retried, not
+// run as ensembles and not recorded as executed.
+func (s *Swarm) runChecks(ctx context.Context, runID string, subtasks []types.Subtask) []types.SubtaskResult {
+	sem := make(chan struct{}, s.cfg.MaxSubagents)
+	results := make([]types.SubtaskResult, len(subtasks))
+	var wg sync.WaitGroup
+	for i, task := range subtasks {
+		agentID := fmt.Sprintf("verify-%d", i+1)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			s.startAgent(runID, agentID)
+			results[i] = s.runAgentWith(ctx, runID, agentID, task, agentOptions{readOnly: true})
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// extractClaims breaks a summary into individual sentences worth checking.
+// Headings, very short fragments and duplicates are skipped.
+func extractClaims(summary string) []string {
+	seen := map[string]bool{}
+	out := make([]string, 0)
+	for _, line := range strings.Split(summary, "\n") {
+		line = strings.TrimSpace(bulletPrefix.ReplaceAllString(line, ""))
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasSuffix(line, ":") {
+			continue
+		}
+		for _, sentence := range splitSentences(line) {
+			if len(strings.Fields(sentence)) < 4 || seen[sentence] {
+				continue
+			}
+			seen[sentence] = true
+			out = append(out, sentence)
+			if len(out) == maxVerifyClaims {
+				return out
+			}
+		}
+	}
+	return out
+}
+
+func splitSentences(line string) []string {
+	out := make([]string, 0, 1)
+	start := 0
+	for i := 0; i < len(line)-1; i++ {
+		if (line[i] == '.' || line[i] == '!' || line[i] == '?') && line[i+1] == ' ' {
+			if sentence := strings.TrimSpace(line[start : i+1]); sentence != "" {
+				out = append(out, sentence)
+			}
+			start = i + 1
+		}
+	}
+	if tail := strings.TrimSpace(line[start:]); tail != "" {
+		out = append(out, tail)
+	}
+	return out
+}
+
+// claimGroups splits n claims into at most limit contiguous [start, end)
+// ranges of near-equal size.
+func claimGroups(n, limit int) [][2]int {
+	if limit <= 0 {
+		limit = 1
+	}
+	if limit > n {
+		limit = n
+	}
+	out := make([][2]int, 0, limit)
+	start := 0
+	for i := 0; i < limit; i++ {
+		end := start + (n-start)/(limit-i)
+		out = append(out, [2]int{start, end})
+		start = end
+	}
+	return out
+}
+
+func claimCheckPrompt(task string, claims []string, start, end int, sources []types.Source) string {
+	var builder strings.Builder
+	builder.WriteString("Verify each claim below from the final answer to the user task. Check it against the listed sources first and fetch more evidence only when they are insufficient.\n")
+	builder.WriteString("Return one finding per claim formatted as `C<n> <supported|refuted|unverified>: <evidence> [S#]`.\n\nUSER_TASK:\n")
+	builder.WriteString(task)
+	builder.WriteString("\n\nCLAIMS:\n")
+	for i := start; i < end; i++ {
+		builder.WriteString(fmt.Sprintf("C%d: %s\n", i+1, claims[i]))
+	}
+	if len(sources) > 0 {
+		builder.WriteString("\nSOURCES:\n")
+		for _, src := range sources {
+			builder.WriteString(fmt.Sprintf("[%s] %s %s\n", src.ID, src.URL, src.Title))
+		}
+	}
+	return builder.String()
+}
+
+func parseVerdictLine(line string) (int, types.ClaimVerdict, bool) {
+	m := verdictLine.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return 0, types.ClaimVerdict{}, false
+	}
+	n, _ := strconv.Atoi(m[1])
+	evidence := strings.TrimSpace(m[3])
+	return n, types.ClaimVerdict{
+		Verdict:  strings.ToLower(m[2]),
+		Evidence: firstNonEmpty(evidence, strings.ToLower(m[2])),
+		Sources:  runtime.CitedSourceIDs(evidence),
+	}, true
+}
+
+func refutedClaims(verdicts []types.ClaimVerdict) []types.ClaimVerdict {
+	out := make([]types.ClaimVerdict, 0)
+	for _, v := range verdicts {
+		if v.Verdict == types.VerdictRefuted {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// verificationResult feeds refuted claims back to the orchestrator as a
+// fact_checker result so the next decision round can correct them.
+func verificationResult(verdicts []types.ClaimVerdict) types.SubtaskResult {
+	refuted := refutedClaims(verdicts)
+	res := types.SubtaskResult{
+		Subtask: types.Subtask{Role: types.RoleFactChecker, Task: "Verify the claims in the proposed final summary"},
+		Summary: fmt.Sprintf("%d of %d claim(s) in the proposed final summary were refuted. Correct or research them before finalizing again.", len(refuted), len(verdicts)),
+	}
+	for _, v := range refuted {
+		res.Findings = append(res.Findings, fmt.Sprintf("refuted: %s (%s)", v.Claim, v.Evidence))
+	}
+	return res
+}
+
+func annotateSummary(summary string, verdicts []types.ClaimVerdict) string {
+	if len(verdicts) == 0 {
+		return summary
+	}
+	var builder strings.Builder
+	builder.WriteString(strings.TrimSpace(summary))
+	builder.WriteString("\n\nVerification:\n")
+	for _, v := range verdicts {
+		builder.WriteString(fmt.Sprintf("- [%s] %s", v.Verdict, v.Claim))
+		if len(v.Sources) > 0 {
+			builder.WriteString(" (" + strings.Join(v.Sources, ", ") + ")")
+		}
+		builder.WriteString("\n")
+	}
+	return strings.TrimSpace(builder.String())
+}
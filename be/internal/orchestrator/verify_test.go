@@ -0,0 +1,87 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"backboard-swarm/be/internal/agent"
+	"backboard-swarm/be/internal/config"
+	"backboard-swarm/be/internal/types"
+)
+
+func TestExtractClaimsSplitsSentencesAndBullets(t *testing.T) {
+	claims := extractClaims("Findings:\n- Go 1.22 changed loop variable scoping. It shipped in February 2024.\n- ok\n1) The release also added range over integers.")
+	want := []string{
+		"Go 1.22 changed loop variable scoping.",
+		"It shipped in February 2024.",
+		"The release also added range over integers.",
+	}
+	if strings.Join(claims, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected claims: %q", claims)
+	}
+}
+
+func TestVerificationSendsOrchestratorBackOnRefutedClaims(t *testing.T) {
+	runner := &verifyingRunner{}
+	verify := true
+	s := NewSwarm(runner, config.Config{MaxSubagents: 2, MaxOrchRounds: 3}, nil)
+
+	out, err := s.Execute(context.Background(), Request{RunID: "run-1", Task: "when was Go 1.22 released?", Verify: &verify})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runner.decisions != 2 {
+		t.Fatalf("expected a second decision round after refutation, got %d", runner.decisions)
+	}
+	if !strings.Contains(runner.lastDecision, "refuted: Go 1.22 was released in March 2024.") {
+		t.Fatalf("expected refuted claim in follow-up prompt, got %s", runner.lastDecision)
+	}
+	if len(out.Verification) != 1 || out.Verification[0].Verdict != types.VerdictSupported {
+		t.Fatalf("expected supported verdict, got %+v", out.Verification)
+	}
+	if !strings.Contains(out.Summary, "[supported] Go 1.22 was released in February 2024. (S1)") {
+		t.Fatalf("expected annotated summary, got %q", out.Summary)
+	}
+	if strings.Join(runner.checkers, ",") != "verify-1,verify-1" {
+		t.Fatalf("expected read-only verify-N checkers, got %v", runner.checkers)
+	}
+}
+
+type verifyingRunner struct {
+	mu           sync.Mutex
+	decisions    int
+	lastDecision string
+	checkers     []string
+}
+
+func (v *verifyingRunner) RunTask(_ context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	switch {
+	case in.FinishMode == types.FinishPlan:
+		return agent.TaskResult{Payload: []byte(`{"subtasks":[{"role":"researcher","task":"find the date"}]}`)}, nil
+	case in.FinishMode == types.FinishDecision:
+		v.decisions++
+		v.lastDecision = in.Task
+		if v.decisions == 1 {
+			return agent.TaskResult{Payload: []byte(`{"action":"finalize","summary":"Go 1.22 was released in March 2024."}`)}, nil
+		}
+		return agent.TaskResult{Payload: []byte(`{"action":"finalize","summary":"Go 1.22 was released in February 2024."}`)}, nil
+	case in.Role == types.RoleFactChecker:
+		if in.ReadOnly && in.Delegator == nil {
+			v.checkers = append(v.checkers, in.AgentID)
+		}
+		verdict := "supported"
+		if strings.Contains(in.Task, "March") {
+			verdict = "refuted"
+		}
+		return agent.TaskResult{Payload: []byte(`{"summary":"checked","findings":["C1 ` + verdict + `: release notes say February [S1]"]}`)}, nil
+	}
+	return agent.TaskResult{Summary: "released February 2024 [S1]", Sources: []types.Source{{ID: "S1", URL: "https://go.dev/doc/go1.22"}}}, nil
+}
+
+func (v *verifyingRunner) EndRun(_ string) {}
+
+func (v *verifyingRunner) ResetSession(_, _ string) {}
@@ -0,0 +1,103 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/types"
+)
+
+// SetWorkspaces gives runs, and optionally each coder, an isolated copy of
+// the workspace root instead of the shared one.
+func (s *Swarm) SetWorkspaces(workspaces *runtime.WorkspaceManager) {
+	s.workspaces = workspaces
+}
+
+// workspaceFor returns the workspace root for an agent, or "" to use the
+// configured root.
+func (s *Swarm) workspaceFor(runID, agentID string, role types.Role) (string, error) {
+	if s.workspaces == nil {
+		return "", nil
+	}
+	return s.workspaces.Path(runID, agentID, role)
+}
+
+// mergeWorkspace folds an isolated coder's changes back into the run
+// workspace once the coder is done.
+func (s *Swarm) mergeWorkspace(runID, agentID string, role types.Role) {
+	if s.workspaces == nil || s.workspaces.Mode() != runtime.WorkspaceAgent {
+		return
+	}
+	status, message := "merged", "changes merged into the run workspace"
+	if err := s.workspaces.FinishAgent(runID, agentID); err != nil {
+		status, message = "conflict", err.Error()
+	}
+	s.emit(types.Event{
+		Type:      "workspace_merged",
+		RunID:     runID,
+		AgentID:   agentID,
+		Role:      role,
+		Status:    status,
+		Message:   message,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// settleWorkspace merges the workspace of an agent that succeeded and
+// discards that of one that failed.
+func (s *Swarm) settleWorkspace(runID, agentID string, res types.SubtaskResult) {
+	if res.Error != "" {
+		s.discardWorkspace(runID, agentID)
+		return
+	}
+	s.mergeWorkspace(runID, agentID, res.Subtask.Role.Normalize())
+}
+
+// settleMembers merges the chosen ensemble member's workspace and discards
+// the others. An empty chosen discards them all.
+func (s *Swarm) settleMembers(runID string, members []types.SubtaskResult, chosen string) {
+	for _, m := range members {
+		if m.AgentID == chosen {
+			s.settleWorkspace(runID, m.AgentID, m)
+			continue
+		}
+		s.discardWorkspace(runID, m.AgentID)
+	}
+}
+
+func (s *Swarm) discardWorkspace(runID, agentID string) {
+	if s.workspaces == nil || s.workspaces.Mode() != runtime.WorkspaceAgent {
+		return
+	}
+	s.workspaces.DiscardAgent(runID, agentID)
+}
+
+// endWorkspace saves the run's diff and removes its workspaces.
+func (s *Swarm) endWorkspace(runID string) {
+	if s.workspaces == nil || s.workspaces.Mode() == runtime.WorkspaceShared {
+		return
+	}
+	patch, merged, err := s.workspaces.EndRun(runID)
+	evt := types.Event{
+		Type:      "workspace_diff",
+		RunID:     runID,
+		Status:    "saved",
+		Message:   fmt.Sprintf("%d file(s) changed", changedFiles(patch)),
+		Timestamp: time.Now().UTC(),
+		Meta:      map[string]any{"merged": merged},
+	}
+	if merged {
+		evt.Status = "merged"
+	}
+	if err != nil {
+		evt.Status = "failed"
+		evt.Message = err.Error()
+	}
+	s.emit(evt)
+}
+
+func changedFiles(patch string) int {
+	return strings.Count(patch, "diff --git ")
+}
@@ -0,0 +1,263 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"backboard-swarm/be/internal/agent"
+	"backboard-swarm/be/internal/config"
+	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/types"
+)
+
+func TestCodersWorkInIsolatedWorktrees(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	for _, args := range [][]string{{"init", "-q"}, {"add", "-A"}, {"-c", "user.name=t", "-c", "user.email=t@t", "commit", "-q", "-m", "init"}} {
+		if args[0] == "add" {
+			writeFile(t, filepath.Join(root, "main.go"), "package main\n")
+		}
+		if out, err := exec.Command("git", append([]string{"-C", root}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v %s", args, err, out)
+		}
+	}
+	// Uncommitted edits to tracked files are part of the starting point.
+	writeFile(t, filepath.Join(root, "main.go"), "package main\n\nfunc main() {}\n")
+
+	workspaces, err := runtime.NewWorkspaceManager(root, t.TempDir(), runtime.WorkspaceAgent, false)
+	if err != nil {
+		t.Fatalf("workspaces: %v", err)
+	}
+	runner := &workspaceRunner{roots: map[string]string{}}
+	events := &eventLog{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 2}, events)
+	s.SetWorkspaces(workspaces)
+
+	if _, err := s.Execute(context.Background(), Request{RunID: "run-1", Task: "add two files"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	a, b := runner.roots["agent-1"], runner.roots["agent-2"]
+	if a == "" || a == b || a == root || b == root {
+		t.Fatalf("expected coders in separate workspaces, got %v", runner.roots)
+	}
+	if got := runner.seen["agent-1"]; !strings.Contains(got, "func main") {
+		t.Fatalf("expected uncommitted edits in the coder's workspace, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "agent-1.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected root to stay untouched without merge, got %v", err)
+	}
+	diff, err := workspaces.Diff("run-1")
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if !strings.Contains(diff, "b/agent-1.txt") || !strings.Contains(diff, "b/agent-2.txt") || strings.Contains(diff, "main.go") {
+		t.Fatalf("expected both coders' files in the run diff, got %s", diff)
+	}
+	if events.count("workspace_merged") != 2 || events.count("workspace_diff") != 1 {
+		t.Fatalf("expected merge and diff events, got %+v", events)
+	}
+	if _, err := os.Stat(a); !os.IsNotExist(err) {
+		t.Fatalf("expected coder workspace to be removed, got %v", err)
+	}
+}
+
+func TestRunWorkspaceCopyMergesBack(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "notes.txt"), "one\n")
+	workspaces, err := runtime.NewWorkspaceManager(root, t.TempDir(), runtime.WorkspaceRun, true)
+	if err != nil {
+		t.Fatalf("workspaces: %v", err)
+	}
+	runner := &workspaceRunner{roots: map[string]string{}}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 2}, &eventLog{})
+	s.SetWorkspaces(workspaces)
+
+	if _, err := s.Execute(context.Background(), Request{RunID: "run-2", Task: "add two files"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runner.roots["agent-1"] != runner.roots["agent-2"] || runner.roots["agent-1"] == root {
+		t.Fatalf("expected one shared run workspace, got %v", runner.roots)
+	}
+	for _, name := range []string{"agent-1.txt", "agent-2.txt"} {
+		if _, err := os.Stat(filepath.Join(root, name)); err != nil {
+			t.Fatalf("expected %s merged into root: %v", name, err)
+		}
+	}
+}
+
+func TestOnlyChosenAttemptIsMerged(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "notes.txt"), "one\n")
+	workspaces, err := runtime.NewWorkspaceManager(root, t.TempDir(), runtime.WorkspaceAgent, false)
+	if err != nil {
+		t.Fatalf("workspaces: %v", err)
+	}
+	runner := &attemptRunner{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 4, SubtaskRetries: 1}, &eventLog{})
+	s.SetWorkspaces(workspaces)
+
+	if _, err := s.Execute(context.Background(), Request{RunID: "run-1", Task: "write files"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	diff, err := workspaces.Diff("run-1")
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	for _, want := range []string{"b/agent-1-v1.txt", "b/agent-2-attempt-2.txt"} {
+		if !strings.Contains(diff, want) {
+			t.Fatalf("expected %s in the run diff, got %s", want, diff)
+		}
+	}
+	for _, unwanted := range []string{"agent-1-v2.txt", "agent-1-v3.txt", "agent-2-attempt-1.txt"} {
+		if strings.Contains(diff, unwanted) {
+			t.Fatalf("expected %s to be discarded, got %s", unwanted, diff)
+		}
+	}
+}
+
+// attemptRunner answers an ensemble of three coders identically and fails
+// the first attempt of a second coder.
+type attemptRunner struct {
+	mu       sync.Mutex
+	attempts int
+}
+
+func (a *attemptRunner) RunTask(_ context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	switch in.FinishMode {
+	case types.FinishPlan:
+		return agent.TaskResult{Payload: []byte(`{"subtasks":[{"role":"coder","task":"vote","ensemble":3},{"role":"coder","task":"retry"}]}`)}, nil
+	case types.FinishDecision:
+		return agent.TaskResult{Payload: []byte(`{"action":"finalize","summary":"done"}`)}, nil
+	}
+	name := in.AgentID
+	if in.AgentID == "agent-2" {
+		a.mu.Lock()
+		a.attempts++
+		name = fmt.Sprintf("%s-attempt-%d", in.AgentID, a.attempts)
+		a.mu.Unlock()
+	}
+	if err := os.WriteFile(filepath.Join(in.WorkspaceRoot, name+".txt"), []byte(name+"\n"), 0o644); err != nil {
+		return agent.TaskResult{}, err
+	}
+	if name == "agent-2-attempt-1" {
+		return agent.TaskResult{}, errors.New("tool loop failed")
+	}
+	return agent.TaskResult{Summary: "wrote the file"}, nil
+}
+
+func (a *attemptRunner) EndRun(_ string) {}
+
+func (a *attemptRunner) ResetSession(_, _ string) {}
+
+func TestJudgedEnsembleMergesTheNamedMember(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "notes.txt"), "one\n")
+	workspaces, err := runtime.NewWorkspaceManager(root, t.TempDir(), runtime.WorkspaceAgent, false)
+	if err != nil {
+		t.Fatalf("workspaces: %v", err)
+	}
+	runner := &judgedRunner{}
+	s := NewSwarm(runner, config.Config{MaxSubagents: 4}, &eventLog{})
+	s.SetWorkspaces(workspaces)
+
+	if _, err := s.Execute(context.Background(), Request{RunID: "run-1", Task: "write files"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !runner.judgeReadOnly {
+		t.Fatal("expected the judge to run read-only")
+	}
+	diff, err := workspaces.Diff("run-1")
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if !strings.Contains(diff, "b/agent-1-v2.txt") || strings.Contains(diff, "agent-1-v1.txt") {
+		t.Fatalf("expected only the judged member's edits, got %s", diff)
+	}
+}
+
+// judgedRunner answers an ensemble of two coders differently and has the
+// judge pick the second.
+type judgedRunner struct {
+	mu            sync.Mutex
+	judgeReadOnly bool
+}
+
+func (j *judgedRunner) RunTask(_ context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	switch in.FinishMode {
+	case types.FinishPlan:
+		return agent.TaskResult{Payload: []byte(`{"subtasks":[{"role":"coder","task":"write","ensemble":2}]}`)}, nil
+	case types.FinishDecision:
+		return agent.TaskResult{Payload: []byte(`{"action":"finalize","summary":"done"}`)}, nil
+	}
+	if strings.HasSuffix(in.AgentID, "-judge") {
+		j.mu.Lock()
+		j.judgeReadOnly = in.ReadOnly
+		j.mu.Unlock()
+		return agent.TaskResult{Summary: "the second approach is cleaner\nBEST: 2"}, nil
+	}
+	if err := os.WriteFile(filepath.Join(in.WorkspaceRoot, in.AgentID+".txt"), []byte(in.AgentID+"\n"), 0o644); err != nil {
+		return agent.TaskResult{}, err
+	}
+	return agent.TaskResult{Summary: "wrote " + in.AgentID}, nil
+}
+
+func (j *judgedRunner) EndRun(_ string) {}
+
+func (j *judgedRunner) ResetSession(_, _ string) {}
+
+type workspaceRunner struct {
+	mu    sync.Mutex
+	roots map[string]string
+	seen  map[string]string
+}
+
+func (w *workspaceRunner) RunTask(_ context.Context, in agent.TaskInput) (agent.TaskResult, error) {
+	switch in.FinishMode {
+	case types.FinishPlan:
+		return agent.TaskResult{Payload: []byte(`{"subtasks":[{"role":"coder","task":"write agent-1.txt"},{"role":"coder","task":"write agent-2.txt"}]}`)}, nil
+	case types.FinishDecision:
+		return agent.TaskResult{Payload: []byte(`{"action":"finalize","summary":"done"}`)}, nil
+	}
+	main, _ := os.ReadFile(filepath.Join(in.WorkspaceRoot, "main.go"))
+	if err := os.WriteFile(filepath.Join(in.WorkspaceRoot, in.AgentID+".txt"), []byte(in.AgentID+"\n"), 0o644); err != nil {
+		return agent.TaskResult{}, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.roots[in.AgentID] = in.WorkspaceRoot
+	if w.seen == nil {
+		w.seen = map[string]string{}
+	}
+	w.seen[in.AgentID] = string(main)
+	return agent.TaskResult{Summary: "wrote " + in.AgentID + ".txt"}, nil
+}
+
+func (w *workspaceRunner) EndRun(_ string) {}
+
+func (w *workspaceRunner) ResetSession(_, _ string) {}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,55 @@
+package runtime
+
+import "sync"
+
+const (
+	ApprovalApprove = "approve"
+	ApprovalDeny    = "deny"
+	ApprovalEdit    = "edit"
+)
+
+type ApprovalDecision struct {
+	Decision  string         `json:"decision"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	Reason    string         `json:"reason,omitempty"`
+}
+
+type ApprovalStore struct {
+	mu      sync.Mutex
+	pending map[string]chan ApprovalDecision
+}
+
+func NewApprovalStore() *ApprovalStore {
+	return &ApprovalStore{pending: make(map[string]chan ApprovalDecision)}
+}
+
+func (s *ApprovalStore) Open(runID, toolCallID string) <-chan ApprovalDecision {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := make(chan ApprovalDecision, 1)
+	s.pending[approvalKey(runID, toolCallID)] = ch
+	return ch
+}
+
+func (s *ApprovalStore) Resolve(runID, toolCallID string, d ApprovalDecision) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := approvalKey(runID, toolCallID)
+	ch, ok := s.pending[key]
+	if !ok {
+		return false
+	}
+	delete(s.pending, key)
+	ch <- d
+	return true
+}
+
+func (s *ApprovalStore) Close(runID, toolCallID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, approvalKey(runID, toolCallID))
+}
+
+func approvalKey(runID, toolCallID string) string {
+	return runID + "::" + toolCallID
+}
@@ -0,0 +1,112 @@
+package runtime
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"backboard-swarm/be/internal/types"
+)
+
+type BoardEntry struct {
+	Seq       int        `json:"seq"`
+	AgentID   string     `json:"agent_id"`
+	Role      types.Role `json:"role"`
+	Topic     string     `json:"topic,omitempty"`
+	Content   string     `json:"content"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type boardRun struct {
+	entries []BoardEntry
+	// changed is closed and replaced on every post to wake subscribers.
+	changed chan struct{}
+}
+
+// BoardStore is a run-scoped blackboard that agents working in parallel use
+// to share intermediate findings.
+type BoardStore struct {
+	mu   sync.Mutex
+	runs map[string]*boardRun
+}
+
+func NewBoardStore() *BoardStore {
+	return &BoardStore{runs: make(map[string]*boardRun)}
+}
+
+func (s *BoardStore) run(runID string) *boardRun {
+	b, ok := s.runs[runID]
+	if !ok {
+		b = &boardRun{changed: make(chan struct{})}
+		s.runs[runID] = b
+	}
+	return b
+}
+
+func (s *BoardStore) Post(runID, agentID string, role types.Role, topic, content string) BoardEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.run(runID)
+	entry := BoardEntry{
+		Seq:       len(b.entries) + 1,
+		AgentID:   agentID,
+		Role:      role,
+		Topic:     strings.TrimSpace(topic),
+		Content:   content,
+		CreatedAt: time.Now().UTC(),
+	}
+	b.entries = append(b.entries, entry)
+	close(b.changed)
+	b.changed = make(chan struct{})
+	return entry
+}
+
+// Read returns entries with a sequence number above since, optionally
+// filtered by topic, and the latest sequence number on the board.
+func (s *BoardStore) Read(runID string, since int, topic string) ([]BoardEntry, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, latest, _ := s.read(runID, since, topic)
+	return entries, latest
+}
+
+func (s *BoardStore) read(runID string, since int, topic string) ([]BoardEntry, int, <-chan struct{}) {
+	b := s.run(runID)
+	topic = strings.TrimSpace(topic)
+	out := make([]BoardEntry, 0)
+	for _, e := range b.entries {
+		if e.Seq > since && (topic == "" || strings.EqualFold(e.Topic, topic)) {
+			out = append(out, e)
+		}
+	}
+	return out, len(b.entries), b.changed
+}
+
+// Wait blocks until entries newer than since (matching topic) exist, the
+// timeout elapses or ctx is done. It returns whatever is new at that point.
+func (s *BoardStore) Wait(ctx context.Context, runID string, since int, topic string, timeout time.Duration) ([]BoardEntry, int) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		s.mu.Lock()
+		entries, latest, changed := s.read(runID, since, topic)
+		s.mu.Unlock()
+		if len(entries) > 0 {
+			return entries, latest
+		}
+		select {
+		case <-ctx.Done():
+			return entries, latest
+		case <-timer.C:
+			return entries, latest
+		case <-changed:
+		}
+	}
+}
+
+func (s *BoardStore) EndRun(runID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.runs, runID)
+}
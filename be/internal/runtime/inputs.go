@@ -0,0 +1,104 @@
+package runtime
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type PendingQuestion struct {
+	QuestionID string    `json:"question_id"`
+	AgentID    string    `json:"agent_id"`
+	Question   string    `json:"question"`
+	Options    []string  `json:"options,omitempty"`
+	AskedAt    time.Time `json:"asked_at"`
+}
+
+type pendingInput struct {
+	question PendingQuestion
+	answer   chan string
+}
+
+// InputStore tracks questions agents have asked the user. While any question
+// is open for a run, the run is reported as waiting_for_input.
+type InputStore struct {
+	mu      sync.Mutex
+	runs    *RunStore
+	pending map[string]map[string]pendingInput
+	seq     atomic.Uint64
+}
+
+func NewInputStore(runs *RunStore) *InputStore {
+	return &InputStore{runs: runs, pending: make(map[string]map[string]pendingInput)}
+}
+
+func (s *InputStore) Open(runID, agentID, question string, options []string) (PendingQuestion, <-chan string) {
+	q := PendingQuestion{
+		QuestionID: fmt.Sprintf("q-%d", s.seq.Add(1)),
+		AgentID:    agentID,
+		Question:   question,
+		Options:    options,
+		AskedAt:    time.Now().UTC(),
+	}
+	ch := make(chan string, 1)
+
+	s.mu.Lock()
+	if _, ok := s.pending[runID]; !ok {
+		s.pending[runID] = make(map[string]pendingInput)
+	}
+	s.pending[runID][q.QuestionID] = pendingInput{question: q, answer: ch}
+	open := len(s.pending[runID])
+	s.mu.Unlock()
+
+	if s.runs != nil {
+		s.runs.SetWaitingForInput(runID, open)
+	}
+	return q, ch
+}
+
+// Answer delivers an answer. An empty questionID answers the only open
+// question of the run.
+func (s *InputStore) Answer(runID, questionID, answer string) (PendingQuestion, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runPending := s.pending[runID]
+	if questionID == "" && len(runPending) == 1 {
+		for id := range runPending {
+			questionID = id
+		}
+	}
+	p, ok := runPending[questionID]
+	if !ok {
+		return PendingQuestion{}, false
+	}
+	delete(runPending, questionID)
+	p.answer <- answer
+	return p.question, true
+}
+
+func (s *InputStore) Close(runID, questionID string) {
+	s.mu.Lock()
+	delete(s.pending[runID], questionID)
+	open := len(s.pending[runID])
+	if open == 0 {
+		delete(s.pending, runID)
+	}
+	s.mu.Unlock()
+
+	if s.runs != nil {
+		s.runs.SetWaitingForInput(runID, open)
+	}
+}
+
+func (s *InputStore) Pending(runID string) []PendingQuestion {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]PendingQuestion, 0, len(s.pending[runID]))
+	for _, p := range s.pending[runID] {
+		out = append(out, p.question)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AskedAt.Before(out[j].AskedAt) })
+	return out
+}
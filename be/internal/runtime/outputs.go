@@ -0,0 +1,97 @@
+package runtime
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+type OutputStore struct {
+	mu    sync.RWMutex
+	byRun map[string]map[string]string
+	seq   atomic.Uint64
+}
+
+type OutputPage struct {
+	OutputID   string `json:"output_id"`
+	Offset     int    `json:"offset"`
+	NextOffset int    `json:"next_offset"`
+	TotalBytes int    `json:"total_bytes"`
+	Content    string `json:"content"`
+	Done       bool   `json:"done"`
+}
+
+func NewOutputStore() *OutputStore {
+	return &OutputStore{byRun: make(map[string]map[string]string)}
+}
+
+func (s *OutputStore) Put(runID, content string) string {
+	id := fmt.Sprintf("out-%d", s.seq.Add(1))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.byRun[runID]; !ok {
+		s.byRun[runID] = make(map[string]string)
+	}
+	s.byRun[runID][id] = content
+	return id
+}
+
+func (s *OutputStore) Read(runID, id string, offset, limit int) (OutputPage, bool) {
+	s.mu.RLock()
+	content, ok := s.byRun[runID][id]
+	s.mu.RUnlock()
+	if !ok {
+		return OutputPage{}, false
+	}
+
+	total := len(content)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	for offset < total && !utf8.RuneStart(content[offset]) {
+		offset++
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = RuneBoundary(content, offset+limit)
+		// A limit narrower than the rune at offset still returns that rune,
+		// so paging by NextOffset always advances.
+		if end <= offset {
+			_, size := utf8.DecodeRuneInString(content[offset:])
+			end = offset + size
+		}
+	}
+	return OutputPage{
+		OutputID:   id,
+		Offset:     offset,
+		NextOffset: end,
+		TotalBytes: total,
+		Content:    content[offset:end],
+		Done:       end >= total,
+	}, true
+}
+
+func (s *OutputStore) EndRun(runID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.byRun, runID)
+}
+
+// RuneBoundary moves n back to the nearest UTF-8 rune start so that s[:n]
+// never splits a multi-byte character.
+func RuneBoundary(s string, n int) int {
+	if n >= len(s) {
+		return len(s)
+	}
+	if n <= 0 {
+		return 0
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return n
+}
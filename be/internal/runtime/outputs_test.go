@@ -0,0 +1,28 @@
+package runtime
+
+import "testing"
+
+func TestOutputReadAdvancesPastWideRunes(t *testing.T) {
+	store := NewOutputStore()
+	id := store.Put("run-1", "a€b")
+
+	var got string
+	offset := 0
+	for i := 0; i < 10; i++ {
+		page, ok := store.Read("run-1", id, offset, 1)
+		if !ok {
+			t.Fatal("output not found")
+		}
+		if page.NextOffset <= page.Offset {
+			t.Fatalf("page at %d did not advance: %+v", offset, page)
+		}
+		got += page.Content
+		offset = page.NextOffset
+		if page.Done {
+			break
+		}
+	}
+	if got != "a€b" {
+		t.Fatalf("expected paged content to reassemble, got %q", got)
+	}
+}
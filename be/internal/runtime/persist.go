@@ -0,0 +1,87 @@
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loadJSON reads path into v. A missing file leaves v untouched.
+func loadJSON(path string, v any) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// saveJSON writes v to path through a temporary file so a crash never
+// leaves a half-written file behind.
+func saveJSON(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// runFile is the file that keeps one run's state under dir.
+func runFile(dir, runID string) string {
+	return filepath.Join(dir, url.PathEscape(runID)+".json")
+}
+
+// loadRunFiles reads the per-run files under dir, keyed by run id. A file
+// from before state was kept per run, at dir+".json", is split into per-run
+// files and removed.
+func loadRunFiles[T any](dir string) (map[string]T, error) {
+	out := map[string]T{}
+	legacy := dir + ".json"
+	if err := loadJSON(legacy, &out); err != nil {
+		return nil, err
+	}
+	migrate := len(out) > 0
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if e.IsDir() || !ok {
+			continue
+		}
+		runID, err := url.PathUnescape(name)
+		if err != nil {
+			continue
+		}
+		var v T
+		if err := loadJSON(filepath.Join(dir, e.Name()), &v); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		out[runID] = v
+	}
+	if migrate {
+		for runID, v := range out {
+			if err := saveJSON(runFile(dir, runID), v); err != nil {
+				return nil, err
+			}
+		}
+		if err := os.Remove(legacy); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,45 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunStoreSplitsLegacyFileAndSavesPerRun(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "runs")
+	legacy := `{"run-1":{"run_id":"run-1","task":"old","status":"completed"},"run-2":{"run_id":"run-2","task":"cut off","status":"running"}}`
+	if err := os.WriteFile(dir+".json", []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewPersistentRunStore(dir)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	if _, err := os.Stat(dir + ".json"); !os.IsNotExist(err) {
+		t.Fatalf("expected the legacy file to be removed, got %v", err)
+	}
+	if r, ok := store.Get("run-2"); !ok || r.Status != "failed" {
+		t.Fatalf("expected the interrupted run to be failed, got %+v", r)
+	}
+
+	id := store.New("fresh")
+	store.SetRunning(id)
+	for _, run := range []string{"run-1", "run-2", id} {
+		if _, err := os.Stat(filepath.Join(dir, run+".json")); err != nil {
+			t.Fatalf("expected a file for %s: %v", run, err)
+		}
+	}
+
+	reloaded, err := NewPersistentRunStore(dir)
+	if err != nil {
+		t.Fatalf("reload store: %v", err)
+	}
+	if r, ok := reloaded.Get("run-1"); !ok || r.Task != "old" {
+		t.Fatalf("expected run-1 after reload, got %+v", r)
+	}
+	if r, ok := reloaded.Get(id); !ok || r.Status != "failed" {
+		t.Fatalf("expected the running run to be failed after reload, got %+v", r)
+	}
+}
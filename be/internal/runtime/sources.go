@@ -0,0 +1,138 @@
+package runtime
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"backboard-swarm/be/internal/types"
+)
+
+var (
+	citationRe = regexp.MustCompile(`\[(S\d+(?:\s*,\s*S\d+)*)\]`)
+	sourceIDRe = regexp.MustCompile(`(?i)^\[?S\d+\]?$`)
+)
+
+type runSources struct {
+	byID  map[string]types.Source
+	byKey map[string]string
+	order []string
+}
+
+// SourceStore assigns stable per-run ids (S1, S2, ...) to the URLs and files
+// agents consult, so findings can cite them and the final answer can carry a
+// bibliography.
+type SourceStore struct {
+	mu    sync.Mutex
+	byRun map[string]*runSources
+}
+
+func NewSourceStore() *SourceStore {
+	return &SourceStore{byRun: make(map[string]*runSources)}
+}
+
+func (s *SourceStore) Register(runID string, src types.Source) types.Source {
+	key := sourceKey(src.URL)
+	if key == "" {
+		return types.Source{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rs, ok := s.byRun[runID]
+	if !ok {
+		rs = &runSources{byID: make(map[string]types.Source), byKey: make(map[string]string)}
+		s.byRun[runID] = rs
+	}
+	if id, ok := rs.byKey[key]; ok {
+		existing := rs.byID[id]
+		if existing.Title == "" && src.Title != "" {
+			existing.Title = src.Title
+			rs.byID[id] = existing
+		}
+		return existing
+	}
+	src.ID = fmt.Sprintf("S%d", len(rs.order)+1)
+	src.URL = strings.TrimSpace(src.URL)
+	src.Title = strings.TrimSpace(src.Title)
+	if src.AddedAt.IsZero() {
+		src.AddedAt = time.Now().UTC()
+	}
+	rs.byID[src.ID] = src
+	rs.byKey[key] = src.ID
+	rs.order = append(rs.order, src.ID)
+	return src
+}
+
+// Resolve looks a reference up by id or by URL.
+func (s *SourceStore) Resolve(runID, ref string) (types.Source, bool) {
+	ref = strings.Trim(strings.TrimSpace(ref), "[]")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rs, ok := s.byRun[runID]
+	if !ok {
+		return types.Source{}, false
+	}
+	if src, ok := rs.byID[strings.ToUpper(ref)]; ok {
+		return src, true
+	}
+	if id, ok := rs.byKey[sourceKey(ref)]; ok {
+		return rs.byID[id], true
+	}
+	return types.Source{}, false
+}
+
+func (s *SourceStore) List(runID string) []types.Source {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rs, ok := s.byRun[runID]
+	if !ok {
+		return nil
+	}
+	out := make([]types.Source, 0, len(rs.order))
+	for _, id := range rs.order {
+		out = append(out, rs.byID[id])
+	}
+	return out
+}
+
+func (s *SourceStore) EndRun(runID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.byRun, runID)
+}
+
+// CitedSourceIDs returns the source ids cited in text, either alone as
+// [S<n>] or grouped as [S1, S2].
+func CitedSourceIDs(text string) []string {
+	matches := citationRe.FindAllStringSubmatch(text, -1)
+	out := make([]string, 0, len(matches))
+	for _, m := range matches {
+		for _, id := range strings.Split(m[1], ",") {
+			out = append(out, strings.TrimSpace(id))
+		}
+	}
+	return out
+}
+
+func IsSourceID(ref string) bool {
+	return sourceIDRe.MatchString(strings.TrimSpace(ref))
+}
+
+func sourceKey(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.Path = strings.TrimRight(u.Path, "/")
+	return u.String()
+}
@@ -0,0 +1,14 @@
+package runtime
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCitedSourceIDsSplitsGroupedCitations(t *testing.T) {
+	got := CitedSourceIDs("Paris is the capital [S1, S2] and has 2M people [S3][S4,S5]; see [S6 and [x].")
+	want := []string{"S1", "S2", "S3", "S4", "S5"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
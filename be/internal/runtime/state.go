@@ -2,9 +2,12 @@ package runtime
 
 import (
 	"fmt"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"backboard-swarm/be/internal/types"
 )
 
 type AssistantStore struct {
@@ -29,120 +32,69 @@ func (s *AssistantStore) Set(role, assistantID string) {
 	s.ids[role] = assistantID
 }
 
-type TodoItem struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Completed bool      `json:"completed"`
-	CreatedAt time.Time `json:"created_at"`
-}
-
-type TodoStore struct {
-	mu      sync.RWMutex
-	byAgent map[string]map[string]TodoItem
-	seq     atomic.Uint64
-}
-
-func NewTodoStore() *TodoStore {
-	return &TodoStore{byAgent: make(map[string]map[string]TodoItem)}
-}
-
-func (s *TodoStore) Create(agentID, title string) TodoItem {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if _, ok := s.byAgent[agentID]; !ok {
-		s.byAgent[agentID] = make(map[string]TodoItem)
-	}
-	id := fmt.Sprintf("todo-%d", s.seq.Add(1))
-	item := TodoItem{ID: id, Title: title, CreatedAt: time.Now().UTC()}
-	s.byAgent[agentID][id] = item
-	return item
-}
-
-func (s *TodoStore) Update(agentID, id, title string) (TodoItem, bool) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	agentTodos, ok := s.byAgent[agentID]
-	if !ok {
-		return TodoItem{}, false
-	}
-	item, ok := agentTodos[id]
-	if !ok {
-		return TodoItem{}, false
-	}
-	item.Title = title
-	agentTodos[id] = item
-	return item, true
-}
-
-func (s *TodoStore) Delete(agentID, id string) bool {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	agentTodos, ok := s.byAgent[agentID]
-	if !ok {
-		return false
-	}
-	if _, ok := agentTodos[id]; !ok {
-		return false
-	}
-	delete(agentTodos, id)
-	return true
-}
-
-func (s *TodoStore) Complete(agentID, id string) (TodoItem, bool) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	agentTodos, ok := s.byAgent[agentID]
-	if !ok {
-		return TodoItem{}, false
-	}
-	item, ok := agentTodos[id]
-	if !ok {
-		return TodoItem{}, false
-	}
-	item.Completed = true
-	agentTodos[id] = item
-	return item, true
-}
-
-func (s *TodoStore) List(agentID string) []TodoItem {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	agentTodos, ok := s.byAgent[agentID]
-	if !ok {
-		return nil
-	}
-	out := make([]TodoItem, 0, len(agentTodos))
-	for _, item := range agentTodos {
-		out = append(out, item)
-	}
-	return out
-}
-
 type RunStatus struct {
 	RunID      string    `json:"run_id"`
 	Task       string    `json:"task"`
 	Status     string    `json:"status"`
 	Summary    string    `json:"summary,omitempty"`
 	Error      string    `json:"error,omitempty"`
+	Waiting    int       `json:"waiting_for_input,omitempty"`
 	StartedAt  time.Time `json:"started_at"`
 	FinishedAt time.Time `json:"finished_at,omitempty"`
+
+	Bibliography []types.Source `json:"bibliography,omitempty"`
+	Unsourced    []string       `json:"unsourced_claims,omitempty"`
 }
 
 type RunStore struct {
 	mu   sync.RWMutex
 	runs map[string]RunStatus
 	seq  atomic.Uint64
+	dir  string
 }
 
 func NewRunStore() *RunStore {
 	return &RunStore{runs: make(map[string]RunStatus)}
 }
 
+// NewPersistentRunStore loads the runs saved under dir, one file per run,
+// and saves a run after every change to it. Runs that were still active
+// when the process stopped are marked failed.
+func NewPersistentRunStore(dir string) (*RunStore, error) {
+	s := NewRunStore()
+	s.dir = dir
+	runs, err := loadRunFiles[RunStatus](dir)
+	if err != nil {
+		return nil, fmt.Errorf("load runs: %w", err)
+	}
+	s.runs = runs
+	for id, r := range s.runs {
+		if r.Status != "completed" && r.Status != "failed" {
+			r.Status = "failed"
+			r.Error = "interrupted by server restart"
+			r.Waiting = 0
+			s.runs[id] = r
+		}
+	}
+	return s, nil
+}
+
+// save writes one run's file. It must be called with s.mu held.
+func (s *RunStore) save(runID string) {
+	if s.dir == "" {
+		return
+	}
+	if err := saveJSON(runFile(s.dir, runID), s.runs[runID]); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: save runs: %v\n", err)
+	}
+}
+
 func (s *RunStore) New(task string) string {
 	id := fmt.Sprintf("run-%d-%d", time.Now().UnixMilli(), s.seq.Add(1))
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.runs[id] = RunStatus{RunID: id, Task: task, Status: "queued", StartedAt: time.Now().UTC()}
+	s.save(id)
 	return id
 }
 
@@ -152,16 +104,40 @@ func (s *RunStore) SetRunning(runID string) {
 	r := s.runs[runID]
 	r.Status = "running"
 	s.runs[runID] = r
+	s.save(runID)
+}
+
+// SetWaitingForInput records how many agent questions are open. The run
+// moves to waiting_for_input while any are open and back to running after.
+func (s *RunStore) SetWaitingForInput(runID string, open int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.runs[runID]
+	if !ok {
+		return
+	}
+	r.Waiting = open
+	switch {
+	case open > 0 && r.Status == "running":
+		r.Status = "waiting_for_input"
+	case open == 0 && r.Status == "waiting_for_input":
+		r.Status = "running"
+	}
+	s.runs[runID] = r
+	s.save(runID)
 }
 
-func (s *RunStore) SetCompleted(runID, summary string) {
+func (s *RunStore) SetCompleted(runID, summary string, bibliography []types.Source, unsourced []string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	r := s.runs[runID]
 	r.Status = "completed"
 	r.Summary = summary
+	r.Bibliography = bibliography
+	r.Unsourced = unsourced
 	r.FinishedAt = time.Now().UTC()
 	s.runs[runID] = r
+	s.save(runID)
 }
 
 func (s *RunStore) SetFailed(runID string, err error) {
@@ -174,6 +150,7 @@ func (s *RunStore) SetFailed(runID string, err error) {
 	}
 	r.FinishedAt = time.Now().UTC()
 	s.runs[runID] = r
+	s.save(runID)
 }
 
 func (s *RunStore) Get(runID string) (RunStatus, bool) {
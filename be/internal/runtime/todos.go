@@ -0,0 +1,469 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TodoStatus string
+
+const (
+	TodoPending    TodoStatus = "pending"
+	TodoInProgress TodoStatus = "in_progress"
+	TodoBlocked    TodoStatus = "blocked"
+	TodoDone       TodoStatus = "done"
+	TodoCancelled  TodoStatus = "cancelled"
+)
+
+var ErrTodoNotFound = errors.New("todo not found")
+
+func ValidTodoStatus(s TodoStatus) bool {
+	switch s {
+	case TodoPending, TodoInProgress, TodoBlocked, TodoDone, TodoCancelled:
+		return true
+	}
+	return false
+}
+
+func (s TodoStatus) closed() bool {
+	return s == TodoDone || s == TodoCancelled
+}
+
+type TodoItem struct {
+	ID        string     `json:"id"`
+	AgentID   string     `json:"agent_id"`
+	Title     string     `json:"title"`
+	Status    TodoStatus `json:"status"`
+	Completed bool       `json:"completed"`
+	Order     int        `json:"order"`
+	Notes     string     `json:"notes,omitempty"`
+	ParentID  string     `json:"parent_id,omitempty"`
+	DependsOn []string   `json:"depends_on,omitempty"`
+	// Archived is set once the agent id moved on to another subtask.
+	Archived bool `json:"archived,omitempty"`
+	// Key names an item within a CreateAll batch; it is not stored.
+	Key       string    `json:"key,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TodoUpdate changes the todo with ID. Nil fields are left as they are.
+type TodoUpdate struct {
+	ID        string
+	Title     *string
+	Status    *TodoStatus
+	Notes     *string
+	Order     *int
+	ParentID  *string
+	DependsOn *[]string
+}
+
+// TodoStore keeps agent todos per run and agent. When created with a
+// directory it loads existing todos from disk and saves a run's todos after
+// every change to them.
+type TodoStore struct {
+	mu    sync.RWMutex
+	byRun map[string]map[string]map[string]TodoItem
+	seq   uint64
+	dir   string
+}
+
+func NewTodoStore() *TodoStore {
+	return &TodoStore{byRun: make(map[string]map[string]map[string]TodoItem)}
+}
+
+func NewPersistentTodoStore(dir string) (*TodoStore, error) {
+	s := NewTodoStore()
+	s.dir = dir
+	byRun, err := loadRunFiles[map[string]map[string]TodoItem](dir)
+	if err != nil {
+		return nil, fmt.Errorf("load todos: %w", err)
+	}
+	s.byRun = byRun
+	for _, agents := range s.byRun {
+		for _, items := range agents {
+			for id, item := range items {
+				if n := todoOrdinal(id); n > s.seq {
+					s.seq = n
+				}
+				// Files written before statuses existed only carry completed.
+				if item.Status == "" {
+					item.Status = TodoPending
+					if item.Completed {
+						item.Status = TodoDone
+					}
+					items[id] = item
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+func (s *TodoStore) agentTodos(runID, agentID string, create bool) map[string]TodoItem {
+	agents, ok := s.byRun[runID]
+	if !ok {
+		if !create {
+			return nil
+		}
+		agents = make(map[string]map[string]TodoItem)
+		s.byRun[runID] = agents
+	}
+	items, ok := agents[agentID]
+	if !ok && create {
+		items = make(map[string]TodoItem)
+		agents[agentID] = items
+	}
+	return items
+}
+
+// save writes one run's todos. It must be called with s.mu held.
+func (s *TodoStore) save(runID string) {
+	if s.dir == "" {
+		return
+	}
+	if err := saveJSON(runFile(s.dir, runID), s.byRun[runID]); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: save todos: %v\n", err)
+	}
+}
+
+func (s *TodoStore) Create(runID, agentID string, item TodoItem) (TodoItem, error) {
+	out, err := s.CreateAll(runID, agentID, []TodoItem{item})
+	if err != nil {
+		return TodoItem{}, err
+	}
+	return out[0], nil
+}
+
+// CreateAll adds every item or none of them. Ids are only assigned here, so
+// later items refer to earlier ones of the same batch by their Key in
+// ParentID and DependsOn.
+func (s *TodoStore) CreateAll(runID, agentID string, items []TodoItem) ([]TodoItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := cloneTodos(s.agentTodos(runID, agentID, false))
+	seq := s.seq
+	now := time.Now().UTC()
+	out := make([]TodoItem, 0, len(items))
+	keys := map[string]string{}
+	resolve := func(ref string) string {
+		if id, ok := keys[ref]; ok {
+			return id
+		}
+		return ref
+	}
+	for _, item := range items {
+		seq++
+		item.ID = fmt.Sprintf("todo-%d", seq)
+		item.ParentID = resolve(item.ParentID)
+		deps := make([]string, 0, len(item.DependsOn))
+		for _, dep := range item.DependsOn {
+			deps = append(deps, resolve(dep))
+		}
+		item.DependsOn = deps
+		if item.Key != "" {
+			if _, dup := keys[item.Key]; dup {
+				return nil, fmt.Errorf("key %q is used twice", item.Key)
+			}
+			keys[item.Key] = item.ID
+		}
+		item.AgentID = agentID
+		item.Title = strings.TrimSpace(item.Title)
+		if item.Title == "" {
+			return nil, errors.New("title is required")
+		}
+		if item.Status == "" {
+			item.Status = TodoPending
+		}
+		if item.Order <= 0 {
+			item.Order = nextOrder(next, item.ParentID)
+		}
+		item.CreatedAt, item.UpdatedAt = now, now
+		if err := checkTodo(next, item); err != nil {
+			return nil, err
+		}
+		item.Completed = item.Status == TodoDone
+		out = append(out, item)
+		item.Key = ""
+		next[item.ID] = item
+	}
+	s.seq = seq
+	s.commit(runID, agentID, next)
+	return out, nil
+}
+
+func (s *TodoStore) Update(runID, agentID string, update TodoUpdate) (TodoItem, error) {
+	out, err := s.UpdateAll(runID, agentID, []TodoUpdate{update})
+	if err != nil {
+		return TodoItem{}, err
+	}
+	return out[0], nil
+}
+
+func (s *TodoStore) Complete(runID, agentID, id string) (TodoItem, error) {
+	done := TodoDone
+	return s.Update(runID, agentID, TodoUpdate{ID: id, Status: &done})
+}
+
+// UpdateAll applies the updates in order and keeps the store unchanged if
+// any of them fails.
+func (s *TodoStore) UpdateAll(runID, agentID string, updates []TodoUpdate) ([]TodoItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := cloneTodos(s.agentTodos(runID, agentID, false))
+	now := time.Now().UTC()
+	out := make([]TodoItem, 0, len(updates))
+	for _, u := range updates {
+		item, ok := next[u.ID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrTodoNotFound, u.ID)
+		}
+		if u.Title != nil {
+			item.Title = strings.TrimSpace(*u.Title)
+			if item.Title == "" {
+				return nil, fmt.Errorf("%s: title must not be empty", u.ID)
+			}
+		}
+		if u.Status != nil {
+			item.Status = *u.Status
+		}
+		if u.Notes != nil {
+			item.Notes = *u.Notes
+		}
+		if u.ParentID != nil {
+			item.ParentID = *u.ParentID
+		}
+		if u.DependsOn != nil {
+			item.DependsOn = append([]string(nil), (*u.DependsOn)...)
+		}
+		if u.Order != nil {
+			item.Order = *u.Order
+		} else if u.ParentID != nil {
+			item.Order = nextOrder(next, item.ParentID)
+		}
+		if err := checkTodo(next, item); err != nil {
+			return nil, err
+		}
+		item.Completed = item.Status == TodoDone
+		item.UpdatedAt = now
+		next[item.ID] = item
+		out = append(out, item)
+	}
+	s.commit(runID, agentID, next)
+	return out, nil
+}
+
+// Delete removes a todo. Its children take its place under its parent and
+// other todos stop depending on it.
+func (s *TodoStore) Delete(runID, agentID, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.agentTodos(runID, agentID, false)
+	deleted, ok := items[id]
+	if !ok {
+		return false
+	}
+	delete(items, id)
+	for otherID, item := range items {
+		changed := false
+		if item.ParentID == id {
+			item.ParentID = deleted.ParentID
+			item.Order = deleted.Order
+			changed = true
+		}
+		if deps := removeString(item.DependsOn, id); len(deps) != len(item.DependsOn) {
+			item.DependsOn = deps
+			changed = true
+		}
+		if changed {
+			items[otherID] = item
+		}
+	}
+	s.save(runID)
+	return true
+}
+
+// Archive retires an agent's todos, e.g. when its id is reused for a new
+// subtask. They are kept under a numbered key such as agent-1#1 and marked
+// archived, so they drop out of the agent's list and ListRun but stay in
+// History. It returns the archived todos.
+func (s *TodoStore) Archive(runID, agentID string) []TodoItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.agentTodos(runID, agentID, false)
+	if len(items) == 0 {
+		return nil
+	}
+	key := ""
+	for n := 1; ; n++ {
+		key = fmt.Sprintf("%s#%d", agentID, n)
+		if _, taken := s.byRun[runID][key]; !taken {
+			break
+		}
+	}
+	archived := make(map[string]TodoItem, len(items))
+	for id, item := range items {
+		item.Archived = true
+		archived[id] = item
+	}
+	s.byRun[runID][key] = archived
+	delete(s.byRun[runID], agentID)
+	s.save(runID)
+	return sortTodos(archived)
+}
+
+// commit must be called with s.mu held.
+func (s *TodoStore) commit(runID, agentID string, items map[string]TodoItem) {
+	s.agentTodos(runID, agentID, true)
+	s.byRun[runID][agentID] = items
+	s.save(runID)
+}
+
+// List returns one agent's todos depth first: each todo is followed by its
+// children, and siblings are sorted by order.
+func (s *TodoStore) List(runID, agentID string) []TodoItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortTodos(s.agentTodos(runID, agentID, false))
+}
+
+// ListRun returns the current todos of a run grouped by agent.
+func (s *TodoStore) ListRun(runID string) []TodoItem {
+	return s.listRun(runID, false)
+}
+
+// History is ListRun including archived todos.
+func (s *TodoStore) History(runID string) []TodoItem {
+	return s.listRun(runID, true)
+}
+
+func (s *TodoStore) listRun(runID string, archived bool) []TodoItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	agents := make([]string, 0, len(s.byRun[runID]))
+	for agentID := range s.byRun[runID] {
+		agents = append(agents, agentID)
+	}
+	sort.Strings(agents)
+	out := make([]TodoItem, 0)
+	for _, agentID := range agents {
+		for _, item := range sortTodos(s.byRun[runID][agentID]) {
+			if archived || !item.Archived {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+// checkTodo validates item against the other todos of the same agent.
+func checkTodo(items map[string]TodoItem, item TodoItem) error {
+	if !ValidTodoStatus(item.Status) {
+		return fmt.Errorf("%s: unknown status %q", item.ID, item.Status)
+	}
+	if item.ParentID != "" {
+		if _, ok := items[item.ParentID]; !ok {
+			return fmt.Errorf("%s: parent %w: %s", item.ID, ErrTodoNotFound, item.ParentID)
+		}
+		for p := item.ParentID; p != ""; p = items[p].ParentID {
+			if p == item.ID {
+				return fmt.Errorf("%s: parent %s would create a cycle", item.ID, item.ParentID)
+			}
+		}
+	}
+	for _, dep := range item.DependsOn {
+		other, ok := items[dep]
+		if !ok {
+			return fmt.Errorf("%s: dependency %w: %s", item.ID, ErrTodoNotFound, dep)
+		}
+		if dep == item.ID || dependsOn(items, dep, item.ID, map[string]bool{}) {
+			return fmt.Errorf("%s: dependency on %s would create a cycle", item.ID, dep)
+		}
+		if (item.Status == TodoInProgress || item.Status == TodoDone) && !other.Status.closed() {
+			return fmt.Errorf("%s: cannot be %s while dependency %s is %s", item.ID, item.Status, dep, other.Status)
+		}
+	}
+	return nil
+}
+
+// dependsOn reports whether from reaches target through depends_on edges.
+func dependsOn(items map[string]TodoItem, from, target string, seen map[string]bool) bool {
+	if seen[from] {
+		return false
+	}
+	seen[from] = true
+	for _, dep := range items[from].DependsOn {
+		if dep == target || dependsOn(items, dep, target, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+func nextOrder(items map[string]TodoItem, parentID string) int {
+	max := 0
+	for _, item := range items {
+		if item.ParentID == parentID && item.Order > max {
+			max = item.Order
+		}
+	}
+	return max + 1
+}
+
+func cloneTodos(items map[string]TodoItem) map[string]TodoItem {
+	out := make(map[string]TodoItem, len(items))
+	for id, item := range items {
+		out[id] = item
+	}
+	return out
+}
+
+func removeString(list []string, s string) []string {
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func sortTodos(items map[string]TodoItem) []TodoItem {
+	children := make(map[string][]TodoItem)
+	for _, item := range items {
+		parent := item.ParentID
+		if _, ok := items[parent]; !ok {
+			parent = ""
+		}
+		children[parent] = append(children[parent], item)
+	}
+	for _, list := range children {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Order != list[j].Order {
+				return list[i].Order < list[j].Order
+			}
+			return todoOrdinal(list[i].ID) < todoOrdinal(list[j].ID)
+		})
+	}
+	out := make([]TodoItem, 0, len(items))
+	var walk func(parent string)
+	walk = func(parent string) {
+		for _, item := range children[parent] {
+			out = append(out, item)
+			walk(item.ID)
+		}
+	}
+	walk("")
+	return out
+}
+
+func todoOrdinal(id string) uint64 {
+	n, _ := strconv.ParseUint(strings.TrimPrefix(id, "todo-"), 10, 64)
+	return n
+}
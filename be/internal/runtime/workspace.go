@@ -0,0 +1,410 @@
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"backboard-swarm/be/internal/types"
+)
+
+// Workspace isolation modes.
+const (
+	WorkspaceShared = "shared"
+	WorkspaceRun    = "run"
+	WorkspaceAgent  = "agent"
+)
+
+// runWorkspace is a git checkout the agents of one run work in. base is the
+// commit it started from, so its diff is everything the run changed. origin
+// is the repository the checkout is a worktree of, empty for copies.
+//
+// mu serializes the git commands run against the checkout and guards
+// agents; ready is set once the checkout exists and ended once EndRun has
+// removed it.
+type runWorkspace struct {
+	path   string
+	top    string
+	base   string
+	origin string
+
+	mu     sync.Mutex
+	ready  bool
+	ended  bool
+	agents map[string]*runWorkspace
+}
+
+// WorkspaceManager gives runs, and in agent mode each coder, their own
+// copy of the workspace root. A root inside a git repository is checked out
+// as a worktree of its current tracked state; any other root is copied and
+// committed into a fresh repository. Untracked files of a git root are not
+// carried over.
+//
+// mu only guards the runs map. Git work happens under the run's own lock,
+// or rootMu for commands against the root repository, so one run's merges
+// never hold up another run.
+type WorkspaceManager struct {
+	root  string
+	dir   string
+	mode  string
+	merge bool
+
+	rootMu sync.Mutex
+
+	mu   sync.Mutex
+	runs map[string]*runWorkspace
+}
+
+// NewWorkspaceManager keeps isolated workspaces and saved diffs under dir.
+// With merge set, a run's changes are applied back onto root when it ends.
+func NewWorkspaceManager(root, dir, mode string, merge bool) (*WorkspaceManager, error) {
+	switch mode {
+	case WorkspaceShared, WorkspaceRun, WorkspaceAgent:
+	default:
+		return nil, fmt.Errorf("unknown workspace isolation %q", mode)
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &WorkspaceManager{root: absRoot, dir: absDir, mode: mode, merge: merge, runs: make(map[string]*runWorkspace)}, nil
+}
+
+func (m *WorkspaceManager) Mode() string {
+	return m.mode
+}
+
+// Path returns the directory an agent should treat as its workspace root,
+// creating the run or agent workspace on first use.
+func (m *WorkspaceManager) Path(runID, agentID string, role types.Role) (string, error) {
+	if m.mode == WorkspaceShared {
+		return m.root, nil
+	}
+	m.mu.Lock()
+	ws, ok := m.runs[runID]
+	if !ok {
+		ws = &runWorkspace{agents: make(map[string]*runWorkspace)}
+		m.runs[runID] = ws
+	}
+	m.mu.Unlock()
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.ended {
+		return "", fmt.Errorf("run %s has ended", runID)
+	}
+	if !ws.ready {
+		checkout, err := m.checkoutRoot(filepath.Join(m.dir, safeName(runID)))
+		if err != nil {
+			return "", fmt.Errorf("create workspace for %s: %w", runID, err)
+		}
+		ws.path, ws.top, ws.base, ws.origin = checkout.path, checkout.top, checkout.base, checkout.origin
+		ws.ready = true
+	}
+	if m.mode != WorkspaceAgent || role.Normalize() != types.RoleCoder {
+		return ws.path, nil
+	}
+	if child, ok := ws.agents[agentID]; ok {
+		return child.path, nil
+	}
+	child, err := worktreeOf(ws, filepath.Join(m.dir, safeName(runID)+"-"+safeName(agentID)), true)
+	if err != nil {
+		return "", fmt.Errorf("create workspace for %s: %w", agentID, err)
+	}
+	ws.agents[agentID] = child
+	return child.path, nil
+}
+
+// lookup returns the run's workspace once it has been checked out.
+func (m *WorkspaceManager) lookup(runID string) (*runWorkspace, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ws, ok := m.runs[runID]
+	return ws, ok
+}
+
+// checkoutRoot uses a worktree when the root is inside a git repository
+// with at least one commit and falls back to a copy otherwise.
+func (m *WorkspaceManager) checkoutRoot(dst string) (*runWorkspace, error) {
+	m.rootMu.Lock()
+	defer m.rootMu.Unlock()
+	if top, err := git(m.root, nil, "rev-parse", "--show-toplevel"); err == nil {
+		if ws, err := worktreeOf(&runWorkspace{path: m.root, top: strings.TrimSpace(top)}, dst, false); err == nil {
+			return ws, nil
+		}
+	}
+	return m.copyRoot(dst)
+}
+
+// worktreeOf checks out the current state of src into dst. Untracked files
+// are only included when src is a workspace of ours, since staging them
+// touches the index.
+func worktreeOf(src *runWorkspace, dst string, untracked bool) (*runWorkspace, error) {
+	if untracked {
+		if _, err := git(src.top, nil, "add", "-A"); err != nil {
+			return nil, err
+		}
+	}
+	base, err := git(src.top, nil, "stash", "create")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(base) == "" {
+		if base, err = git(src.top, nil, "rev-parse", "HEAD"); err != nil {
+			return nil, err
+		}
+	}
+	base = strings.TrimSpace(base)
+	_ = os.RemoveAll(dst)
+	if _, err := git(src.top, nil, "worktree", "add", "--detach", dst, base); err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(src.top, src.path)
+	if err != nil {
+		return nil, err
+	}
+	return &runWorkspace{path: filepath.Join(dst, rel), top: dst, base: base, origin: src.top}, nil
+}
+
+func (m *WorkspaceManager) copyRoot(dst string) (*runWorkspace, error) {
+	_ = os.RemoveAll(dst)
+	if err := copyTree(m.root, dst, m.dir); err != nil {
+		return nil, err
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "-A"}, {"commit", "-q", "--allow-empty", "-m", "workspace base"}} {
+		if _, err := git(dst, nil, args...); err != nil {
+			return nil, err
+		}
+	}
+	base, err := git(dst, nil, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return &runWorkspace{path: dst, top: dst, base: strings.TrimSpace(base)}, nil
+}
+
+// FinishAgent applies a coder's changes onto its run workspace and removes
+// the coder's workspace. On a conflict the patch is saved next to the run
+// diff and the error names it.
+func (m *WorkspaceManager) FinishAgent(runID, agentID string) error {
+	ws, child := m.takeAgent(runID, agentID)
+	if child == nil {
+		return nil
+	}
+	// The coder's checkout is ours alone now, so diff it without the lock.
+	patch, err := diff(child, false)
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	defer removeWorkspace(child)
+	if err != nil || patch == "" || ws.ended {
+		return err
+	}
+	if _, err := git(ws.top, strings.NewReader(patch), "apply", "--3way", "--whitespace=nowarn", "-"); err != nil {
+		path := filepath.Join(m.dir, safeName(runID)+"-"+safeName(agentID)+".diff")
+		_ = os.WriteFile(path, []byte(patch), 0o644)
+		return fmt.Errorf("merge changes of %s (patch kept at %s): %w", agentID, path, err)
+	}
+	return nil
+}
+
+// DiscardAgent removes a coder's workspace without merging its changes, so
+// the next Path call for the agent starts from the run workspace again.
+func (m *WorkspaceManager) DiscardAgent(runID, agentID string) {
+	ws, child := m.takeAgent(runID, agentID)
+	if child == nil {
+		return
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	removeWorkspace(child)
+}
+
+// takeAgent detaches a coder's workspace from its run.
+func (m *WorkspaceManager) takeAgent(runID, agentID string) (*runWorkspace, *runWorkspace) {
+	ws, ok := m.lookup(runID)
+	if !ok {
+		return nil, nil
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	child, ok := ws.agents[agentID]
+	if !ok {
+		return nil, nil
+	}
+	delete(ws.agents, agentID)
+	return ws, child
+}
+
+// Diff returns the changes a run made relative to the workspace root. Once
+// the run has ended it returns the diff saved by EndRun.
+func (m *WorkspaceManager) Diff(runID string) (string, error) {
+	if ws, ok := m.lookup(runID); ok {
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+		if ws.ready && !ws.ended {
+			return diff(ws, true)
+		}
+	}
+	b, err := os.ReadFile(m.diffPath(runID))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return string(b), err
+}
+
+// EndRun saves the run's diff, applies it to the root when merging is
+// enabled and removes the run's workspaces. It reports whether the diff was
+// merged.
+func (m *WorkspaceManager) EndRun(runID string) (string, bool, error) {
+	m.mu.Lock()
+	ws, ok := m.runs[runID]
+	delete(m.runs, runID)
+	m.mu.Unlock()
+	if !ok {
+		return "", false, nil
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if !ws.ready || ws.ended {
+		ws.ended = true
+		return "", false, nil
+	}
+	ws.ended = true
+	defer m.remove(ws)
+	patch, err := diff(ws, true)
+	if err != nil {
+		return "", false, err
+	}
+	if err := os.WriteFile(m.diffPath(runID), []byte(patch), 0o644); err != nil {
+		return patch, false, err
+	}
+	if !m.merge || patch == "" {
+		return patch, false, nil
+	}
+	m.rootMu.Lock()
+	defer m.rootMu.Unlock()
+	if _, err := git(m.root, strings.NewReader(patch), "apply", "--whitespace=nowarn", "-"); err != nil {
+		return patch, false, fmt.Errorf("merge run changes into %s: %w", m.root, err)
+	}
+	return patch, true, nil
+}
+
+func (m *WorkspaceManager) diffPath(runID string) string {
+	return filepath.Join(m.dir, safeName(runID)+".diff")
+}
+
+// remove must be called with ws.mu held.
+func (m *WorkspaceManager) remove(ws *runWorkspace) {
+	for _, child := range ws.agents {
+		removeWorkspace(child)
+	}
+	ws.agents = nil
+	if ws.origin != "" {
+		m.rootMu.Lock()
+		defer m.rootMu.Unlock()
+	}
+	removeWorkspace(ws)
+}
+
+// diff stages everything in ws and returns it against ws.base. relative
+// limits the diff to ws.path and makes its paths relative to it; otherwise
+// paths are relative to the checkout so the patch applies to its source.
+func diff(ws *runWorkspace, relative bool) (string, error) {
+	if _, err := git(ws.top, nil, "add", "-A"); err != nil {
+		return "", err
+	}
+	if relative {
+		return git(ws.path, nil, "diff", "--cached", "--binary", "--relative", ws.base)
+	}
+	return git(ws.top, nil, "diff", "--cached", "--binary", ws.base)
+}
+
+func removeWorkspace(ws *runWorkspace) {
+	if ws.origin == "" {
+		_ = os.RemoveAll(ws.top)
+		return
+	}
+	if _, err := git(ws.origin, nil, "worktree", "remove", "--force", ws.top); err != nil {
+		_ = os.RemoveAll(ws.top)
+		_, _ = git(ws.origin, nil, "worktree", "prune")
+	}
+}
+
+func git(dir string, stdin io.Reader, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-c", "user.name=wuvo", "-c", "user.email=wuvo@localhost", "-c", "core.autocrlf=false"}, args...)...)
+	cmd.Dir = dir
+	cmd.Stdin = stdin
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// copyTree copies src into dst, skipping .git directories and skip itself.
+func copyTree(src, dst, skip string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == ".git" || path == skip) {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0o700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// safeName keeps run and agent ids from escaping the workspace directory.
+func safeName(s string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(s)
+}
@@ -0,0 +1,60 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Fixture answers queries from canned results, for tests and offline runs.
+// Queries are matched case-insensitively; a "*" entry answers any query
+// without its own entry.
+type Fixture struct {
+	results map[string][]Result
+}
+
+func NewFixture(results map[string][]Result) *Fixture {
+	f := &Fixture{results: make(map[string][]Result, len(results))}
+	for q, r := range results {
+		f.results[fixtureKey(q)] = r
+	}
+	return f
+}
+
+// LoadFixture reads a JSON object mapping queries to result lists.
+func LoadFixture(path string) (*Fixture, error) {
+	if path == "" {
+		return nil, errors.New("fixture search needs WUVO_SEARCH_FIXTURE")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read search fixture: %w", err)
+	}
+	var results map[string][]Result
+	if err := json.Unmarshal(b, &results); err != nil {
+		return nil, fmt.Errorf("parse search fixture: %w", err)
+	}
+	return NewFixture(results), nil
+}
+
+func (f *Fixture) Name() string {
+	return "fixture"
+}
+
+func (f *Fixture) Search(_ context.Context, query string, opts Options) (Response, error) {
+	results, ok := f.results[fixtureKey(query)]
+	if !ok {
+		if results, ok = f.results["*"]; !ok {
+			return Response{}, fmt.Errorf("no fixture results for %q", query)
+		}
+	}
+	results, cut := limitResults(results, opts.MaxResults)
+	return Response{Provider: f.Name(), Results: results, Truncated: cut}, nil
+}
+
+func fixtureKey(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
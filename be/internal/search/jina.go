@@ -0,0 +1,62 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	serpTitleRe       = regexp.MustCompile(`(?m)^\[(\d+)\] Title:\s*(.*)$`)
+	serpURLRe         = regexp.MustCompile(`(?m)^\[(\d+)\] URL Source:\s*(\S+)`)
+	serpDescriptionRe = regexp.MustCompile(`(?m)^\[(\d+)\] Description:\s*(.*)$`)
+)
+
+// Jina searches with s.jina.ai.
+type Jina struct {
+	apiKey string
+	client *http.Client
+}
+
+func NewJina(apiKey string, timeout time.Duration) *Jina {
+	return &Jina{apiKey: strings.TrimSpace(apiKey), client: httpClient(timeout)}
+}
+
+func (j *Jina) Name() string {
+	return "jina"
+}
+
+func (j *Jina) Search(ctx context.Context, query string, opts Options) (Response, error) {
+	if j.apiKey == "" {
+		return Response{}, errors.New("JINA_API_KEY is required for websearch")
+	}
+	endpoint := "https://s.jina.ai/?q=" + url.QueryEscape(query)
+	body, truncated, err := get(ctx, j.client, "jina", endpoint, map[string]string{"Authorization": "Bearer " + j.apiKey, "X-Respond-With": "no-content"}, opts.MaxBytes)
+	if err != nil {
+		return Response{}, err
+	}
+	results, cut := limitResults(ParseSERP(body), opts.MaxResults)
+	return Response{Provider: j.Name(), Results: results, Content: body, Truncated: truncated || cut}, nil
+}
+
+// ParseSERP reads the numbered result layout Jina returns, in the order
+// the result URLs appear.
+func ParseSERP(content string) []Result {
+	titles := map[string]string{}
+	for _, m := range serpTitleRe.FindAllStringSubmatch(content, -1) {
+		titles[m[1]] = strings.TrimSpace(m[2])
+	}
+	snippets := map[string]string{}
+	for _, m := range serpDescriptionRe.FindAllStringSubmatch(content, -1) {
+		snippets[m[1]] = strings.TrimSpace(m[2])
+	}
+	results := make([]Result, 0)
+	for _, m := range serpURLRe.FindAllStringSubmatch(content, -1) {
+		results = append(results, Result{Title: titles[m[1]], URL: m[2], Snippet: snippets[m[1]]})
+	}
+	return results
+}
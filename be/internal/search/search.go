@@ -0,0 +1,167 @@
+// Package search queries web search backends through a common Provider
+// interface.
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout    = 60 * time.Second
+	defaultMaxBytes   = 30000
+	defaultMaxResults = 10
+)
+
+type Result struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+type Response struct {
+	Provider string
+	Results  []Result
+	// Content is the raw result page for providers that return one.
+	Content   string
+	Truncated bool
+}
+
+type Options struct {
+	MaxBytes   int
+	MaxResults int
+}
+
+type Provider interface {
+	Name() string
+	Search(ctx context.Context, query string, opts Options) (Response, error)
+}
+
+// Settings holds what the providers need from the configuration.
+type Settings struct {
+	JinaAPIKey  string
+	SearXNGURL  string
+	FixturePath string
+	Timeout     time.Duration
+}
+
+// New builds the named providers in order. With more than one, a search
+// falls back to the next provider when one fails.
+func New(names []string, s Settings) (Provider, error) {
+	providers := make(Fallback, 0, len(names))
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "jina":
+			providers = append(providers, NewJina(s.JinaAPIKey, s.Timeout))
+		case "searxng":
+			if s.SearXNGURL == "" {
+				return nil, errors.New("searxng search needs WUVO_SEARXNG_URL")
+			}
+			providers = append(providers, NewSearXNG(s.SearXNGURL, s.Timeout))
+		case "fixture":
+			f, err := LoadFixture(s.FixturePath)
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, f)
+		default:
+			return nil, fmt.Errorf("unknown search provider %q", name)
+		}
+	}
+	switch len(providers) {
+	case 0:
+		return nil, errors.New("no search provider configured")
+	case 1:
+		return providers[0], nil
+	}
+	return providers, nil
+}
+
+// Fallback tries each provider in turn and returns the first successful
+// response, or all their errors.
+type Fallback []Provider
+
+func (f Fallback) Name() string {
+	names := make([]string, len(f))
+	for i, p := range f {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (f Fallback) Search(ctx context.Context, query string, opts Options) (Response, error) {
+	errs := make([]error, 0, len(f))
+	for _, p := range f {
+		resp, err := p.Search(ctx, query, opts)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return Response{}, ctx.Err()
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	if len(errs) == 0 {
+		return Response{}, errors.New("no search provider configured")
+	}
+	return Response{}, errors.Join(errs...)
+}
+
+func limitResults(results []Result, max int) ([]Result, bool) {
+	if max <= 0 {
+		max = defaultMaxResults
+	}
+	if len(results) > max {
+		return results[:max], true
+	}
+	return results, false
+}
+
+func httpClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// get fetches endpoint and returns at most maxBytes of the body. Non-2xx
+// responses are errors quoting the start of the body.
+func get(ctx context.Context, client *http.Client, name, endpoint string, headers map[string]string, maxBytes int) (string, bool, error) {
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", false, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes+1)))
+	if err != nil {
+		return "", false, err
+	}
+	truncated := len(b) > maxBytes
+	if truncated {
+		b = b[:maxBytes]
+	}
+	content := string(b)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if len(content) > 220 {
+			content = content[:220] + "..."
+		}
+		return "", false, fmt.Errorf("%s request failed (%d): %s", name, resp.StatusCode, content)
+	}
+	return content, truncated, nil
+}
@@ -0,0 +1,72 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFallbackMovesToNextProviderOnError(t *testing.T) {
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.URL.Query().Get("format") != "json" {
+			http.NotFound(w, r)
+			return
+		}
+		gotQuery = r.URL.Query().Get("q")
+		w.Write([]byte(`{"results":[{"title":" Go 1.22 ","url":"https://go.dev/doc/go1.22","content":"notes"},{"title":"no url"},{"title":"Blog","url":"https://go.dev/blog"}]}`))
+	}))
+	defer srv.Close()
+
+	provider, err := New([]string{"jina", "searxng"}, Settings{SearXNGURL: srv.URL + "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := provider.Search(context.Background(), "go 1.22", Options{MaxResults: 1})
+	if err != nil {
+		t.Fatalf("expected fallback to searxng, got %v", err)
+	}
+	if gotQuery != "go 1.22" || resp.Provider != "searxng" || !resp.Truncated {
+		t.Fatalf("unexpected response %+v for query %q", resp, gotQuery)
+	}
+	if len(resp.Results) != 1 || resp.Results[0] != (Result{Title: "Go 1.22", URL: "https://go.dev/doc/go1.22", Snippet: "notes"}) {
+		t.Fatalf("unexpected results %+v", resp.Results)
+	}
+
+	down := NewSearXNG(srv.URL+"/down", 0)
+	_, err = Fallback{NewJina("", 0), down}.Search(context.Background(), "q", Options{})
+	if err == nil || !strings.Contains(err.Error(), "jina: JINA_API_KEY") || !strings.Contains(err.Error(), "searxng: searxng request failed (404)") {
+		t.Fatalf("expected every provider's error, got %v", err)
+	}
+
+	if _, err := New([]string{"bing"}, Settings{}); err == nil {
+		t.Fatal("expected unknown provider to be rejected")
+	}
+	if _, err := New([]string{"searxng"}, Settings{}); err == nil {
+		t.Fatal("expected searxng without a url to be rejected")
+	}
+}
+
+func TestFixtureAndSERPParsing(t *testing.T) {
+	f := NewFixture(map[string][]Result{
+		"Go  Release": {{Title: "Go", URL: "https://go.dev"}},
+		"*":           {{Title: "Any", URL: "https://example.com"}},
+	})
+	if resp, err := f.Search(context.Background(), "go release", Options{}); err != nil || resp.Results[0].Title != "Go" {
+		t.Fatalf("unexpected fixture answer %+v %v", resp, err)
+	}
+	if resp, err := f.Search(context.Background(), "other", Options{}); err != nil || resp.Results[0].Title != "Any" {
+		t.Fatalf("expected wildcard answer, got %+v %v", resp, err)
+	}
+	if _, err := NewFixture(nil).Search(context.Background(), "other", Options{}); err == nil {
+		t.Fatal("expected missing fixture query to fail")
+	}
+
+	serp := "[1] Title: Go 1.22 Release Notes\n[1] URL Source: https://go.dev/doc/go1.22\n[1] Description: notes\n[2] Title: Blog\n[2] URL Source: https://go.dev/blog/go1.22\n"
+	results := ParseSERP(serp)
+	if len(results) != 2 || results[0] != (Result{Title: "Go 1.22 Release Notes", URL: "https://go.dev/doc/go1.22", Snippet: "notes"}) || results[1].Title != "Blog" {
+		t.Fatalf("unexpected serp results %+v", results)
+	}
+}
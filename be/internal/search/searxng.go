@@ -0,0 +1,58 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SearXNG queries a SearXNG instance, or any endpoint that answers
+// /search?q=...&format=json with a results array of title, url and content.
+type SearXNG struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewSearXNG(baseURL string, timeout time.Duration) *SearXNG {
+	return &SearXNG{baseURL: strings.TrimRight(baseURL, "/"), client: httpClient(timeout)}
+}
+
+func (s *SearXNG) Name() string {
+	return "searxng"
+}
+
+func (s *SearXNG) Search(ctx context.Context, query string, opts Options) (Response, error) {
+	endpoint := s.baseURL + "/search?" + url.Values{"q": {query}, "format": {"json"}}.Encode()
+	// The JSON is parsed, so read it whole rather than cutting at MaxBytes.
+	body, truncated, err := get(ctx, s.client, "searxng", endpoint, map[string]string{"Accept": "application/json"}, 4<<20)
+	if err != nil {
+		return Response{}, err
+	}
+	if truncated {
+		return Response{}, errors.New("searxng response is too large")
+	}
+	var payload struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		return Response{}, fmt.Errorf("parse searxng response: %w", err)
+	}
+	results := make([]Result, 0, len(payload.Results))
+	for _, r := range payload.Results {
+		if r.URL == "" {
+			continue
+		}
+		results = append(results, Result{Title: strings.TrimSpace(r.Title), URL: r.URL, Snippet: strings.TrimSpace(r.Content)})
+	}
+	results, cut := limitResults(results, opts.MaxResults)
+	return Response{Provider: s.Name(), Results: results, Truncated: cut}, nil
+}
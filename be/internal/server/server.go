@@ -3,33 +3,48 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"backboard-swarm/be/internal/agent"
 	"backboard-swarm/be/internal/backboard"
 	"backboard-swarm/be/internal/config"
+	"backboard-swarm/be/internal/fetch"
 	"backboard-swarm/be/internal/orchestrator"
 	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/search"
 	"backboard-swarm/be/internal/tools"
 	"backboard-swarm/be/internal/types"
 	"backboard-swarm/be/internal/ws"
 )
 
 type Server struct {
-	cfg      config.Config
-	runStore *runtime.RunStore
-	hub      *ws.Hub
-	swarm    *orchestrator.Swarm
-	http     *http.Server
+	cfg        config.Config
+	runStore   *runtime.RunStore
+	todos      *runtime.TodoStore
+	workspaces *runtime.WorkspaceManager
+	approvals  *runtime.ApprovalStore
+	inputs     *runtime.InputStore
+	hub        *ws.Hub
+	swarm      *orchestrator.Swarm
+	http       *http.Server
 }
 
 type taskRequest struct {
-	Task string `json:"task"`
+	Task     string   `json:"task"`
+	Strategy string   `json:"strategy,omitempty"`
+	Role     string   `json:"role,omitempty"`
+	Inputs   []string `json:"inputs,omitempty"`
+	Verify   *bool    `json:"verify,omitempty"`
+
+	MaxPlanSize      int `json:"max_plan_size,omitempty"`
+	MaxTotalSubtasks int `json:"max_total_subtasks,omitempty"`
+	MaxRounds        int `json:"max_rounds,omitempty"`
 }
 
 type taskResponse struct {
@@ -37,6 +52,22 @@ type taskResponse struct {
 	State string `json:"state"`
 }
 
+type wsMessage struct {
+	Type       string         `json:"type"`
+	RunID      string         `json:"run_id"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+	Decision   string         `json:"decision,omitempty"`
+	Arguments  map[string]any `json:"arguments,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+	QuestionID string         `json:"question_id,omitempty"`
+	Answer     string         `json:"answer,omitempty"`
+}
+
+type inputRequest struct {
+	QuestionID string `json:"question_id"`
+	Answer     string `json:"answer"`
+}
+
 func New(cfg config.Config) (*Server, error) {
 	wd, err := os.Getwd()
 	if err != nil {
@@ -47,30 +78,68 @@ func New(cfg config.Config) (*Server, error) {
 	if err != nil {
 		return nil, err
 	}
+	if _, ok := orchestrator.LookupStrategy(cfg.Strategy); !ok {
+		return nil, fmt.Errorf("unknown strategy %q in WUVO_STRATEGY", cfg.Strategy)
+	}
 
 	hub := ws.NewHub()
-	runStore := runtime.NewRunStore()
+	runStore, err := runtime.NewPersistentRunStore(filepath.Join(cfg.DataDir, "runs"))
+	if err != nil {
+		return nil, err
+	}
+	todos, err := runtime.NewPersistentTodoStore(filepath.Join(cfg.DataDir, "todos"))
+	if err != nil {
+		return nil, err
+	}
+	workspaces, err := runtime.NewWorkspaceManager(cfg.WorkspaceRoot, filepath.Join(cfg.DataDir, "workspaces"), cfg.WorkspaceIsolation, cfg.WorkspaceMerge)
+	if err != nil {
+		return nil, err
+	}
+	searcher, err := search.New(cfg.SearchProviders, search.Settings{JinaAPIKey: cfg.JinaAPIKey, SearXNGURL: cfg.SearXNGURL, FixturePath: cfg.SearchFixture, Timeout: cfg.RequestTimeout})
+	if err != nil {
+		return nil, err
+	}
+	fetcher, err := fetch.New(cfg.Fetchers, fetch.Settings{JinaAPIKey: cfg.JinaAPIKey, Timeout: cfg.RequestTimeout, AllowPrivate: cfg.FetchAllowPrivate})
+	if err != nil {
+		return nil, err
+	}
+	approvals := runtime.NewApprovalStore()
+	inputs := runtime.NewInputStore(runStore)
 	registry := tools.NewRegistry()
 	tools.RegisterBuiltins(registry)
+	registry.SetOutputLimit(cfg.ToolOutputMaxBytes)
+	registry.SetApprovalPolicy(tools.ApprovalPolicy{Rules: cfg.ApprovalRules, Timeout: cfg.ApprovalTimeout})
 
 	client := backboard.NewClient(cfg.BaseURL, cfg.BackboardAPIKey, cfg.RequestTimeout)
 	runner := agent.NewRunner(
 		client,
 		cfg,
 		registry,
-		runtime.NewAssistantStore(),
-		runtime.NewTodoStore(),
+		agent.Stores{
+			Assistants: runtime.NewAssistantStore(),
+			Todos:      todos,
+			Outputs:    runtime.NewOutputStore(),
+			Approvals:  approvals,
+			Inputs:     inputs,
+			Sources:    runtime.NewSourceStore(),
+			Board:      runtime.NewBoardStore(),
+		},
 		prompts,
 		hub,
 	)
+	runner.SetSearch(searcher)
+	runner.SetFetcher(fetcher)
 	swarm := orchestrator.NewSwarm(runner, cfg, hub)
+	swarm.SetTodos(todos)
+	swarm.SetWorkspaces(workspaces)
 
-	s := &Server{cfg: cfg, runStore: runStore, hub: hub, swarm: swarm}
+	s := &Server{cfg: cfg, runStore: runStore, todos: todos, workspaces: workspaces, approvals: approvals, inputs: inputs, hub: hub, swarm: swarm}
+	hub.SetHandler(s.handleWSMessage)
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", s.handleHealth)
 	mux.HandleFunc("/ws", s.hub.HandleWS)
 	mux.HandleFunc("/tasks", s.handleTasks)
-	mux.HandleFunc("/runs/", s.handleGetRun)
+	mux.HandleFunc("/runs/", s.handleRuns)
 
 	s.http = &http.Server{
 		Addr:              cfg.ServerAddr,
@@ -109,6 +178,16 @@ func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "task is required"})
 		return
 	}
+	if req.MaxPlanSize < 0 || req.MaxTotalSubtasks < 0 || req.MaxRounds < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "limits must not be negative"})
+		return
+	}
+	if req.Strategy != "" {
+		if _, ok := orchestrator.LookupStrategy(req.Strategy); !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": fmt.Sprintf("unknown strategy %q", req.Strategy)})
+			return
+		}
+	}
 
 	runID := s.runStore.New(task)
 	s.runStore.SetRunning(runID)
@@ -118,7 +197,19 @@ func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*s.cfg.RequestTimeout)
 		defer cancel()
 
-		summary, err := s.swarm.Run(ctx, runID, task)
+		out, err := s.swarm.Execute(ctx, orchestrator.Request{
+			RunID:    runID,
+			Task:     task,
+			Strategy: req.Strategy,
+			Role:     types.Role(req.Role),
+			Inputs:   req.Inputs,
+			Verify:   req.Verify,
+			Limits: orchestrator.Limits{
+				MaxPlanSize:      req.MaxPlanSize,
+				MaxTotalSubtasks: req.MaxTotalSubtasks,
+				MaxRounds:        req.MaxRounds,
+			},
+		})
 		if err != nil {
 			s.runStore.SetFailed(runID, err)
 			s.hub.Emit(types.Event{
@@ -130,18 +221,36 @@ func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
-		s.runStore.SetCompleted(runID, summary)
+		s.runStore.SetCompleted(runID, out.Summary, out.Bibliography, out.Unsourced)
 	}()
 }
 
-func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/runs/"), "/"), "/")
+	if len(parts) == 0 || parts[0] == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "missing run id"})
 		return
 	}
-	runID := path.Base(r.URL.Path)
-	if runID == "" || runID == "runs" {
-		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "missing run id"})
+	runID := parts[0]
+	switch {
+	case len(parts) == 1:
+		s.handleGetRun(w, r, runID)
+	case len(parts) == 2 && parts[1] == "input":
+		s.handleInput(w, r, runID)
+	case len(parts) == 2 && parts[1] == "todos":
+		s.handleTodos(w, r, runID)
+	case len(parts) == 2 && parts[1] == "diff":
+		s.handleDiff(w, r, runID)
+	case len(parts) == 3 && parts[1] == "approvals":
+		s.handleApproval(w, r, runID, parts[2])
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not found"})
+	}
+}
+
+func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request, runID string) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
 		return
 	}
 	run, ok := s.runStore.Get(runID)
@@ -152,6 +261,140 @@ func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, run)
 }
 
+func (s *Server) handleTodos(w http.ResponseWriter, r *http.Request, runID string) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	if _, ok := s.runStore.Get(runID); !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "run not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"run_id": runID, "todos": s.todos.History(runID)})
+}
+
+func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request, runID string) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	if _, ok := s.runStore.Get(runID); !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "run not found"})
+		return
+	}
+	diff, err := s.workspaces.Diff(runID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"run_id": runID, "isolation": s.workspaces.Mode(), "diff": diff})
+}
+
+func (s *Server) handleApproval(w http.ResponseWriter, r *http.Request, runID, toolCallID string) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	var decision runtime.ApprovalDecision
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid json"})
+		return
+	}
+	if err := s.resolveApproval(runID, toolCallID, decision); err != nil {
+		writeJSON(w, approvalStatusCode(err), map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+var errNoPendingApproval = errors.New("no pending approval for this tool call")
+
+func (s *Server) resolveApproval(runID, toolCallID string, decision runtime.ApprovalDecision) error {
+	decision.Decision = strings.ToLower(strings.TrimSpace(decision.Decision))
+	switch decision.Decision {
+	case runtime.ApprovalApprove, runtime.ApprovalDeny:
+	case runtime.ApprovalEdit:
+		if decision.Arguments == nil {
+			return fmt.Errorf("arguments are required for edit")
+		}
+	default:
+		return fmt.Errorf("decision must be approve, deny or edit")
+	}
+	if !s.approvals.Resolve(runID, toolCallID, decision) {
+		return errNoPendingApproval
+	}
+	return nil
+}
+
+func (s *Server) handleInput(w http.ResponseWriter, r *http.Request, runID string) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"run_id": runID, "pending": s.inputs.Pending(runID)})
+	case http.MethodPost:
+		var req inputRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid json"})
+			return
+		}
+		q, err := s.answerInput(runID, req.QuestionID, req.Answer)
+		if err != nil {
+			writeJSON(w, inputStatusCode(err), map[string]any{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "question_id": q.QuestionID})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+	}
+}
+
+var errNoPendingInput = errors.New("no pending question matches this answer")
+
+func (s *Server) answerInput(runID, questionID, answer string) (runtime.PendingQuestion, error) {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return runtime.PendingQuestion{}, fmt.Errorf("answer is required")
+	}
+	q, ok := s.inputs.Answer(runID, strings.TrimSpace(questionID), answer)
+	if !ok {
+		return runtime.PendingQuestion{}, errNoPendingInput
+	}
+	return q, nil
+}
+
+func inputStatusCode(err error) int {
+	if errors.Is(err, errNoPendingInput) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+func approvalStatusCode(err error) int {
+	if errors.Is(err, errNoPendingApproval) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+func (s *Server) handleWSMessage(msg []byte) error {
+	var in wsMessage
+	if err := json.Unmarshal(msg, &in); err != nil {
+		return fmt.Errorf("invalid json")
+	}
+	switch in.Type {
+	case "tool_approval":
+		return s.resolveApproval(in.RunID, in.ToolCallID, runtime.ApprovalDecision{
+			Decision:  in.Decision,
+			Arguments: in.Arguments,
+			Reason:    in.Reason,
+		})
+	case "user_input":
+		_, err := s.answerInput(in.RunID, in.QuestionID, in.Answer)
+		return err
+	default:
+		return fmt.Errorf("unsupported message type %q", in.Type)
+	}
+}
+
 func writeJSON(w http.ResponseWriter, code int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
@@ -83,6 +83,7 @@ func Run(ctx context.Context, serverURL, task string, out io.Writer) error {
 					return fmt.Errorf("run failed: %s", evt.Message)
 				}
 				fmt.Fprintf(out, "\nFinal summary:\n%s\n", evt.Message)
+				renderBibliography(out, evt.Meta)
 				return nil
 			}
 		}
@@ -108,6 +109,27 @@ func renderEventMessage(evt types.Event) string {
 	return strings.TrimSpace(msg)
 }
 
+func renderBibliography(out io.Writer, meta map[string]any) {
+	sources, ok := meta["bibliography"].([]any)
+	if !ok || len(sources) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\nSources:")
+	for _, raw := range sources {
+		src, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		id, _ := getStringMeta(src, "id")
+		link, _ := getStringMeta(src, "url")
+		if title, ok := getStringMeta(src, "title"); ok {
+			fmt.Fprintf(out, "[%s] %s - %s\n", id, title, link)
+			continue
+		}
+		fmt.Fprintf(out, "[%s] %s\n", id, link)
+	}
+}
+
 func getIntMeta(meta map[string]any, key string) (int, bool) {
 	if meta == nil {
 		return 0, false
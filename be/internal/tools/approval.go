@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"backboard-swarm/be/internal/backboard"
+	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/types"
+)
+
+type ApprovalPolicy struct {
+	Rules   []types.ApprovalRule
+	Timeout time.Duration
+}
+
+type approvalDenied struct {
+	reason string
+}
+
+func (e approvalDenied) Error() string {
+	return "tool call denied: " + e.reason
+}
+
+// Match reports the first rule that covers the call. A rule matches when its
+// tool name matches and every path or domain constraint it sets matches too.
+// A path that cannot be checked against a rule is an error, and the call
+// must not run.
+func (p ApprovalPolicy) Match(toolName string, args map[string]any, workspaceRoot string) (types.ApprovalRule, bool, error) {
+	for _, rule := range p.Rules {
+		if rule.Tool != "" && rule.Tool != "*" && rule.Tool != toolName {
+			continue
+		}
+		if rule.PathGlob != "" {
+			ok, err := matchArgPath(rule.PathGlob, args, workspaceRoot)
+			if err != nil {
+				return rule, false, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		if rule.Domain != "" && !matchArgDomain(rule.Domain, args) {
+			continue
+		}
+		return rule, true, nil
+	}
+	return types.ApprovalRule{}, false, nil
+}
+
+// matchArgPath matches the call's path, or any of its paths, against
+// pattern.
+func matchArgPath(pattern string, args map[string]any, workspaceRoot string) (bool, error) {
+	paths := getStringSlice(args, "paths")
+	if p := getString(args, "path", ""); p != "" {
+		paths = append(paths, p)
+	}
+	for _, p := range paths {
+		ok, err := matchPathGlob(pattern, p, workspaceRoot)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// matchPathGlob matches p, relative to the workspace root, against a glob
+// in which ** spans directories.
+func matchPathGlob(pattern, p, workspaceRoot string) (bool, error) {
+	abs, err := resolvePath(workspaceRoot, p)
+	if err != nil {
+		return false, err
+	}
+	rootAbs, err := filepath.Abs(workspaceRoot)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(rootAbs, abs)
+	if err != nil {
+		return false, err
+	}
+	return matchPath(strings.TrimPrefix(pattern, "./"), filepath.ToSlash(rel)), nil
+}
+
+func matchArgDomain(domain string, args map[string]any) bool {
+	raw := getString(args, "url", "")
+	if raw == "" {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	domain = strings.ToLower(strings.TrimPrefix(domain, "*."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// approve checks the call against the approval policy and waits for a
+// reviewer when a rule covers it. Arguments a reviewer edited are checked
+// again before they run, and may need approval in turn.
+func (r *Registry) approve(ctx context.Context, call backboard.ToolCall, args map[string]any, execCtx *ExecutionContext) (map[string]any, error) {
+	r.mu.RLock()
+	policy := r.approvals
+	r.mu.RUnlock()
+	for {
+		rule, needsApproval, err := policy.Match(call.Function.Name, args, execCtx.WorkspaceRoot)
+		if err != nil {
+			return nil, approvalDenied{reason: fmt.Sprintf("cannot check arguments against approval rules: %v", err)}
+		}
+		if !needsApproval {
+			return args, nil
+		}
+		approved, err := r.awaitApproval(ctx, call.ID, call.Function.Name, args, rule, execCtx)
+		if err != nil || reflect.DeepEqual(approved, args) {
+			return approved, err
+		}
+		args = approved
+	}
+}
+
+// awaitApproval blocks the calling tool until a reviewer answers or the
+// policy timeout, capped by the caller's deadline, elapses. Timeouts are
+// treated as denials.
+func (r *Registry) awaitApproval(ctx context.Context, toolCallID, toolName string, args map[string]any, rule types.ApprovalRule, execCtx *ExecutionContext) (map[string]any, error) {
+	if execCtx.Approvals == nil {
+		return nil, approvalDenied{reason: "approval required but no reviewer is available"}
+	}
+
+	r.mu.RLock()
+	timeout := r.approvals.Timeout
+	r.mu.RUnlock()
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	timeout = userWait(ctx, timeout)
+
+	wait := execCtx.Approvals.Open(execCtx.RunID, toolCallID)
+	defer execCtx.Approvals.Close(execCtx.RunID, toolCallID)
+
+	emitApproval(execCtx, "tool_approval_required", toolName, "pending", fmt.Sprintf("approval required for %s", toolName), map[string]any{
+		"tool_call_id":    toolCallID,
+		"arguments":       args,
+		"rule":            rule,
+		"timeout_seconds": int(timeout.Seconds()),
+	})
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var decision runtime.ApprovalDecision
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		decision = runtime.ApprovalDecision{Decision: runtime.ApprovalDeny, Reason: fmt.Sprintf("no approval received within %s", timeout)}
+	case decision = <-wait:
+	}
+
+	emitApproval(execCtx, "tool_approval_resolved", toolName, decision.Decision, decision.Reason, map[string]any{
+		"tool_call_id": toolCallID,
+	})
+
+	switch decision.Decision {
+	case runtime.ApprovalApprove:
+		return args, nil
+	case runtime.ApprovalEdit:
+		if decision.Arguments != nil {
+			return decision.Arguments, nil
+		}
+		return args, nil
+	default:
+		reason := strings.TrimSpace(decision.Reason)
+		if reason == "" {
+			reason = "rejected by reviewer"
+		}
+		return nil, approvalDenied{reason: reason}
+	}
+}
+
+func emitApproval(execCtx *ExecutionContext, eventType, toolName, status, message string, meta map[string]any) {
+	if execCtx.Emitter == nil {
+		return
+	}
+	execCtx.Emitter.Emit(types.Event{
+		Type:      eventType,
+		RunID:     execCtx.RunID,
+		AgentID:   execCtx.AgentID,
+		Role:      execCtx.Role,
+		ToolName:  toolName,
+		Status:    status,
+		Message:   message,
+		Timestamp: time.Now().UTC(),
+		Meta:      meta,
+	})
+}
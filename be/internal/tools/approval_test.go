@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backboard-swarm/be/internal/backboard"
+	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/types"
+)
+
+type recordingEmitter struct {
+	mu     sync.Mutex
+	events []types.Event
+	onEmit func(types.Event)
+}
+
+func (e *recordingEmitter) Emit(evt types.Event) {
+	e.mu.Lock()
+	e.events = append(e.events, evt)
+	onEmit := e.onEmit
+	e.mu.Unlock()
+	if onEmit != nil {
+		onEmit(evt)
+	}
+}
+
+func TestApprovalPolicyMatch(t *testing.T) {
+	policy := ApprovalPolicy{Rules: []types.ApprovalRule{
+		{Tool: "read", PathGlob: "secrets/*"},
+		{Tool: "web_fetch", Domain: "*.internal.example.com"},
+	}}
+	root := t.TempDir()
+
+	if _, ok, _ := policy.Match("read", map[string]any{"path": "secrets/key.pem"}, root); !ok {
+		t.Fatal("expected secrets path to require approval")
+	}
+	if _, ok, _ := policy.Match("read", map[string]any{"path": "docs/readme.md"}, root); ok {
+		t.Fatal("expected docs path to be allowed")
+	}
+	if _, ok, _ := policy.Match("read", map[string]any{"paths": []any{"docs/readme.md", "secrets/key.pem"}}, root); !ok {
+		t.Fatal("expected a secrets path among several to require approval")
+	}
+	if _, ok, _ := policy.Match("web_fetch", map[string]any{"url": "https://wiki.internal.example.com/page"}, root); !ok {
+		t.Fatal("expected internal domain to require approval")
+	}
+	if _, ok, _ := policy.Match("web_fetch", map[string]any{"url": "https://example.com/page"}, root); ok {
+		t.Fatal("expected public domain to be allowed")
+	}
+
+	nested := ApprovalPolicy{Rules: []types.ApprovalRule{{Tool: "read", PathGlob: "config/**/*.env"}}}
+	if _, ok, _ := nested.Match("read", map[string]any{"path": "config/prod/eu/app.env"}, root); !ok {
+		t.Fatal("expected ** to match nested directories")
+	}
+	if _, _, err := nested.Match("read", map[string]any{"path": "../outside.env"}, root); err == nil {
+		t.Fatal("expected a path outside the workspace to fail the check")
+	}
+}
+
+func TestRegistryApprovalRechecksEditedArguments(t *testing.T) {
+	tmp := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(tmp, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	r.SetApprovalPolicy(ApprovalPolicy{Rules: []types.ApprovalRule{{Tool: "read", PathGlob: "*.txt"}}, Timeout: time.Second})
+
+	approvals := runtime.NewApprovalStore()
+	var mu sync.Mutex
+	var asked []any
+	edits := []runtime.ApprovalDecision{
+		{Decision: runtime.ApprovalEdit, Arguments: map[string]any{"path": "b.txt"}},
+		{Decision: runtime.ApprovalEdit, Arguments: map[string]any{"path": "../escape.txt"}},
+	}
+	emitter := &recordingEmitter{}
+	emitter.onEmit = func(evt types.Event) {
+		if evt.Type != "tool_approval_required" {
+			return
+		}
+		mu.Lock()
+		asked = append(asked, evt.Meta["arguments"].(map[string]any)["path"])
+		decision := runtime.ApprovalDecision{Decision: runtime.ApprovalApprove}
+		if len(asked) <= len(edits) {
+			decision = edits[len(asked)-1]
+		}
+		mu.Unlock()
+		go approvals.Resolve(evt.RunID, evt.Meta["tool_call_id"].(string), decision)
+	}
+	call := backboard.ToolCall{ID: "c1", Function: backboard.ToolCallFunction{Name: "read", ParsedArguments: []byte(`{"path":"a.txt"}`)}}
+
+	_, _, _, err := r.Execute(context.Background(), call, &ExecutionContext{RunID: "run-1", WorkspaceRoot: tmp, Approvals: approvals, Emitter: emitter, Role: types.RoleCoder})
+	if err == nil || !strings.Contains(err.Error(), "cannot check arguments") {
+		t.Fatalf("expected the escaping edit to be denied, got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(asked) != 2 || asked[0] != "a.txt" || asked[1] != "b.txt" {
+		t.Fatalf("expected the edited arguments to need approval again, got %v", asked)
+	}
+}
+
+func TestRegistryApprovalApproveAndDeny(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "a.txt"), []byte("guarded"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	r.SetApprovalPolicy(ApprovalPolicy{Rules: []types.ApprovalRule{{Tool: "read"}}, Timeout: time.Second})
+
+	approvals := runtime.NewApprovalStore()
+	decision := runtime.ApprovalDecision{Decision: runtime.ApprovalApprove}
+	emitter := &recordingEmitter{}
+	emitter.onEmit = func(evt types.Event) {
+		if evt.Type == "tool_approval_required" {
+			go approvals.Resolve(evt.RunID, evt.Meta["tool_call_id"].(string), decision)
+		}
+	}
+	execCtx := func() *ExecutionContext {
+		return &ExecutionContext{RunID: "run-1", WorkspaceRoot: tmp, Approvals: approvals, Emitter: emitter, Role: types.RoleCoder}
+	}
+	call := backboard.ToolCall{ID: "c1", Function: backboard.ToolCallFunction{Name: "read", ParsedArguments: []byte(`{"path":"a.txt"}`)}}
+
+	out, _, _, err := r.Execute(context.Background(), call, execCtx())
+	if err != nil || !strings.Contains(out.Output, "guarded") {
+		t.Fatalf("expected approved read to run, got %v %s", err, out.Output)
+	}
+
+	decision = runtime.ApprovalDecision{Decision: runtime.ApprovalDeny, Reason: "not this file"}
+	out, _, _, err = r.Execute(context.Background(), call, execCtx())
+	if err == nil {
+		t.Fatal("expected denial error")
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(out.Output), &payload); err != nil {
+		t.Fatalf("expected json output, got %s", out.Output)
+	}
+	if payload["denied"] != true || payload["reason"] != "not this file" {
+		t.Fatalf("expected structured denial, got %s", out.Output)
+	}
+}
+
+func TestRegistryApprovalTimesOutAsDenial(t *testing.T) {
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	r.SetApprovalPolicy(ApprovalPolicy{Rules: []types.ApprovalRule{{Tool: "ls"}}, Timeout: 20 * time.Millisecond})
+
+	out, _, _, err := r.Execute(context.Background(), backboard.ToolCall{
+		ID:       "c1",
+		Function: backboard.ToolCallFunction{Name: "ls", ParsedArguments: []byte(`{}`)},
+	}, &ExecutionContext{RunID: "run-1", WorkspaceRoot: t.TempDir(), Approvals: runtime.NewApprovalStore(), Role: types.RoleCoder})
+	if err == nil || !strings.Contains(out.Output, `"denied":true`) {
+		t.Fatalf("expected timeout denial, got %v %s", err, out.Output)
+	}
+}
+
+func TestRegistryApprovalDeniesBeforeTheSubtaskDeadline(t *testing.T) {
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	r.SetApprovalPolicy(ApprovalPolicy{Rules: []types.ApprovalRule{{Tool: "ls"}}, Timeout: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	out, _, _, err := r.Execute(ctx, backboard.ToolCall{
+		ID:       "c1",
+		Function: backboard.ToolCallFunction{Name: "ls", ParsedArguments: []byte(`{}`)},
+	}, &ExecutionContext{RunID: "run-1", WorkspaceRoot: t.TempDir(), Approvals: runtime.NewApprovalStore(), Role: types.RoleCoder})
+	if err == nil || errors.Is(err, context.DeadlineExceeded) || !strings.Contains(out.Output, "no approval received") {
+		t.Fatalf("expected a timeout denial before the deadline, got %v %s", err, out.Output)
+	}
+}
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/types"
+)
+
+func TestAskUserWaitsForAnswer(t *testing.T) {
+	runs := runtime.NewRunStore()
+	runID := runs.New("task")
+	runs.SetRunning(runID)
+	inputs := runtime.NewInputStore(runs)
+
+	emitter := &recordingEmitter{}
+	emitter.onEmit = func(evt types.Event) {
+		if evt.Type != "user_input_required" {
+			return
+		}
+		if run, _ := runs.Get(runID); run.Status != "waiting_for_input" {
+			t.Errorf("expected run waiting_for_input, got %s", run.Status)
+		}
+		go inputs.Answer(runID, evt.Meta["question_id"].(string), "use the staging cluster")
+	}
+
+	out, err := askUserTool(context.Background(), map[string]any{"question": "which cluster?"}, &ExecutionContext{
+		RunID: runID, AgentID: "agent-1", Inputs: inputs, Emitter: emitter, AskUserTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("ask_user failed: %v", err)
+	}
+	res := out.(map[string]any)
+	if res["answered"] != true || res["answer"] != "use the staging cluster" {
+		t.Fatalf("unexpected ask_user result: %+v", res)
+	}
+	if run, _ := runs.Get(runID); run.Status != "running" {
+		t.Fatalf("expected run back to running, got %s", run.Status)
+	}
+}
+
+func TestAskUserTimeoutFallsBack(t *testing.T) {
+	out, err := askUserTool(context.Background(), map[string]any{"question": "which cluster?"}, &ExecutionContext{
+		RunID: "run-1", Inputs: runtime.NewInputStore(nil), AskUserTimeout: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("ask_user failed: %v", err)
+	}
+	res := out.(map[string]any)
+	if res["answered"] != false {
+		t.Fatalf("expected unanswered fallback, got %+v", res)
+	}
+}
+
+func TestAskUserFallsBackBeforeTheSubtaskDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	out, err := askUserTool(ctx, map[string]any{"question": "which cluster?"}, &ExecutionContext{
+		RunID: "run-1", Inputs: runtime.NewInputStore(nil), AskUserTimeout: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("expected the fallback before the deadline, got %v", err)
+	}
+	if res := out.(map[string]any); res["answered"] != false || ctx.Err() != nil {
+		t.Fatalf("expected an unanswered fallback within the deadline, got %+v", res)
+	}
+}
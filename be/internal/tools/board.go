@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"backboard-swarm/be/internal/types"
+)
+
+const maxBoardWait = 60 * time.Second
+
+func boardPostTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	if execCtx.Board == nil {
+		return nil, errors.New("board is unavailable")
+	}
+	content := strings.TrimSpace(getString(args, "content", ""))
+	if content == "" {
+		return nil, errors.New("content is required")
+	}
+	entry := execCtx.Board.Post(execCtx.RunID, execCtx.AgentID, execCtx.Role, getString(args, "topic", ""), content)
+	if execCtx.Emitter != nil {
+		execCtx.Emitter.Emit(types.Event{
+			Type:      "board_post",
+			RunID:     execCtx.RunID,
+			AgentID:   execCtx.AgentID,
+			Role:      execCtx.Role,
+			Status:    "posted",
+			Message:   content,
+			Timestamp: entry.CreatedAt,
+			Meta:      map[string]any{"entry": entry},
+		})
+	}
+	return entry, nil
+}
+
+func boardReadTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	if execCtx.Board == nil {
+		return nil, errors.New("board is unavailable")
+	}
+	entries, latest := execCtx.Board.Read(execCtx.RunID, getInt(args, "since", 0), getString(args, "topic", ""))
+	return map[string]any{"entries": entries, "latest": latest}, nil
+}
+
+func boardSubscribeTool(ctx context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	if execCtx.Board == nil {
+		return nil, errors.New("board is unavailable")
+	}
+	timeout := time.Duration(getInt(args, "timeout_seconds", 20)) * time.Second
+	if timeout <= 0 || timeout > maxBoardWait {
+		timeout = maxBoardWait
+	}
+	entries, latest := execCtx.Board.Wait(ctx, execCtx.RunID, getInt(args, "since", 0), getString(args, "topic", ""), timeout)
+	return map[string]any{"entries": entries, "latest": latest, "timed_out": len(entries) == 0}, nil
+}
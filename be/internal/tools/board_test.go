@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"backboard-swarm/be/internal/backboard"
+	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/types"
+)
+
+func TestBoardToolsShareEntriesAcrossAgents(t *testing.T) {
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	board := runtime.NewBoardStore()
+	emitter := &recordingEmitter{}
+	ctxFor := func(agentID string, role types.Role) *ExecutionContext {
+		return &ExecutionContext{RunID: "run-1", AgentID: agentID, Role: role, Board: board, Emitter: emitter}
+	}
+	call := func(name, args string, execCtx *ExecutionContext) map[string]any {
+		out, _, _, err := r.Execute(context.Background(), backboard.ToolCall{
+			ID:       name,
+			Function: backboard.ToolCallFunction{Name: name, ParsedArguments: []byte(args)},
+		}, execCtx)
+		if err != nil {
+			t.Fatalf("%s failed: %v", name, err)
+		}
+		var payload struct {
+			Result map[string]any `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(out.Output), &payload); err != nil {
+			t.Fatalf("%s returned invalid json: %s", name, out.Output)
+		}
+		return payload.Result
+	}
+
+	call("board_post", `{"content":"Go 1.22 shipped 2024-02-06 [S1]","topic":"release"}`, ctxFor("agent-1", types.RoleResearcher))
+	read := call("board_read", `{"topic":"release"}`, ctxFor("agent-2", types.RoleCoder))
+	entries, _ := read["entries"].([]any)
+	if len(entries) != 1 || entries[0].(map[string]any)["agent_id"] != "agent-1" || read["latest"] != float64(1) {
+		t.Fatalf("expected one entry by agent-1, got %v", read)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		board.Post("run-1", "agent-3", types.RoleResearcher, "", "loopvar semantics changed")
+	}()
+	sub := call("board_subscribe", `{"since":1,"timeout_seconds":5}`, ctxFor("agent-2", types.RoleCoder))
+	entries, _ = sub["entries"].([]any)
+	if len(entries) != 1 || entries[0].(map[string]any)["content"] != "loopvar semantics changed" {
+		t.Fatalf("expected subscriber to wake on the new entry, got %v", sub)
+	}
+
+	if len(emitter.events) == 0 || emitter.events[0].Type != "board_post" {
+		t.Fatalf("expected board_post event, got %+v", emitter.events)
+	}
+}
@@ -1,82 +1,244 @@
 package tools
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
+	"backboard-swarm/be/internal/fetch"
+	"backboard-swarm/be/internal/search"
 	"backboard-swarm/be/internal/types"
 )
 
+const maxOutputPage = 16000
+
+const (
+	maxLsDepth       = 5
+	defaultListLimit = 500
+	maxListLimit     = 2000
+)
+
 func RegisterBuiltins(r *Registry) {
 	r.RegisterBuiltin(Registration{
 		Name:        "read",
-		Description: "Read file contents from the workspace",
+		Description: "Read files from the workspace as line-numbered text. Use offset_line and limit_lines to page through large files, or paths to read several files at once. Binary files are refused.",
 		Parameters: objectSchema(map[string]any{
-			"path":      map[string]any{"type": "string", "description": "Absolute or workspace-relative file path"},
-			"max_bytes": map[string]any{"type": "integer", "description": "Optional max bytes to read", "default": 20000},
-		}, []string{"path"}),
-		Handler: readTool,
+			"path":         map[string]any{"type": "string", "description": "Absolute or workspace-relative file path"},
+			"paths":        map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Read up to 20 files instead of path; each file is read with the same range"},
+			"offset_line":  map[string]any{"type": "integer", "description": "First line to return, starting at 1", "default": 1},
+			"limit_lines":  map[string]any{"type": "integer", "description": "Maximum lines to return", "default": defaultReadLines},
+			"max_bytes":    map[string]any{"type": "integer", "description": "Maximum bytes of content per file; output stops at the last whole line that fits", "default": defaultReadBytes},
+			"line_numbers": map[string]any{"type": "boolean", "description": "Prefix each line with its number and a tab", "default": true},
+		}, nil),
+		Handler:        readTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 24000,
 	})
 
 	r.RegisterBuiltin(Registration{
 		Name:        "ls",
-		Description: "List entries in a directory",
+		Description: "List a directory with each entry's type, size, mode and mtime, optionally recursing. Skips .gitignored entries.",
 		Parameters: objectSchema(map[string]any{
-			"path": map[string]any{"type": "string", "description": "Directory path. Defaults to workspace root."},
+			"path":      map[string]any{"type": "string", "description": "Directory path. Defaults to workspace root."},
+			"depth":     map[string]any{"type": "integer", "description": "Levels to recurse, 1 lists only the directory itself. At most 5."},
+			"limit":     map[string]any{"type": "integer", "description": "Maximum entries. Defaults to 500, at most 2000."},
+			"no_ignore": map[string]any{"type": "boolean", "description": "Also list entries .gitignore excludes"},
 		}, nil),
-		Handler: lsTool,
+		Handler:        lsTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 16000,
 	})
 
 	r.RegisterBuiltin(Registration{
 		Name:        "grep",
-		Description: "Search for a regex pattern in files under a path",
+		Description: "Search file contents for a regex under a path. Skips binary files, files over 10MB (listed in skipped_too_large) and anything .gitignore excludes.",
 		Parameters: objectSchema(map[string]any{
-			"pattern": map[string]any{"type": "string"},
-			"path":    map[string]any{"type": "string", "description": "File or directory path. Defaults to workspace root."},
+			"pattern":     map[string]any{"type": "string", "description": "RE2 regular expression"},
+			"path":        map[string]any{"type": "string", "description": "File or directory path. Defaults to workspace root."},
+			"ignore_case": map[string]any{"type": "boolean"},
+			"context":     map[string]any{"type": "integer", "description": "Lines of context before and after each match, at most 10"},
+			"before":      map[string]any{"type": "integer", "description": "Lines of context before each match"},
+			"after":       map[string]any{"type": "integer", "description": "Lines of context after each match"},
+			"include":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Only search files matching these globs, e.g. *.go or internal/**/*.go"},
+			"exclude":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Skip files and directories matching these globs"},
+			"mode":        map[string]any{"type": "string", "enum": []any{"content", "files", "count"}, "description": "content returns matching lines, files only the matching paths, count the matches per file"},
+			"max_results": map[string]any{"type": "integer", "description": "Matching lines (or files) to return. Defaults to 100, at most 1000."},
+			"no_ignore":   map[string]any{"type": "boolean", "description": "Also search files .gitignore excludes"},
 		}, []string{"pattern"}),
-		Handler: grepTool,
+		Handler:        grepTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 24000,
 	})
 
 	r.RegisterBuiltin(Registration{
 		Name:        "glob",
-		Description: "Find files matching a glob pattern",
+		Description: "Find files and directories matching a glob pattern; ** matches any number of directories, e.g. **/*.go. Skips .gitignored entries.",
 		Parameters: objectSchema(map[string]any{
-			"pattern": map[string]any{"type": "string"},
-			"path":    map[string]any{"type": "string", "description": "Base path. Defaults to workspace root."},
+			"pattern":   map[string]any{"type": "string"},
+			"path":      map[string]any{"type": "string", "description": "Base path. Defaults to workspace root."},
+			"sort":      map[string]any{"type": "string", "enum": []any{"name", "mtime", "size"}, "description": "name (default), mtime (newest first) or size (largest first)"},
+			"limit":     map[string]any{"type": "integer", "description": "Maximum matches. Defaults to 500, at most 2000."},
+			"details":   map[string]any{"type": "boolean", "description": "Return entries with type, size, mode and mtime instead of bare paths"},
+			"no_ignore": map[string]any{"type": "boolean", "description": "Also match entries .gitignore excludes"},
 		}, []string{"pattern"}),
-		Handler: globTool,
+		Handler:        globTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 16000,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:        "git_status",
+		Description: "Show the current branch and changed, staged and untracked files in the workspace repository",
+		Parameters: objectSchema(map[string]any{
+			"path": map[string]any{"type": "string", "description": "Limit to this file or directory. Defaults to workspace root."},
+		}, nil),
+		Handler:        gitStatusTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 16000,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:        "git_diff",
+		Description: "Show a diff of the working tree, the staged changes, or between revisions",
+		Parameters: objectSchema(map[string]any{
+			"path":   map[string]any{"type": "string", "description": "Limit to this file or directory. Defaults to workspace root."},
+			"staged": map[string]any{"type": "boolean", "description": "Diff staged changes instead of the working tree"},
+			"rev":    map[string]any{"type": "string", "description": "Compare against this revision, or a range like main..HEAD"},
+			"rev2":   map[string]any{"type": "string", "description": "Second revision to compare rev with"},
+			"stat":   map[string]any{"type": "boolean", "description": "Only list changed files with line counts"},
+		}, nil),
+		Handler:        gitDiffTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 24000,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:        "git_log",
+		Description: "List commits, newest first, optionally for one path, author or message pattern",
+		Parameters: objectSchema(map[string]any{
+			"path":      map[string]any{"type": "string", "description": "Only commits touching this file or directory"},
+			"rev":       map[string]any{"type": "string", "description": "Start from this revision or range. Defaults to HEAD."},
+			"author":    map[string]any{"type": "string"},
+			"grep":      map[string]any{"type": "string", "description": "Case-insensitive pattern matched against commit messages"},
+			"max_count": map[string]any{"type": "integer", "description": "Defaults to 20, at most 200"},
+		}, nil),
+		Handler:        gitLogTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 16000,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:        "git_blame",
+		Description: "Show which commit and author last changed each line of a file",
+		Parameters: objectSchema(map[string]any{
+			"path":       map[string]any{"type": "string"},
+			"start_line": map[string]any{"type": "integer", "description": "Defaults to 1"},
+			"end_line":   map[string]any{"type": "integer", "description": "Defaults to 200 lines after start_line"},
+			"rev":        map[string]any{"type": "string", "description": "Blame the file as of this revision"},
+		}, []string{"path"}),
+		Handler:        gitBlameTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 24000,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:        "git_show",
+		Description: "Show a commit's message and diff, or a file's contents at a revision when path is set",
+		Parameters: objectSchema(map[string]any{
+			"rev":  map[string]any{"type": "string", "description": "Defaults to HEAD"},
+			"path": map[string]any{"type": "string", "description": "Return this file as of rev instead of the commit"},
+			"stat": map[string]any{"type": "boolean", "description": "Only list the files the commit changed"},
+		}, nil),
+		Handler:        gitShowTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 24000,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:        "go_symbols",
+		Description: "Outline the top-level Go declarations of a file, or of every file in a package directory, with their signatures",
+		Parameters: objectSchema(map[string]any{
+			"path":          map[string]any{"type": "string", "description": "Go file or package directory. Defaults to workspace root."},
+			"exported_only": map[string]any{"type": "boolean"},
+		}, nil),
+		Handler:        goSymbolsTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 24000,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:        "go_package",
+		Description: "Summarise a Go package: doc, files, imports and its exported API grouped by type",
+		Parameters: objectSchema(map[string]any{
+			"path": map[string]any{"type": "string", "description": "Package directory. Defaults to workspace root."},
+		}, nil),
+		Handler:        goPackageTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 24000,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:           "go_definition",
+		Description:    "Find where a Go identifier is declared, either the identifier at path and line (and column) or every declaration of name such as Name, pkg.Name or Type.Method",
+		Parameters:     goTargetParameters(),
+		Handler:        goDefinitionTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 24000,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:           "go_references",
+		Description:    "List every use of a Go identifier across the workspace, chosen like go_definition",
+		Parameters:     goTargetParameters(),
+		Handler:        goReferencesTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 24000,
 	})
 
 	r.RegisterBuiltin(Registration{
 		Name:        "websearch",
-		Description: "Search the web using Jina and return SERP content",
+		Description: "Search the web with the configured search providers and return result titles, URLs and snippets. Results are registered as sources with ids like S1 to cite in findings.",
 		Parameters: objectSchema(map[string]any{
-			"query":     map[string]any{"type": "string"},
-			"max_bytes": map[string]any{"type": "integer", "description": "Optional max bytes to return", "default": 30000},
+			"query":       map[string]any{"type": "string"},
+			"max_results": map[string]any{"type": "integer", "description": "Optional max results to return", "default": 10},
+			"max_bytes":   map[string]any{"type": "integer", "description": "Optional max bytes of raw result content", "default": 30000},
 		}, []string{"query"}),
-		Handler: webSearchTool,
+		Handler:        webSearchTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 16000,
 	})
 
 	r.RegisterBuiltin(Registration{
 		Name:        "web_fetch",
-		Description: "Fetch a web page through Jina Reader and return markdown",
+		Description: "Fetch a web page and return its main content as markdown with links preserved. Handles HTML, plain text, JSON and PDF, and respects robots.txt. The page is registered as a source with an id to cite in findings.",
 		Parameters: objectSchema(map[string]any{
 			"url":       map[string]any{"type": "string", "description": "HTTP(S) URL to fetch"},
 			"max_bytes": map[string]any{"type": "integer", "description": "Optional max bytes to return", "default": 40000},
 		}, []string{"url"}),
-		Handler: webFetchTool,
+		Handler:        webFetchTool,
+		ReadOnly:       true,
+		MaxOutputBytes: 24000,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:        "read_output",
+		Description: "Page through a large tool output that was replaced by an output_id handle",
+		Parameters: objectSchema(map[string]any{
+			"output_id": map[string]any{"type": "string", "description": "Handle returned in place of the oversized output"},
+			"offset":    map[string]any{"type": "integer", "description": "Byte offset to start from; use next_offset from the previous page", "default": 0},
+			"limit":     map[string]any{"type": "integer", "description": "Optional max bytes to return (at most 16000)", "default": 8000},
+		}, []string{"output_id"}),
+		Handler:  readOutputTool,
+		ReadOnly: true,
 	})
 
 	r.RegisterBuiltin(Registration{
@@ -85,26 +247,72 @@ func RegisterBuiltins(r *Registry) {
 		Parameters: objectSchema(map[string]any{
 			"content": map[string]any{"type": "string"},
 		}, []string{"content"}),
-		Handler: messageTool,
+		Handler:  messageTool,
+		ReadOnly: true,
 	})
 
 	r.RegisterBuiltin(Registration{
-		Name:        "todo_create",
-		Description: "Create a todo item",
+		Name:        "ask_user",
+		Description: "Ask the user a clarifying question and wait for the answer. Only this agent pauses; use it when a wrong guess would waste the task.",
 		Parameters: objectSchema(map[string]any{
-			"title": map[string]any{"type": "string"},
-		}, []string{"title"}),
-		Handler: todoCreate,
+			"question": map[string]any{"type": "string"},
+			"options":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Optional suggested answers"},
+		}, []string{"question"}),
+		Handler: askUserTool,
 	})
 
 	r.RegisterBuiltin(Registration{
-		Name:        "todo_update",
-		Description: "Update a todo title",
+		Name:        "delegate",
+		Description: "Run independent child subtasks on helper agents in parallel and return their reports. Only available to subagents, within depth and fan-out limits.",
+		Parameters:  delegateParameters,
+		Handler:     delegateTool,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:        "board_post",
+		Description: "Share an intermediate finding on the run's shared board so agents working in parallel can use it",
+		Parameters: objectSchema(map[string]any{
+			"content": map[string]any{"type": "string"},
+			"topic":   map[string]any{"type": "string", "description": "Optional short topic to group related entries"},
+		}, []string{"content"}),
+		Handler: boardPostTool,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:        "board_read",
+		Description: "Read entries other agents posted on the run's shared board",
+		Parameters: objectSchema(map[string]any{
+			"since": map[string]any{"type": "integer", "description": "Only entries with a seq above this value"},
+			"topic": map[string]any{"type": "string"},
+		}, nil),
+		Handler:  boardReadTool,
+		ReadOnly: true,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:        "board_subscribe",
+		Description: "Wait until new entries appear on the shared board, up to timeout_seconds (max 60)",
 		Parameters: objectSchema(map[string]any{
-			"id":    map[string]any{"type": "string"},
-			"title": map[string]any{"type": "string"},
-		}, []string{"id", "title"}),
-		Handler: todoUpdate,
+			"since":           map[string]any{"type": "integer", "description": "Wait for entries with a seq above this value; use latest from a previous read"},
+			"topic":           map[string]any{"type": "string"},
+			"timeout_seconds": map[string]any{"type": "integer"},
+		}, nil),
+		Handler:  boardSubscribeTool,
+		ReadOnly: true,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:        "todo_create",
+		Description: "Create a todo, or several at once via items. Todos can nest under parent_id and wait on depends_on",
+		Parameters:  todoCreateParameters(),
+		Handler:     todoCreate,
+	})
+
+	r.RegisterBuiltin(Registration{
+		Name:        "todo_update",
+		Description: "Change a todo's title, status, notes, order, parent or dependencies, or several todos at once via updates",
+		Parameters:  todoUpdateParameters(),
+		Handler:     todoUpdate,
 	})
 
 	r.RegisterBuiltin(Registration{
@@ -118,14 +326,17 @@ func RegisterBuiltins(r *Registry) {
 
 	r.RegisterBuiltin(Registration{
 		Name:        "todo_list",
-		Description: "List current todos for this agent",
-		Parameters:  objectSchema(map[string]any{}, nil),
-		Handler:     todoList,
+		Description: "List this agent's todos in plan order, optionally filtered by status",
+		Parameters: objectSchema(map[string]any{
+			"status": todoStatusSchema,
+		}, nil),
+		Handler:  todoList,
+		ReadOnly: true,
 	})
 
 	r.RegisterBuiltin(Registration{
 		Name:        "todo_complete",
-		Description: "Mark a todo as completed",
+		Description: "Mark a todo as done",
 		Parameters: objectSchema(map[string]any{
 			"id": map[string]any{"type": "string"},
 		}, []string{"id"}),
@@ -134,32 +345,24 @@ func RegisterBuiltins(r *Registry) {
 
 	r.RegisterBuiltin(Registration{
 		Name:        "finish",
-		Description: "Signal that the agent is done and provide final summary",
-		Parameters: objectSchema(map[string]any{
-			"summary": map[string]any{"type": "string"},
-		}, []string{"summary"}),
-		Handler: finishTool,
+		Description: "Signal that the agent is done and provide the final result. Fill the fields required by your current mode.",
+		Parameters:  finishParameters(),
+		Handler:     finishTool,
+		ReadOnly:    true,
 	})
 }
 
-func objectSchema(properties map[string]any, required []string) map[string]any {
-	return map[string]any{"type": "object", "properties": properties, "required": required}
+func goTargetParameters() map[string]any {
+	return objectSchema(map[string]any{
+		"name":   map[string]any{"type": "string", "description": "Identifier, pkg.Name or Type.Member. With path and line it picks the identifier on that line."},
+		"path":   map[string]any{"type": "string", "description": "Go file containing the identifier"},
+		"line":   map[string]any{"type": "integer"},
+		"column": map[string]any{"type": "integer", "description": "1-based byte column of the identifier"},
+	}, nil)
 }
 
-func readTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
-	p, err := resolvePath(execCtx.WorkspaceRoot, getString(args, "path", ""))
-	if err != nil {
-		return nil, err
-	}
-	maxBytes := getInt(args, "max_bytes", 20000)
-	b, err := os.ReadFile(p)
-	if err != nil {
-		return nil, err
-	}
-	if len(b) > maxBytes {
-		b = b[:maxBytes]
-	}
-	return map[string]any{"path": p, "content": string(b)}, nil
+func objectSchema(properties map[string]any, required []string) map[string]any {
+	return map[string]any{"type": "object", "properties": properties, "required": required}
 }
 
 func lsTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
@@ -168,77 +371,60 @@ func lsTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (
 	if err != nil {
 		return nil, err
 	}
-	entries, err := os.ReadDir(resolved)
+	info, err := os.Stat(resolved)
 	if err != nil {
 		return nil, err
 	}
-	out := make([]string, 0, len(entries))
-	for _, e := range entries {
-		name := e.Name()
-		if e.IsDir() {
-			name += "/"
-		}
-		out = append(out, name)
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", resolved)
 	}
-	sort.Strings(out)
-	return map[string]any{"path": resolved, "entries": out}, nil
-}
-
-func grepTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
-	pattern := getString(args, "pattern", "")
-	if strings.TrimSpace(pattern) == "" {
-		return nil, errors.New("pattern is required")
-	}
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		return nil, err
-	}
-
-	basePath := getString(args, "path", execCtx.WorkspaceRoot)
-	root, err := resolvePath(execCtx.WorkspaceRoot, basePath)
+	workspace, err := filepath.Abs(execCtx.WorkspaceRoot)
 	if err != nil {
 		return nil, err
 	}
-
-	matches := make([]map[string]any, 0)
-	err = filepath.WalkDir(root, func(path string, d os.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return nil
+	depth := min(getInt(args, "depth", 1), maxLsDepth)
+	limit := min(getInt(args, "limit", defaultListLimit), maxListLimit)
+	entries := make([]map[string]any, 0)
+	truncated := false
+	err = walkWorkspace(workspace, resolved, getBool(args, "no_ignore", false), func(path, _ string, d os.DirEntry) error {
+		rel, _ := filepath.Rel(resolved, path)
+		rel = filepath.ToSlash(rel)
+		if len(entries) == limit {
+			truncated = true
+			return filepath.SkipAll
 		}
-		if d.IsDir() {
-			if d.Name() == ".git" || d.Name() == "node_modules" {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		if len(matches) >= 100 {
-			return ioEOF{}
-		}
-
-		f, err := os.Open(path)
+		info, err := d.Info()
 		if err != nil {
 			return nil
 		}
-		defer f.Close()
-
-		scanner := bufio.NewScanner(f)
-		lineNo := 0
-		for scanner.Scan() {
-			lineNo++
-			line := scanner.Text()
-			if re.MatchString(line) {
-				matches = append(matches, map[string]any{"path": path, "line": lineNo, "content": line})
-				if len(matches) >= 100 {
-					return ioEOF{}
-				}
-			}
+		entries = append(entries, fileEntry(rel, info))
+		if d.IsDir() && strings.Count(rel, "/")+1 >= depth {
+			return filepath.SkipDir
 		}
 		return nil
 	})
-	if err != nil && !errors.As(err, &ioEOF{}) {
+	if err != nil {
 		return nil, err
 	}
-	return map[string]any{"pattern": pattern, "matches": matches}, nil
+	sort.Slice(entries, func(i, j int) bool { return entries[i]["path"].(string) < entries[j]["path"].(string) })
+	return map[string]any{"path": resolved, "depth": depth, "entries": entries, "truncated": truncated}, nil
+}
+
+// fileEntry describes a file for ls and glob. Directory names end in a
+// slash.
+func fileEntry(name string, info os.FileInfo) map[string]any {
+	kind := "file"
+	switch {
+	case info.IsDir():
+		kind, name = "dir", name+"/"
+	case info.Mode()&os.ModeSymlink != 0:
+		kind = "symlink"
+	}
+	entry := map[string]any{"path": name, "type": kind, "mode": info.Mode().String(), "mtime": info.ModTime().UTC().Format(time.RFC3339)}
+	if !info.IsDir() {
+		entry["size"] = info.Size()
+	}
+	return entry
 }
 
 func globTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
@@ -251,15 +437,87 @@ func globTool(_ context.Context, args map[string]any, execCtx *ExecutionContext)
 	if err != nil {
 		return nil, err
 	}
-	globPattern := pattern
-	if !filepath.IsAbs(globPattern) {
-		globPattern = filepath.Join(baseResolved, globPattern)
+	if filepath.IsAbs(pattern) {
+		rel, err := filepath.Rel(baseResolved, filepath.Clean(pattern))
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return nil, fmt.Errorf("pattern %s is outside %s", pattern, baseResolved)
+		}
+		pattern = rel
+	}
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	if slices.Contains(strings.Split(pattern, "/"), "..") {
+		return nil, fmt.Errorf("pattern %s must not contain .. segments", pattern)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
 	}
-	results, err := filepath.Glob(globPattern)
+	workspace, err := filepath.Abs(execCtx.WorkspaceRoot)
 	if err != nil {
 		return nil, err
 	}
-	return map[string]any{"pattern": globPattern, "matches": results}, nil
+	sortBy := getString(args, "sort", "name")
+	limit := min(getInt(args, "limit", defaultListLimit), maxListLimit)
+
+	// Start the walk below the pattern's literal leading directories.
+	start, rest := baseResolved, pattern
+	for {
+		dir, tail, ok := strings.Cut(rest, "/")
+		if !ok || strings.ContainsAny(dir, "*?[\\") || dir == "**" {
+			break
+		}
+		start, rest = filepath.Join(start, dir), tail
+	}
+	type match struct {
+		path string
+		info os.FileInfo
+	}
+	matches := make([]match, 0)
+	if info, err := os.Stat(start); err == nil && info.IsDir() {
+		err = walkWorkspace(workspace, start, getBool(args, "no_ignore", false), func(p, _ string, d os.DirEntry) error {
+			rel, _ := filepath.Rel(start, p)
+			if !matchPath(rest, filepath.ToSlash(rel)) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			matches = append(matches, match{path: p, info: info})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch sortBy {
+	case "mtime":
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].info.ModTime().After(matches[j].info.ModTime()) })
+	case "size":
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].info.Size() > matches[j].info.Size() })
+	case "name":
+	default:
+		return nil, errors.New("sort must be name, mtime or size")
+	}
+	total := len(matches)
+	if total > limit {
+		matches = matches[:limit]
+	}
+	out := map[string]any{"pattern": pattern, "path": baseResolved, "total": total, "truncated": total > limit}
+	if getBool(args, "details", false) {
+		entries := make([]map[string]any, 0, len(matches))
+		for _, m := range matches {
+			rel, _ := filepath.Rel(baseResolved, m.path)
+			entries = append(entries, fileEntry(filepath.ToSlash(rel), m.info))
+		}
+		out["entries"] = entries
+		return out, nil
+	}
+	paths := make([]string, 0, len(matches))
+	for _, m := range matches {
+		paths = append(paths, m.path)
+	}
+	out["matches"] = paths
+	return out, nil
 }
 
 func webSearchTool(ctx context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
@@ -267,23 +525,30 @@ func webSearchTool(ctx context.Context, args map[string]any, execCtx *ExecutionC
 	if query == "" {
 		return nil, errors.New("query is required")
 	}
-	if strings.TrimSpace(execCtx.JinaAPIKey) == "" {
-		return nil, errors.New("JINA_API_KEY is required for websearch")
+	provider := execCtx.Search
+	if provider == nil {
+		provider = search.NewJina(execCtx.JinaAPIKey, execCtx.RequestTimeout)
 	}
 
-	maxBytes := getInt(args, "max_bytes", 30000)
-	endpoint := "https://s.jina.ai/?q=" + url.QueryEscape(query)
-	body, statusCode, truncated, err := jinaRequest(ctx, endpoint, execCtx.JinaAPIKey, execCtx.RequestTimeout, map[string]string{"X-Respond-With": "no-content"}, maxBytes)
+	resp, err := provider.Search(ctx, query, search.Options{MaxBytes: getInt(args, "max_bytes", 30000), MaxResults: getInt(args, "max_results", 10)})
 	if err != nil {
 		return nil, err
 	}
-
-	return map[string]any{
-		"query":       query,
-		"status_code": statusCode,
-		"content":     body,
-		"truncated":   truncated,
-	}, nil
+	sources := registerResultSources(execCtx, "websearch", resp.Results)
+	if len(resp.Results) == 0 {
+		sources = registerSERPSources(execCtx, "websearch", resp.Content)
+	}
+	out := map[string]any{
+		"query":     query,
+		"provider":  resp.Provider,
+		"results":   resp.Results,
+		"sources":   sources,
+		"truncated": resp.Truncated,
+	}
+	if resp.Content != "" {
+		out["content"] = resp.Content
+	}
+	return out, nil
 }
 
 func webFetchTool(ctx context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
@@ -291,71 +556,32 @@ func webFetchTool(ctx context.Context, args map[string]any, execCtx *ExecutionCo
 	if rawURL == "" {
 		return nil, errors.New("url is required")
 	}
-	if strings.TrimSpace(execCtx.JinaAPIKey) == "" {
-		return nil, errors.New("JINA_API_KEY is required for web_fetch")
-	}
 	if _, err := validateHTTPURL(rawURL); err != nil {
 		return nil, err
 	}
+	fetcher := execCtx.Fetcher
+	if fetcher == nil {
+		fetcher = fetch.NewNative(execCtx.RequestTimeout)
+	}
 
-	maxBytes := getInt(args, "max_bytes", 40000)
-	endpoint := "https://r.jina.ai/" + rawURL
-	body, statusCode, truncated, err := jinaRequest(ctx, endpoint, execCtx.JinaAPIKey, execCtx.RequestTimeout, nil, maxBytes)
+	page, err := fetcher.Fetch(ctx, rawURL, getInt(args, "max_bytes", 40000))
 	if err != nil {
 		return nil, err
 	}
 
 	return map[string]any{
-		"url":         rawURL,
-		"status_code": statusCode,
-		"markdown":    body,
-		"truncated":   truncated,
+		"url":          rawURL,
+		"final_url":    page.URL,
+		"status_code":  page.StatusCode,
+		"content_type": page.ContentType,
+		"fetcher":      page.Fetcher,
+		"title":        page.Title,
+		"sources":      registerPage(execCtx, "web_fetch", rawURL, page.Title),
+		"markdown":     page.Markdown,
+		"truncated":    page.Truncated,
 	}, nil
 }
 
-func jinaRequest(ctx context.Context, endpoint, apiKey string, timeout time.Duration, extraHeaders map[string]string, maxBytes int) (string, int, bool, error) {
-	if timeout <= 0 {
-		timeout = 60 * time.Second
-	}
-	if maxBytes <= 0 {
-		maxBytes = 20000
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return "", 0, false, err
-	}
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	for k, v := range extraHeaders {
-		req.Header.Set(k, v)
-	}
-
-	resp, err := (&http.Client{Timeout: timeout}).Do(req)
-	if err != nil {
-		return "", 0, false, err
-	}
-	defer resp.Body.Close()
-
-	limited := io.LimitReader(resp.Body, int64(maxBytes+1))
-	b, err := io.ReadAll(limited)
-	if err != nil {
-		return "", resp.StatusCode, false, err
-	}
-	truncated := len(b) > maxBytes
-	if truncated {
-		b = b[:maxBytes]
-	}
-
-	content := string(b)
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if len(content) > 220 {
-			content = content[:220] + "..."
-		}
-		return "", resp.StatusCode, false, fmt.Errorf("jina request failed (%d): %s", resp.StatusCode, content)
-	}
-	return content, resp.StatusCode, truncated, nil
-}
-
 func validateHTTPURL(raw string) (*url.URL, error) {
 	u, err := url.Parse(raw)
 	if err != nil {
@@ -370,6 +596,35 @@ func validateHTTPURL(raw string) (*url.URL, error) {
 	return u, nil
 }
 
+func readOutputTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	if execCtx.Outputs == nil {
+		return nil, errors.New("output store unavailable")
+	}
+	id := strings.TrimSpace(getString(args, "output_id", ""))
+	if id == "" {
+		return nil, errors.New("output_id is required")
+	}
+	limit := getInt(args, "limit", 8000)
+	if limit > maxOutputPage {
+		limit = maxOutputPage
+	}
+	offset := getInt(args, "offset", 0)
+	for {
+		page, ok := execCtx.Outputs.Read(execCtx.RunID, id, offset, limit)
+		if !ok {
+			return nil, fmt.Errorf("output %s not found", id)
+		}
+		// Pages are never stashed again, so shrink the page until the
+		// wrapped payload fits the tool's budget.
+		b, _ := json.Marshal(map[string]any{"ok": true, "result": page})
+		over := len(b) - execCtx.OutputBudget
+		if execCtx.OutputBudget <= 0 || over <= 0 || len(page.Content) <= 1 {
+			return page, nil
+		}
+		limit = max(len(page.Content)-over, 1)
+	}
+}
+
 func messageTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
 	content := getString(args, "content", "")
 	if strings.TrimSpace(content) == "" {
@@ -389,59 +644,88 @@ func messageTool(_ context.Context, args map[string]any, execCtx *ExecutionConte
 	return map[string]any{"ack": true}, nil
 }
 
-func todoCreate(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
-	if execCtx.Todos == nil {
-		return nil, errors.New("todo store unavailable")
-	}
-	title := getString(args, "title", "")
-	if title == "" {
-		return nil, errors.New("title is required")
-	}
-	item := execCtx.Todos.Create(execCtx.AgentID, title)
-	return item, nil
-}
+// userWaitMargin is the time at most kept back from the caller's deadline
+// when waiting for the user, so the agent can still act on the outcome.
+const userWaitMargin = 30 * time.Second
 
-func todoUpdate(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
-	id := getString(args, "id", "")
-	title := getString(args, "title", "")
-	if id == "" || title == "" {
-		return nil, errors.New("id and title are required")
-	}
-	item, ok := execCtx.Todos.Update(execCtx.AgentID, id, title)
+// userWait caps a wait for the user so that it ends before ctx does. The
+// subtask deadline would otherwise always fire first and the timeout
+// fallback would never be reached.
+func userWait(ctx context.Context, timeout time.Duration) time.Duration {
+	deadline, ok := ctx.Deadline()
 	if !ok {
-		return nil, fmt.Errorf("todo %s not found", id)
+		return timeout
 	}
-	return item, nil
+	left := time.Until(deadline)
+	left -= min(userWaitMargin, left/2)
+	return max(min(timeout, left), 0)
 }
 
-func todoDelete(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
-	id := getString(args, "id", "")
-	if id == "" {
-		return nil, errors.New("id is required")
+func askUserTool(ctx context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	if execCtx.Inputs == nil {
+		return nil, errors.New("user input is unavailable")
 	}
-	if !execCtx.Todos.Delete(execCtx.AgentID, id) {
-		return nil, fmt.Errorf("todo %s not found", id)
+	question := strings.TrimSpace(getString(args, "question", ""))
+	if question == "" {
+		return nil, errors.New("question is required")
 	}
-	return map[string]any{"deleted": id}, nil
-}
+	timeout := execCtx.AskUserTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	timeout = userWait(ctx, timeout)
+
+	q, answerCh := execCtx.Inputs.Open(execCtx.RunID, execCtx.AgentID, question, getStringSlice(args, "options"))
+	defer execCtx.Inputs.Close(execCtx.RunID, q.QuestionID)
 
-func todoList(_ context.Context, _ map[string]any, execCtx *ExecutionContext) (any, error) {
-	if execCtx.Todos == nil {
-		return nil, errors.New("todo store unavailable")
+	if execCtx.Emitter != nil {
+		execCtx.Emitter.Emit(types.Event{
+			Type:      "user_input_required",
+			RunID:     execCtx.RunID,
+			AgentID:   execCtx.AgentID,
+			Role:      execCtx.Role,
+			Status:    "waiting_for_input",
+			Message:   question,
+			Timestamp: time.Now().UTC(),
+			Meta: map[string]any{
+				"question_id":     q.QuestionID,
+				"options":         q.Options,
+				"timeout_seconds": int(timeout.Seconds()),
+			},
+		})
 	}
-	return execCtx.Todos.List(execCtx.AgentID), nil
-}
 
-func todoComplete(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
-	id := getString(args, "id", "")
-	if id == "" {
-		return nil, errors.New("id is required")
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	answered := false
+	answer := fmt.Sprintf("No answer from the user within %s. Proceed with your best judgement and state the assumptions you made.", timeout)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+	case a := <-answerCh:
+		answered = true
+		answer = a
 	}
-	item, ok := execCtx.Todos.Complete(execCtx.AgentID, id)
-	if !ok {
-		return nil, fmt.Errorf("todo %s not found", id)
+
+	if execCtx.Emitter != nil {
+		status := "answered"
+		if !answered {
+			status = "timed_out"
+		}
+		execCtx.Emitter.Emit(types.Event{
+			Type:      "user_input_received",
+			RunID:     execCtx.RunID,
+			AgentID:   execCtx.AgentID,
+			Role:      execCtx.Role,
+			Status:    status,
+			Message:   answer,
+			Timestamp: time.Now().UTC(),
+			Meta:      map[string]any{"question_id": q.QuestionID},
+		})
 	}
-	return item, nil
+	return map[string]any{"question_id": q.QuestionID, "answered": answered, "answer": answer}, nil
 }
 
 func finishTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
@@ -451,6 +735,9 @@ func finishTool(_ context.Context, args map[string]any, execCtx *ExecutionContex
 		summary = string(b)
 	}
 	execCtx.FinishSummary = summary
+	if execCtx.FinishMode != "" && !execCtx.SkipFinishValidation {
+		execCtx.FinishPayload, _ = json.Marshal(args)
+	}
 	if execCtx.Emitter != nil {
 		execCtx.Emitter.Emit(types.Event{
 			Type:      "agent_finished",
@@ -480,6 +767,20 @@ func getString(args map[string]any, key, fallback string) string {
 	return s
 }
 
+func getStringSlice(args map[string]any, key string) []string {
+	raw, ok := args[key].([]any)
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 func getInt(args map[string]any, key string, fallback int) int {
 	v, ok := args[key]
 	if !ok {
@@ -498,6 +799,13 @@ func getInt(args map[string]any, key string, fallback int) int {
 	return fallback
 }
 
+func getBool(args map[string]any, key string, fallback bool) bool {
+	if b, ok := args[key].(bool); ok {
+		return b
+	}
+	return fallback
+}
+
 func resolvePath(root, input string) (string, error) {
 	if strings.TrimSpace(input) == "" {
 		input = root
@@ -524,7 +832,3 @@ func resolvePath(root, input string) (string, error) {
 	}
 	return absAbs, nil
 }
-
-type ioEOF struct{}
-
-func (ioEOF) Error() string { return "stop" }
@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"backboard-swarm/be/internal/types"
+)
+
+// Delegator runs child subtasks on behalf of the calling agent. The
+// orchestrator hands each worker its own delegator so child ids and depth
+// limits follow the agent tree.
+type Delegator interface {
+	Delegate(ctx context.Context, subtasks []types.Subtask) ([]types.SubtaskResult, error)
+}
+
+var delegateSubtaskSchema = objectSchema(map[string]any{
+	"role": map[string]any{"type": "string", "enum": []any{"researcher", "fact_checker", "coder"}},
+	"task": map[string]any{"type": "string", "minLength": 1, "description": "Self-contained instructions for the helper agent"},
+}, []string{"role", "task"})
+
+var delegateParameters = objectSchema(map[string]any{
+	"subtasks": map[string]any{"type": "array", "minItems": 1, "items": delegateSubtaskSchema, "description": "Independent pieces of work to run in parallel"},
+}, []string{"subtasks"})
+
+func delegateTool(ctx context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	if execCtx.Delegator == nil {
+		return nil, errors.New("delegation is not available to this agent")
+	}
+	if err := validateSchema(delegateParameters, args, ""); err != nil {
+		return nil, fmt.Errorf("invalid delegate arguments: %w", err)
+	}
+	raw, _ := args["subtasks"].([]any)
+	subtasks := make([]types.Subtask, 0, len(raw))
+	for _, item := range raw {
+		m, _ := item.(map[string]any)
+		subtasks = append(subtasks, types.Subtask{
+			Role: types.Role(getString(m, "role", "")).Normalize(),
+			Task: strings.TrimSpace(getString(m, "task", "")),
+		})
+	}
+
+	results, err := execCtx.Delegator.Delegate(ctx, subtasks)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]map[string]any, 0, len(results))
+	for _, res := range results {
+		item := map[string]any{
+			"agent_id": res.AgentID,
+			"role":     res.Subtask.Role,
+			"task":     res.Subtask.Task,
+		}
+		if res.Error != "" {
+			item["error"] = res.Error
+		} else {
+			item["summary"] = res.Summary
+			item["findings"] = res.Findings
+			item["confidence"] = res.Confidence
+			item["sources"] = res.Sources
+		}
+		out = append(out, item)
+	}
+	return map[string]any{"results": out}, nil
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"backboard-swarm/be/internal/backboard"
+)
+
+func TestGlobRecursesSortsAndLsReportsMetadata(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":          "build/\n",
+		"main.go":             "package main\n",
+		"pkg/util.go":         "package pkg\n\nfunc Util() {}\n",
+		"pkg/deep/more/x.go":  "package more\n",
+		"pkg/deep/more/x.txt": "x\n",
+		"build/gen.go":        "package build\n",
+	}
+	base := time.Now().Add(-time.Hour)
+	i := 0
+	for _, name := range []string{".gitignore", "main.go", "pkg/util.go", "pkg/deep/more/x.go", "pkg/deep/more/x.txt", "build/gen.go"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(files[name]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		i++
+		stamp := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	call := func(tool, args string) map[string]any {
+		out, _, _, err := r.Execute(context.Background(), backboard.ToolCall{
+			ID:       tool,
+			Function: backboard.ToolCallFunction{Name: tool, ParsedArguments: []byte(args)},
+		}, &ExecutionContext{WorkspaceRoot: root})
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", tool, args, err)
+		}
+		var payload struct {
+			Result map[string]any `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(out.Output), &payload); err != nil {
+			t.Fatalf("invalid output %s", out.Output)
+		}
+		return payload.Result
+	}
+	rel := func(values []any) string {
+		out := []string{}
+		for _, v := range values {
+			p, _ := filepath.Rel(root, v.(string))
+			out = append(out, filepath.ToSlash(p))
+		}
+		return strings.Join(out, ",")
+	}
+
+	if got := rel(call("glob", `{"pattern":"**/*.go"}`)["matches"].([]any)); got != "main.go,pkg/deep/more/x.go,pkg/util.go" {
+		t.Fatalf("unexpected ** matches %s", got)
+	}
+	if got := rel(call("glob", `{"pattern":"pkg/**/*.go","sort":"mtime"}`)["matches"].([]any)); got != "pkg/deep/more/x.go,pkg/util.go" {
+		t.Fatalf("unexpected mtime order %s", got)
+	}
+	if got := rel(call("glob", `{"pattern":"**/*.go","no_ignore":true,"sort":"size","limit":1}`)["matches"].([]any)); got != "pkg/util.go" {
+		t.Fatalf("unexpected size order %s", got)
+	}
+	limited := call("glob", `{"pattern":"**","limit":2}`)
+	if limited["truncated"] != true || len(limited["matches"].([]any)) != 2 {
+		t.Fatalf("expected truncated glob, got %v", limited)
+	}
+	detailed := call("glob", `{"pattern":"*.go","details":true}`)["entries"].([]any)
+	if len(detailed) != 1 || detailed[0].(map[string]any)["path"] != "main.go" || detailed[0].(map[string]any)["size"] != float64(len(files["main.go"])) {
+		t.Fatalf("unexpected glob details %v", detailed)
+	}
+
+	paths := func(result map[string]any) string {
+		out := []string{}
+		for _, e := range result["entries"].([]any) {
+			out = append(out, e.(map[string]any)["path"].(string))
+		}
+		return strings.Join(out, ",")
+	}
+	if got := paths(call("ls", `{}`)); got != ".gitignore,main.go,pkg/" {
+		t.Fatalf("unexpected ls %s", got)
+	}
+	if got := paths(call("ls", `{"path":"pkg","depth":3}`)); got != "deep/,deep/more/,deep/more/x.go,deep/more/x.txt,util.go" {
+		t.Fatalf("unexpected recursive ls %s", got)
+	}
+	entry := call("ls", `{"path":"pkg"}`)["entries"].([]any)[1].(map[string]any)
+	if entry["type"] != "file" || entry["mode"] != "-rw-r--r--" || entry["size"] != float64(len(files["pkg/util.go"])) {
+		t.Fatalf("unexpected ls entry %v", entry)
+	}
+	if _, err := time.Parse(time.RFC3339, entry["mtime"].(string)); err != nil {
+		t.Fatalf("unexpected mtime %v", entry["mtime"])
+	}
+}
+
+func TestGlobRejectsPatternsLeavingTheWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "ws")
+	if err := os.MkdirAll(filepath.Join(root, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	for _, pattern := range []string{"../*.txt", "pkg/../../*.txt", "./../**"} {
+		out, _, _, err := r.Execute(context.Background(), backboard.ToolCall{
+			ID:       "glob",
+			Function: backboard.ToolCallFunction{Name: "glob", ParsedArguments: []byte(`{"pattern":"` + pattern + `"}`)},
+		}, &ExecutionContext{WorkspaceRoot: root})
+		if err == nil || strings.Contains(out.Output, "secret.txt") {
+			t.Fatalf("expected %s to be rejected, got %s", pattern, out.Output)
+		}
+	}
+}
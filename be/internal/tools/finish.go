@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+
+	"backboard-swarm/be/internal/types"
+)
+
+var ErrInvalidFinish = errors.New("invalid finish payload")
+
+var subtaskSchema = objectSchema(map[string]any{
+	"role":            map[string]any{"type": "string", "enum": []any{"researcher", "fact_checker", "coder"}},
+	"task":            map[string]any{"type": "string", "minLength": 1, "description": "Detailed, self-contained instructions for the subagent"},
+	"ensemble":        map[string]any{"type": "integer", "minimum": 1, "maximum": 5, "description": "Run the subtask on this many independent agents and reconcile their answers"},
+	"retries":         map[string]any{"type": "integer", "minimum": 0, "maximum": 3, "description": "Automatic retries if the subagent fails"},
+	"timeout_seconds": map[string]any{"type": "integer", "minimum": 1, "description": "Wall-clock budget for the subtask; defaults per role"},
+	"max_iterations":  map[string]any{"type": "integer", "minimum": 1, "description": "Tool-loop iteration budget for the subtask; defaults per role"},
+	"escalate_role":   map[string]any{"type": "string", "enum": []any{"researcher", "fact_checker", "coder"}, "description": "Role to hand the subtask to when a retry is needed"},
+}, []string{"role", "task"})
+
+var finishSchemas = map[types.FinishMode]map[string]any{
+	types.FinishPlan: objectSchema(map[string]any{
+		"subtasks": map[string]any{"type": "array", "minItems": 1, "items": subtaskSchema},
+		"summary":  map[string]any{"type": "string"},
+	}, []string{"subtasks"}),
+	types.FinishDecision: objectSchema(map[string]any{
+		"action":   map[string]any{"type": "string", "enum": []any{"decompose", "finalize"}},
+		"subtasks": map[string]any{"type": "array", "items": subtaskSchema},
+		"summary":  map[string]any{"type": "string"},
+	}, []string{"action"}),
+	types.FinishReport: objectSchema(map[string]any{
+		"summary":    map[string]any{"type": "string", "minLength": 1},
+		"findings":   map[string]any{"type": "array", "items": map[string]any{"type": "string", "minLength": 1}},
+		"confidence": map[string]any{"type": "number", "minimum": 0, "maximum": 1},
+		"sources":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+	}, []string{"summary", "findings", "confidence"}),
+}
+
+func finishParameters() map[string]any {
+	return objectSchema(map[string]any{
+		"summary":    map[string]any{"type": "string", "description": "Final summary in markdown"},
+		"subtasks":   map[string]any{"type": "array", "items": subtaskSchema, "description": "MODE: DECOMPOSE plan, or follow-up work when action is decompose"},
+		"action":     map[string]any{"type": "string", "enum": []any{"decompose", "finalize"}, "description": "MODE: DECIDE_NEXT_STEP only"},
+		"findings":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Subagent reports: one key finding per item"},
+		"confidence": map[string]any{"type": "number", "description": "Subagent reports: confidence in the findings from 0 to 1"},
+		"sources":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Subagent reports: URLs or file paths backing the findings"},
+	}, nil)
+}
+
+// validateFinish checks a finish call against the schema for the agent's
+// current mode. Calls without a mode are accepted as free-form summaries.
+func validateFinish(mode types.FinishMode, args map[string]any) error {
+	schema, ok := finishSchemas[mode]
+	if !ok {
+		return nil
+	}
+	if err := validateSchema(schema, args, ""); err != nil {
+		return fmt.Errorf("%w for %s: %v", ErrInvalidFinish, mode, err)
+	}
+	if mode == types.FinishDecision {
+		subtasks, _ := args["subtasks"].([]any)
+		summary, _ := args["summary"].(string)
+		switch args["action"] {
+		case "decompose":
+			if len(subtasks) == 0 {
+				return fmt.Errorf("%w for %s: subtasks are required when action is decompose", ErrInvalidFinish, mode)
+			}
+		case "finalize":
+			if summary == "" {
+				return fmt.Errorf("%w for %s: summary is required when action is finalize", ErrInvalidFinish, mode)
+			}
+		}
+	}
+	return nil
+}
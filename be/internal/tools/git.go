@@ -0,0 +1,283 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	gitTimeout       = 30 * time.Second
+	maxGitOutput     = 1 << 20
+	defaultGitLog    = 20
+	maxGitLog        = 200
+	defaultBlameSpan = 200
+)
+
+// revPattern accepts commit ids, branch and tag names, ranges and reflog
+// selectors such as HEAD~2, main..feature or HEAD@{1}.
+var revPattern = regexp.MustCompile(`^[A-Za-z0-9_./~^@{}+-]+$`)
+
+// gitArgs pins options that could run external programs or write to the
+// repository.
+var gitArgs = []string{"--no-pager", "--literal-pathspecs", "-c", "core.pager=cat", "-c", "diff.external=", "-c", "core.fsmonitor=false"}
+
+func checkRev(rev string) error {
+	if strings.HasPrefix(rev, "-") || !revPattern.MatchString(rev) {
+		return fmt.Errorf("invalid revision %q", rev)
+	}
+	return nil
+}
+
+// gitPath resolves path inside the workspace and returns it relative to
+// the workspace root for use as a pathspec.
+func gitPath(execCtx *ExecutionContext, path string) (string, error) {
+	resolved, err := resolvePath(execCtx.WorkspaceRoot, path)
+	if err != nil {
+		return "", err
+	}
+	root, err := filepath.Abs(execCtx.WorkspaceRoot)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// runGit runs a read-only git command in the workspace root and reports
+// whether its output was cut at maxGitOutput.
+func runGit(ctx context.Context, execCtx *ExecutionContext, args ...string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", append(append([]string{}, gitArgs...), args...)...)
+	cmd.Dir = execCtx.WorkspaceRoot
+	cmd.Env = append(cmd.Environ(), "GIT_OPTIONAL_LOCKS=0", "GIT_TERMINAL_PROMPT=0")
+	var stdout limitedBuffer
+	var stderr bytes.Buffer
+	stdout.limit = maxGitOutput
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", false, errors.New("git is not installed")
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", false, fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), stdout.truncated, nil
+}
+
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+func gitStatusTool(ctx context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	path, err := gitPath(execCtx, getString(args, "path", ""))
+	if err != nil {
+		return nil, err
+	}
+	out, truncated, err := runGit(ctx, execCtx, "status", "--porcelain=v1", "--branch", "--untracked-files=all", "--", path)
+	if err != nil {
+		return nil, err
+	}
+	branch := ""
+	entries := make([]map[string]any, 0)
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "## "):
+			branch = strings.TrimPrefix(line, "## ")
+		case len(line) > 3:
+			entries = append(entries, map[string]any{"status": strings.TrimSpace(line[:2]), "path": line[3:]})
+		}
+	}
+	return map[string]any{"branch": branch, "entries": entries, "clean": len(entries) == 0, "truncated": truncated}, nil
+}
+
+func gitDiffTool(ctx context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	path, err := gitPath(execCtx, getString(args, "path", ""))
+	if err != nil {
+		return nil, err
+	}
+	cmd := []string{"diff", "--no-ext-diff", "--no-textconv", "--no-color"}
+	if getBool(args, "staged", false) {
+		cmd = append(cmd, "--cached")
+	}
+	if getBool(args, "stat", false) {
+		cmd = append(cmd, "--stat")
+	}
+	for _, key := range []string{"rev", "rev2"} {
+		rev := getString(args, key, "")
+		if rev == "" {
+			continue
+		}
+		if err := checkRev(rev); err != nil {
+			return nil, err
+		}
+		cmd = append(cmd, rev)
+	}
+	out, truncated, err := runGit(ctx, execCtx, append(cmd, "--", path)...)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"path": path, "diff": out, "truncated": truncated}, nil
+}
+
+func gitLogTool(ctx context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	path, err := gitPath(execCtx, getString(args, "path", ""))
+	if err != nil {
+		return nil, err
+	}
+	n := min(getInt(args, "max_count", defaultGitLog), maxGitLog)
+	cmd := []string{"log", "--no-color", "--format=%H%x1f%an%x1f%aI%x1f%s", "-n", strconv.Itoa(n)}
+	if author := getString(args, "author", ""); author != "" {
+		cmd = append(cmd, "--author="+author)
+	}
+	if grep := getString(args, "grep", ""); grep != "" {
+		cmd = append(cmd, "-i", "--grep="+grep)
+	}
+	if rev := getString(args, "rev", ""); rev != "" {
+		if err := checkRev(rev); err != nil {
+			return nil, err
+		}
+		cmd = append(cmd, rev)
+	}
+	out, truncated, err := runGit(ctx, execCtx, append(cmd, "--", path)...)
+	if err != nil {
+		return nil, err
+	}
+	commits := make([]map[string]any, 0)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		parts := strings.SplitN(line, "\x1f", 4)
+		if len(parts) != 4 {
+			continue
+		}
+		commits = append(commits, map[string]any{"commit": parts[0], "author": parts[1], "date": parts[2], "subject": parts[3]})
+	}
+	return map[string]any{"path": path, "commits": commits, "truncated": truncated}, nil
+}
+
+func gitBlameTool(ctx context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	if getString(args, "path", "") == "" {
+		return nil, errors.New("path is required")
+	}
+	path, err := gitPath(execCtx, getString(args, "path", ""))
+	if err != nil {
+		return nil, err
+	}
+	// Without end_line, blame to the end of the file and keep the first
+	// defaultBlameSpan lines, since git rejects ranges past the last line.
+	start := getInt(args, "start_line", 1)
+	span := fmt.Sprintf("%d,", start)
+	limit := defaultBlameSpan
+	if end := getInt(args, "end_line", 0); end > 0 {
+		if end < start {
+			return nil, errors.New("end_line must not be before start_line")
+		}
+		span += strconv.Itoa(end)
+		limit = end - start + 1
+	}
+	cmd := []string{"blame", "--line-porcelain", "-L", span}
+	if rev := getString(args, "rev", ""); rev != "" {
+		if err := checkRev(rev); err != nil {
+			return nil, err
+		}
+		cmd = append(cmd, rev)
+	}
+	out, truncated, err := runGit(ctx, execCtx, append(cmd, "--", path)...)
+	if err != nil {
+		return nil, err
+	}
+	lines := parseBlame(out)
+	if len(lines) > limit {
+		lines, truncated = lines[:limit], true
+	}
+	return map[string]any{"path": path, "lines": lines, "truncated": truncated}, nil
+}
+
+// parseBlame reads git blame --line-porcelain output, where every line is
+// preceded by a full header for its commit.
+func parseBlame(out string) []map[string]any {
+	lines := make([]map[string]any, 0)
+	var cur map[string]any
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			if cur != nil {
+				cur["content"] = line[1:]
+				lines = append(lines, cur)
+				cur = nil
+			}
+		case cur == nil:
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+			n, _ := strconv.Atoi(fields[2])
+			cur = map[string]any{"commit": fields[0], "line": n}
+		case strings.HasPrefix(line, "author "):
+			cur["author"] = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			if sec, err := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64); err == nil {
+				cur["date"] = time.Unix(sec, 0).UTC().Format(time.RFC3339)
+			}
+		case strings.HasPrefix(line, "summary "):
+			cur["summary"] = strings.TrimPrefix(line, "summary ")
+		}
+	}
+	return lines
+}
+
+func gitShowTool(ctx context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	rev := getString(args, "rev", "HEAD")
+	if err := checkRev(rev); err != nil {
+		return nil, err
+	}
+	if getString(args, "path", "") != "" {
+		path, err := gitPath(execCtx, getString(args, "path", ""))
+		if err != nil {
+			return nil, err
+		}
+		out, truncated, err := runGit(ctx, execCtx, "show", "--no-textconv", rev+":./"+path)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"rev": rev, "path": path, "content": out, "truncated": truncated}, nil
+	}
+	cmd := []string{"show", "--no-ext-diff", "--no-textconv", "--no-color", "--format=commit %H%nAuthor: %an <%ae>%nDate: %aI%n%n%B"}
+	if getBool(args, "stat", false) {
+		cmd = append(cmd, "--stat")
+	}
+	// Limit the shown diff to the workspace when it is a subdirectory of
+	// the repository.
+	out, truncated, err := runGit(ctx, execCtx, append(cmd, rev, "--", ".")...)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"rev": rev, "show": out, "truncated": truncated}, nil
+}
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"backboard-swarm/be/internal/backboard"
+)
+
+func TestGitToolsStayInsideWorkspace(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	root := filepath.Join(repo, "app")
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Ada", "-c", "user.email=ada@example.com"}, args...)...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v %s", args, err, out)
+		}
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{"secret.txt": "outside\n", "app/main.go": "package main\n"} {
+		if err := os.WriteFile(filepath.Join(repo, path), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitRun("init", "-q")
+	gitRun("add", "-A")
+	gitRun("commit", "-q", "-m", "initial import")
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	execute := func(name, args string) (string, error) {
+		out, _, _, err := r.Execute(context.Background(), backboard.ToolCall{
+			ID:       name,
+			Function: backboard.ToolCallFunction{Name: name, ParsedArguments: []byte(args)},
+		}, &ExecutionContext{WorkspaceRoot: root})
+		return out.Output, err
+	}
+	call := func(name, args string) map[string]any {
+		out, err := execute(name, args)
+		if err != nil {
+			t.Fatalf("%s failed: %v", name, err)
+		}
+		var payload struct {
+			Result map[string]any `json:"result"`
+		}
+		_ = json.Unmarshal([]byte(out), &payload)
+		return payload.Result
+	}
+
+	status := call("git_status", `{}`)
+	entries, _ := status["entries"].([]any)
+	if len(entries) != 1 || entries[0].(map[string]any)["status"] != "M" {
+		t.Fatalf("expected one modified file, got %v", status)
+	}
+	if diff := call("git_diff", `{"path":"main.go"}`); !strings.Contains(diff["diff"].(string), "+func main() {}") {
+		t.Fatalf("expected working tree diff, got %v", diff)
+	}
+	log := call("git_log", `{"grep":"IMPORT"}`)
+	if commits, _ := log["commits"].([]any); len(commits) != 1 || commits[0].(map[string]any)["author"] != "Ada" {
+		t.Fatalf("expected the initial commit, got %v", log)
+	}
+	blame := call("git_blame", `{"path":"main.go"}`)
+	if lines, _ := blame["lines"].([]any); len(lines) != 3 || lines[0].(map[string]any)["summary"] != "initial import" {
+		t.Fatalf("expected blame for every line, got %v", blame)
+	}
+	if show := call("git_show", `{"path":"main.go"}`); show["content"] != "package main\n" {
+		t.Fatalf("expected committed file contents, got %v", show)
+	}
+	if show := call("git_show", `{}`); strings.Contains(show["show"].(string), "secret.txt") {
+		t.Fatalf("expected commit diff limited to the workspace, got %v", show)
+	}
+
+	for name, args := range map[string]string{
+		"git_show": `{"path":"../secret.txt"}`,
+		"git_log":  `{"rev":"--output=/tmp/x"}`,
+		"git_diff": `{"rev":"HEAD:../secret.txt"}`,
+	} {
+		if out, err := execute(name, args); err == nil {
+			t.Fatalf("expected %s %s to be rejected, got %s", name, args, out)
+		}
+	}
+}
@@ -0,0 +1,744 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"hash/fnv"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxGoReferences = 200
+	maxGoLoaders    = 8
+)
+
+var moduleLine = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+
+// goLoader parses and type-checks Go packages inside the workspace. Imports
+// of other workspace packages are resolved from source; anything else, the
+// standard library included, is stubbed with an empty package so the
+// workspace code still type-checks as far as it can.
+type goLoader struct {
+	root    string
+	fset    *token.FileSet
+	modules map[string]string
+	pkgs    map[string]*goPackage
+	stubs   map[string]*types.Package
+}
+
+type goPackage struct {
+	dir   string
+	path  string
+	files []*ast.File
+	types *types.Package
+	info  *types.Info
+}
+
+// goLoaders caches a loader per workspace root, so packages are parsed and
+// type-checked once rather than on every call. A loader is rebuilt when any
+// Go file or go.mod under its root changes.
+var goLoaders = struct {
+	mu      sync.Mutex
+	entries map[string]*goLoaderEntry
+}{entries: map[string]*goLoaderEntry{}}
+
+type goLoaderEntry struct {
+	mu     sync.Mutex
+	loader *goLoader
+	stamp  uint64
+	used   time.Time
+}
+
+// goLoaderFor returns the cached loader for root, locked for the caller
+// until it calls release.
+func goLoaderFor(root string) (l *goLoader, release func(), err error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	goLoaders.mu.Lock()
+	entry, ok := goLoaders.entries[abs]
+	if !ok {
+		if len(goLoaders.entries) >= maxGoLoaders {
+			evictGoLoader()
+		}
+		entry = &goLoaderEntry{}
+		goLoaders.entries[abs] = entry
+	}
+	entry.used = time.Now()
+	goLoaders.mu.Unlock()
+
+	entry.mu.Lock()
+	stamp := goSourceStamp(abs)
+	if entry.loader == nil || entry.stamp != stamp {
+		if entry.loader, err = newGoLoader(abs); err != nil {
+			entry.mu.Unlock()
+			return nil, nil, err
+		}
+		entry.stamp = stamp
+	}
+	return entry.loader, entry.mu.Unlock, nil
+}
+
+// evictGoLoader drops the least recently used loader. goLoaders.mu must be
+// held.
+func evictGoLoader() {
+	oldest := ""
+	for root, entry := range goLoaders.entries {
+		if oldest == "" || entry.used.Before(goLoaders.entries[oldest].used) {
+			oldest = root
+		}
+	}
+	delete(goLoaders.entries, oldest)
+}
+
+// goSourceStamp fingerprints the path, size and mtime of every Go file and
+// go.mod under root.
+func goSourceStamp(root string) uint64 {
+	h := fnv.New64a()
+	_ = walkGoDirs(root, func(dir string) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			if e.IsDir() || (!strings.HasSuffix(e.Name(), ".go") && e.Name() != "go.mod") {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00%d\n", filepath.Join(dir, e.Name()), info.Size(), info.ModTime().UnixNano())
+		}
+	})
+	return h.Sum64()
+}
+
+func newGoLoader(root string) (*goLoader, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	l := &goLoader{root: abs, fset: token.NewFileSet(), modules: map[string]string{}, pkgs: map[string]*goPackage{}, stubs: map[string]*types.Package{}}
+	err = l.walkDirs(func(dir string) {
+		b, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			return
+		}
+		if m := moduleLine.FindSubmatch(b); m != nil {
+			l.modules[dir] = string(m[1])
+		}
+	})
+	return l, err
+}
+
+// walkDirs calls fn for every directory under the root that Go tooling
+// would consider.
+func (l *goLoader) walkDirs(fn func(dir string)) error {
+	return walkGoDirs(l.root, fn)
+}
+
+func walkGoDirs(root string, fn func(dir string)) error {
+	return filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if p != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "node_modules" || name == "vendor" || name == "testdata") {
+			return filepath.SkipDir
+		}
+		fn(p)
+		return nil
+	})
+}
+
+// importPath returns the import path of dir from the closest enclosing
+// module, or the path relative to the root when there is none.
+func (l *goLoader) importPath(dir string) string {
+	best := ""
+	for modDir := range l.modules {
+		if (dir == modDir || strings.HasPrefix(dir, modDir+string(filepath.Separator))) && len(modDir) > len(best) {
+			best = modDir
+		}
+	}
+	if best == "" {
+		rel, _ := filepath.Rel(l.root, dir)
+		return filepath.ToSlash(rel)
+	}
+	rel, _ := filepath.Rel(best, dir)
+	if rel == "." {
+		return l.modules[best]
+	}
+	return l.modules[best] + "/" + filepath.ToSlash(rel)
+}
+
+func (l *goLoader) dirFor(importPath string) string {
+	for modDir, modPath := range l.modules {
+		rest, ok := strings.CutPrefix(importPath, modPath)
+		if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+			continue
+		}
+		dir := filepath.Join(modDir, filepath.FromSlash(rest))
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
+
+func (l *goLoader) Import(importPath string) (*types.Package, error) {
+	if dir := l.dirFor(importPath); dir != "" {
+		if p, err := l.load(dir); err == nil && p.types != nil {
+			return p.types, nil
+		}
+	}
+	if stub, ok := l.stubs[importPath]; ok {
+		return stub, nil
+	}
+	name := path.Base(importPath)
+	if strings.HasPrefix(name, "v") && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	stub := types.NewPackage(importPath, strings.NewReplacer("-", "_", ".", "_").Replace(name))
+	stub.MarkComplete()
+	l.stubs[importPath] = stub
+	return stub, nil
+}
+
+// load parses the package in dir together with its in-package tests and
+// type-checks it, keeping whatever information survives type errors.
+func (l *goLoader) load(dir string) (*goPackage, error) {
+	if p, ok := l.pkgs[dir]; ok {
+		if p == nil {
+			return nil, fmt.Errorf("import cycle through %s", dir)
+		}
+		return p, nil
+	}
+	l.pkgs[dir] = nil
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		delete(l.pkgs, dir)
+		return nil, err
+	}
+	p := &goPackage{dir: dir, path: l.importPath(dir)}
+	var tests []*ast.File
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		// Files with syntax errors still yield a partial AST worth keeping.
+		f, _ := parser.ParseFile(l.fset, filepath.Join(dir, name), nil, parser.ParseComments|parser.SkipObjectResolution)
+		if f == nil {
+			continue
+		}
+		if strings.HasSuffix(name, "_test.go") {
+			tests = append(tests, f)
+		} else {
+			p.files = append(p.files, f)
+		}
+	}
+	pkgName := ""
+	if len(p.files) > 0 {
+		pkgName = p.files[0].Name.Name
+	}
+	for _, f := range tests {
+		if pkgName == "" {
+			pkgName = f.Name.Name
+		}
+		if f.Name.Name == pkgName {
+			p.files = append(p.files, f)
+		}
+	}
+	if len(p.files) == 0 {
+		delete(l.pkgs, dir)
+		return nil, fmt.Errorf("no Go files in %s", l.rel(dir))
+	}
+	p.info = &types.Info{
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+	}
+	conf := types.Config{Importer: l, FakeImportC: true, Error: func(error) {}}
+	p.types, _ = conf.Check(p.path, l.fset, p.files, p.info)
+	l.pkgs[dir] = p
+	return p, nil
+}
+
+// loadAll loads every package in the workspace.
+func (l *goLoader) loadAll() []*goPackage {
+	_ = l.walkDirs(func(dir string) { _, _ = l.load(dir) })
+	out := make([]*goPackage, 0, len(l.pkgs))
+	for _, p := range l.pkgs {
+		if p != nil {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].dir < out[j].dir })
+	return out
+}
+
+func (l *goLoader) rel(p string) string {
+	rel, err := filepath.Rel(l.root, p)
+	if err != nil {
+		return p
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (l *goLoader) location(pos token.Pos) map[string]any {
+	position := l.fset.Position(pos)
+	out := map[string]any{"path": l.rel(position.Filename), "line": position.Line, "column": position.Column}
+	if b, err := os.ReadFile(position.Filename); err == nil {
+		lines := strings.Split(string(b), "\n")
+		if position.Line >= 1 && position.Line <= len(lines) {
+			out["snippet"] = strings.TrimSpace(lines[position.Line-1])
+		}
+	}
+	return out
+}
+
+func (l *goLoader) describe(obj types.Object) map[string]any {
+	out := l.location(obj.Pos())
+	out["name"] = obj.Name()
+	out["kind"] = objectKind(obj)
+	// Types from stubbed packages print as invalid; the source line reads
+	// better then.
+	sig := types.ObjectString(obj, func(p *types.Package) string {
+		if p == obj.Pkg() {
+			return ""
+		}
+		return p.Name()
+	})
+	if snippet, ok := out["snippet"].(string); ok && strings.Contains(sig, "invalid type") {
+		sig = strings.TrimSpace(strings.TrimSuffix(snippet, "{"))
+	}
+	out["signature"] = sig
+	if obj.Pkg() != nil {
+		out["package"] = obj.Pkg().Path()
+	}
+	return out
+}
+
+func objectKind(obj types.Object) string {
+	switch o := obj.(type) {
+	case *types.Func:
+		if sig, ok := o.Type().(*types.Signature); ok && sig.Recv() != nil {
+			return "method"
+		}
+		return "func"
+	case *types.TypeName:
+		return "type"
+	case *types.Const:
+		return "const"
+	case *types.PkgName:
+		return "package"
+	case *types.Var:
+		if o.IsField() {
+			return "field"
+		}
+		return "var"
+	}
+	return "object"
+}
+
+// origin maps instantiated generic members back to their declaration.
+func origin(obj types.Object) types.Object {
+	switch o := obj.(type) {
+	case *types.Func:
+		return o.Origin()
+	case *types.Var:
+		return o.Origin()
+	}
+	return obj
+}
+
+// resolveTarget finds the objects a tool call refers to, either the
+// identifier at path:line[:column] or every declaration matching name
+// ("Name", "pkg.Name" or "Type.Member").
+func resolveTarget(l *goLoader, execCtx *ExecutionContext, args map[string]any) ([]types.Object, error) {
+	name := strings.TrimSpace(getString(args, "name", ""))
+	line := getInt(args, "line", 0)
+	if getString(args, "path", "") != "" && line > 0 {
+		file, err := resolvePath(execCtx.WorkspaceRoot, getString(args, "path", ""))
+		if err != nil {
+			return nil, err
+		}
+		obj, err := l.objectAt(file, line, getInt(args, "column", 0), name)
+		if err != nil {
+			return nil, err
+		}
+		return []types.Object{obj}, nil
+	}
+	if name == "" {
+		return nil, errors.New("name, or path and line, is required")
+	}
+	head, member, qualified := strings.Cut(name, ".")
+	out := make([]types.Object, 0)
+	for _, p := range l.loadAll() {
+		if p.types == nil {
+			continue
+		}
+		scope := p.types.Scope()
+		if !qualified {
+			if obj := scope.Lookup(head); obj != nil {
+				out = append(out, obj)
+			}
+			continue
+		}
+		if p.types.Name() == head {
+			if obj := scope.Lookup(member); obj != nil {
+				out = append(out, obj)
+			}
+		}
+		if tn, ok := scope.Lookup(head).(*types.TypeName); ok {
+			if obj, _, _ := types.LookupFieldOrMethod(tn.Type(), true, p.types, member); obj != nil {
+				out = append(out, obj)
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no declaration named %s in the workspace", name)
+	}
+	return out, nil
+}
+
+// objectAt returns the object for the identifier at line and column of
+// file. Without a column it takes the first identifier on the line, or the
+// first one called name.
+func (l *goLoader) objectAt(file string, line, column int, name string) (types.Object, error) {
+	p, err := l.load(filepath.Dir(file))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range p.files {
+		tf := l.fset.File(f.Pos())
+		if tf == nil || tf.Name() != file {
+			continue
+		}
+		if line > tf.LineCount() {
+			return nil, fmt.Errorf("%s has only %d lines", l.rel(file), tf.LineCount())
+		}
+		var found *ast.Ident
+		ast.Inspect(f, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok || found != nil {
+				return found == nil
+			}
+			pos := l.fset.Position(id.Pos())
+			switch {
+			case pos.Line != line:
+			case column > 0:
+				if column >= pos.Column && column < pos.Column+len(id.Name) {
+					found = id
+				}
+			case name == "" || id.Name == name:
+				found = id
+			}
+			return true
+		})
+		if found == nil {
+			return nil, fmt.Errorf("no identifier at %s:%d", l.rel(file), line)
+		}
+		obj := p.info.Defs[found]
+		if obj == nil {
+			obj = p.info.Uses[found]
+		}
+		if obj == nil {
+			return nil, fmt.Errorf("cannot resolve %s at %s:%d", found.Name, l.rel(file), line)
+		}
+		return origin(obj), nil
+	}
+	return nil, fmt.Errorf("%s is not a Go file in the workspace", l.rel(file))
+}
+
+func goDefinitionTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	l, release, err := goLoaderFor(execCtx.WorkspaceRoot)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	objs, err := resolveTarget(l, execCtx, args)
+	if err != nil {
+		return nil, err
+	}
+	defs := make([]map[string]any, 0, len(objs))
+	for _, obj := range objs {
+		if pn, ok := obj.(*types.PkgName); ok {
+			def := map[string]any{"kind": "package", "name": pn.Imported().Name(), "package": pn.Imported().Path()}
+			if dir := l.dirFor(pn.Imported().Path()); dir != "" {
+				def["path"] = l.rel(dir)
+			} else {
+				def["external"] = true
+			}
+			defs = append(defs, def)
+			continue
+		}
+		if !obj.Pos().IsValid() {
+			pkg := "builtin"
+			if obj.Pkg() != nil {
+				pkg = obj.Pkg().Path()
+			}
+			defs = append(defs, map[string]any{"name": obj.Name(), "kind": objectKind(obj), "package": pkg, "external": true})
+			continue
+		}
+		defs = append(defs, l.describe(obj))
+	}
+	return map[string]any{"definitions": defs}, nil
+}
+
+func goReferencesTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	l, release, err := goLoaderFor(execCtx.WorkspaceRoot)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	objs, err := resolveTarget(l, execCtx, args)
+	if err != nil {
+		return nil, err
+	}
+	targets := map[types.Object]bool{}
+	for _, obj := range objs {
+		targets[origin(obj)] = true
+	}
+	refs := make([]map[string]any, 0)
+	for _, p := range l.loadAll() {
+		for _, uses := range []map[*ast.Ident]types.Object{p.info.Uses, p.info.Defs} {
+			for id, obj := range uses {
+				if obj == nil || !targets[origin(obj)] {
+					continue
+				}
+				ref := l.location(id.Pos())
+				ref["definition"] = obj.Pos() == id.Pos()
+				refs = append(refs, ref)
+			}
+		}
+	}
+	// Sort before cutting so the same references come back on every call.
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i]["path"] != refs[j]["path"] {
+			return refs[i]["path"].(string) < refs[j]["path"].(string)
+		}
+		if refs[i]["line"] != refs[j]["line"] {
+			return refs[i]["line"].(int) < refs[j]["line"].(int)
+		}
+		return refs[i]["column"].(int) < refs[j]["column"].(int)
+	})
+	truncated := len(refs) > maxGoReferences
+	if truncated {
+		refs = refs[:maxGoReferences]
+	}
+	symbols := make([]map[string]any, 0, len(objs))
+	for _, obj := range objs {
+		symbols = append(symbols, l.describe(obj))
+	}
+	return map[string]any{"symbols": symbols, "references": refs, "truncated": truncated}, nil
+}
+
+// goSymbolsTool outlines the top-level declarations of a file or of every
+// file in a package directory.
+func goSymbolsTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	target, err := resolvePath(execCtx.WorkspaceRoot, getString(args, "path", ""))
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, err
+	}
+	l, release, err := goLoaderFor(execCtx.WorkspaceRoot)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	dir := target
+	if !info.IsDir() {
+		dir = filepath.Dir(target)
+	}
+	p, err := l.load(dir)
+	if err != nil {
+		return nil, err
+	}
+	exportedOnly := getBool(args, "exported_only", false)
+	symbols := make([]map[string]any, 0)
+	for _, f := range p.files {
+		if !info.IsDir() && l.fset.File(f.Pos()).Name() != target {
+			continue
+		}
+		for _, decl := range f.Decls {
+			for _, sym := range declSymbols(l, decl) {
+				if exportedOnly && !sym["exported"].(bool) {
+					continue
+				}
+				symbols = append(symbols, sym)
+			}
+		}
+	}
+	return map[string]any{"path": l.rel(target), "package": p.path, "symbols": symbols}, nil
+}
+
+func declSymbols(l *goLoader, decl ast.Decl) []map[string]any {
+	out := make([]map[string]any, 0, 1)
+	add := func(name, kind string, pos token.Pos, node any) {
+		sym := l.location(pos)
+		delete(sym, "snippet")
+		sym["name"], sym["kind"], sym["exported"], sym["signature"] = name, kind, ast.IsExported(name), render(l.fset, node)
+		out = append(out, sym)
+	}
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		head := *d
+		head.Body, head.Doc = nil, nil
+		kind, name := "func", d.Name.Name
+		if d.Recv != nil && len(d.Recv.List) > 0 {
+			kind, name = "method", receiverName(d.Recv.List[0].Type)+"."+name
+		}
+		add(name, kind, d.Name.Pos(), &head)
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				add(s.Name.Name, "type", s.Name.Pos(), &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{s}})
+			case *ast.ValueSpec:
+				for _, n := range s.Names {
+					if n.Name == "_" {
+						continue
+					}
+					add(n.Name, d.Tok.String(), n.Pos(), &ast.GenDecl{Tok: d.Tok, Specs: []ast.Spec{&ast.ValueSpec{Names: []*ast.Ident{n}, Type: s.Type}}})
+				}
+			}
+		}
+	}
+	return out
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+func render(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// goPackageTool summarises a package by its documentation and exported API,
+// grouping methods and constructors under their types like go doc does.
+func goPackageTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	dir, err := resolvePath(execCtx.WorkspaceRoot, getString(args, "path", ""))
+	if err != nil {
+		return nil, err
+	}
+	l, release, err := goLoaderFor(execCtx.WorkspaceRoot)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	p, err := l.load(dir)
+	if err != nil {
+		return nil, err
+	}
+	// go/doc strips unexported declarations from the files it is given, so
+	// it works on a fresh parse rather than the cached loader's ASTs.
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 0, len(p.files))
+	names := make([]string, 0, len(p.files))
+	imports := map[string]bool{}
+	for _, cached := range p.files {
+		name := l.fset.File(cached.Pos()).Name()
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		names = append(names, filepath.Base(name))
+		for _, imp := range f.Imports {
+			imports[strings.Trim(imp.Path.Value, `"`)] = true
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no non-test Go files in %s", l.rel(dir))
+	}
+	dp, err := doc.NewFromFiles(fset, files, p.path)
+	if err != nil {
+		return nil, err
+	}
+	values := func(vs []*doc.Value) []string {
+		out := make([]string, 0, len(vs))
+		for _, v := range vs {
+			out = append(out, render(fset, v.Decl))
+		}
+		return out
+	}
+	funcs := func(fs []*doc.Func) []map[string]any {
+		out := make([]map[string]any, 0, len(fs))
+		for _, f := range fs {
+			out = append(out, map[string]any{"name": f.Name, "signature": render(fset, f.Decl), "doc": dp.Synopsis(f.Doc)})
+		}
+		return out
+	}
+	typesOut := make([]map[string]any, 0, len(dp.Types))
+	for _, t := range dp.Types {
+		typesOut = append(typesOut, map[string]any{
+			"name":         t.Name,
+			"doc":          dp.Synopsis(t.Doc),
+			"decl":         render(fset, t.Decl),
+			"constructors": funcs(t.Funcs),
+			"methods":      funcs(t.Methods),
+			"consts":       values(t.Consts),
+			"vars":         values(t.Vars),
+		})
+	}
+	importList := make([]string, 0, len(imports))
+	for imp := range imports {
+		importList = append(importList, imp)
+	}
+	sort.Strings(importList)
+	return map[string]any{
+		"name":    dp.Name,
+		"path":    p.path,
+		"dir":     l.rel(dir),
+		"doc":     dp.Synopsis(dp.Doc),
+		"files":   names,
+		"imports": importList,
+		"consts":  values(dp.Consts),
+		"vars":    values(dp.Vars),
+		"funcs":   funcs(dp.Funcs),
+		"types":   typesOut,
+	}, nil
+}
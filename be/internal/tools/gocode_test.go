@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"backboard-swarm/be/internal/backboard"
+)
+
+func TestGoCodeTools(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/demo\n\ngo 1.22\n",
+		"shapes/shapes.go": `// Package shapes computes areas.
+package shapes
+
+import "math"
+
+// Circle is a round shape.
+type Circle struct {
+	Radius float64
+	label  string
+}
+
+// NewCircle returns a circle of radius r.
+func NewCircle(r float64) *Circle { return &Circle{Radius: r} }
+
+// Area returns the area.
+func (c *Circle) Area() float64 { return math.Pi * c.Radius * c.Radius }
+
+func helper() {}
+`,
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/demo/shapes"
+)
+
+func main() {
+	c := shapes.NewCircle(2)
+	fmt.Println(c.Area())
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	execute := func(name, args string) (string, error) {
+		out, _, _, err := r.Execute(context.Background(), backboard.ToolCall{
+			ID:       name,
+			Function: backboard.ToolCallFunction{Name: name, ParsedArguments: []byte(args)},
+		}, &ExecutionContext{WorkspaceRoot: root})
+		return out.Output, err
+	}
+	call := func(name, args string) map[string]any {
+		out, err := execute(name, args)
+		if err != nil {
+			t.Fatalf("%s failed: %v", name, err)
+		}
+		var payload struct {
+			Result map[string]any `json:"result"`
+		}
+		_ = json.Unmarshal([]byte(out), &payload)
+		return payload.Result
+	}
+
+	symbols := call("go_symbols", `{"path":"shapes/shapes.go"}`)
+	names := []string{}
+	for _, s := range symbols["symbols"].([]any) {
+		names = append(names, s.(map[string]any)["name"].(string))
+	}
+	if got := strings.Join(names, ","); got != "Circle,NewCircle,Circle.Area,helper" {
+		t.Fatalf("unexpected symbols %s", got)
+	}
+
+	pkg := call("go_package", `{"path":"shapes"}`)
+	types := pkg["types"].([]any)
+	circle := types[0].(map[string]any)
+	if pkg["path"] != "example.com/demo/shapes" || pkg["doc"] != "Package shapes computes areas." || len(types) != 1 ||
+		len(circle["constructors"].([]any)) != 1 || len(circle["methods"].([]any)) != 1 || len(pkg["funcs"].([]any)) != 0 {
+		t.Fatalf("unexpected package summary %v", pkg)
+	}
+	if strings.Contains(circle["decl"].(string), "label") {
+		t.Fatalf("expected unexported fields to be hidden, got %s", circle["decl"])
+	}
+
+	// go_package must not strip unexported declarations from the cached loader.
+	symbols = call("go_symbols", `{"path":"shapes/shapes.go"}`)
+	if n := len(symbols["symbols"].([]any)); n != 4 {
+		t.Fatalf("expected 4 symbols after go_package, got %v", symbols)
+	}
+	if def := call("go_definition", `{"name":"helper"}`); len(def["definitions"].([]any)) != 1 {
+		t.Fatalf("expected helper to be found after go_package, got %v", def)
+	}
+
+	def := call("go_definition", `{"path":"main.go","line":11,"name":"Area"}`)
+	d := def["definitions"].([]any)[0].(map[string]any)
+	if d["path"] != "shapes/shapes.go" || d["line"] != float64(16) || d["kind"] != "method" {
+		t.Fatalf("unexpected definition %v", def)
+	}
+	if def := call("go_definition", `{"path":"main.go","line":11,"name":"fmt"}`); def["definitions"].([]any)[0].(map[string]any)["external"] != true {
+		t.Fatalf("expected fmt to be reported as external, got %v", def)
+	}
+
+	refs := call("go_references", `{"name":"Circle.Area"}`)["references"].([]any)
+	if len(refs) != 2 || refs[0].(map[string]any)["path"] != "main.go" || refs[1].(map[string]any)["definition"] != true {
+		t.Fatalf("unexpected references %v", refs)
+	}
+
+	if _, err := execute("go_symbols", `{"path":"../"}`); err == nil {
+		t.Fatal("expected paths outside the workspace to be rejected")
+	}
+
+	// The cached loader notices edits.
+	edited := strings.Replace(files["shapes/shapes.go"], "// Area returns", "// Perimeter is not here yet.\n\n// Area returns", 1)
+	if err := os.WriteFile(filepath.Join(root, "shapes/shapes.go"), []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	def = call("go_definition", `{"name":"Circle.Area"}`)
+	if d := def["definitions"].([]any)[0].(map[string]any); d["line"] != float64(18) {
+		t.Fatalf("expected the edited file to be reloaded, got %v", def)
+	}
+}
+
+func TestGoReferencesSortsBeforeLimit(t *testing.T) {
+	root := t.TempDir()
+	var src strings.Builder
+	src.WriteString("package demo\n\nfunc f() {}\n\nfunc g() {\n")
+	for i := 0; i < maxGoReferences+50; i++ {
+		src.WriteString("\tf()\n")
+	}
+	src.WriteString("}\n")
+	if err := os.WriteFile(filepath.Join(root, "demo.go"), []byte(src.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := goReferencesTool(context.Background(), map[string]any{"name": "f"}, &ExecutionContext{WorkspaceRoot: root})
+	if err != nil {
+		t.Fatalf("go_references failed: %v", err)
+	}
+	result := out.(map[string]any)
+	refs := result["references"].([]map[string]any)
+	if len(refs) != maxGoReferences || result["truncated"] != true {
+		t.Fatalf("expected %d references and truncation, got %d %v", maxGoReferences, len(refs), result["truncated"])
+	}
+	if refs[0]["line"] != 3 || refs[len(refs)-1]["line"] != maxGoReferences+4 {
+		t.Fatalf("expected the first references by position, got lines %v to %v", refs[0]["line"], refs[len(refs)-1]["line"])
+	}
+}
@@ -0,0 +1,283 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	goruntime "runtime"
+	"strings"
+	"sync"
+
+	"backboard-swarm/be/internal/runtime"
+)
+
+const (
+	defaultGrepResults = 100
+	maxGrepResults     = 1000
+	maxGrepContext     = 10
+	maxGrepFileBytes   = 10 << 20
+	maxGrepLineBytes   = 500
+	binarySniffBytes   = 8000
+)
+
+type grepOptions struct {
+	re           *regexp.Regexp
+	before       int
+	after        int
+	include      []string
+	exclude      []string
+	mode         string
+	maxResults   int
+	noIgnore     bool
+	workspaceAbs string
+}
+
+type grepFile struct {
+	path     string
+	matches  []map[string]any
+	count    int
+	binary   bool
+	tooLarge bool
+}
+
+func grepTool(ctx context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	pattern := getString(args, "pattern", "")
+	if strings.TrimSpace(pattern) == "" {
+		return nil, errors.New("pattern is required")
+	}
+	expr := pattern
+	if getBool(args, "ignore_case", false) {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	root, err := resolvePath(execCtx.WorkspaceRoot, getString(args, "path", execCtx.WorkspaceRoot))
+	if err != nil {
+		return nil, err
+	}
+	workspaceAbs, err := filepath.Abs(execCtx.WorkspaceRoot)
+	if err != nil {
+		return nil, err
+	}
+	ctxLines := min(getInt(args, "context", 0), maxGrepContext)
+	opts := grepOptions{
+		re:           re,
+		before:       min(getInt(args, "before", ctxLines), maxGrepContext),
+		after:        min(getInt(args, "after", ctxLines), maxGrepContext),
+		include:      globArgs(args, "include"),
+		exclude:      globArgs(args, "exclude"),
+		mode:         getString(args, "mode", "content"),
+		maxResults:   min(getInt(args, "max_results", defaultGrepResults), maxGrepResults),
+		noIgnore:     getBool(args, "no_ignore", false),
+		workspaceAbs: workspaceAbs,
+	}
+	switch opts.mode {
+	case "content", "files", "count":
+	default:
+		return nil, errors.New("mode must be content, files or count")
+	}
+
+	files, err := grepWalk(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	results, skipped, tooLarge, truncated := grepFiles(ctx, files, opts)
+	out := map[string]any{
+		"pattern":           pattern,
+		"mode":              opts.mode,
+		"files_searched":    len(files),
+		"binary_skipped":    skipped,
+		"skipped_too_large": tooLarge,
+		"truncated":         truncated,
+	}
+	switch opts.mode {
+	case "files":
+		paths := make([]string, 0, len(results))
+		for _, r := range results {
+			paths = append(paths, r.path)
+		}
+		out["files"] = paths
+	case "count":
+		counts := make([]map[string]any, 0, len(results))
+		total := 0
+		for _, r := range results {
+			counts = append(counts, map[string]any{"path": r.path, "count": r.count})
+			total += r.count
+		}
+		out["counts"], out["total"] = counts, total
+	default:
+		matches := make([]map[string]any, 0)
+		for _, r := range results {
+			matches = append(matches, r.matches...)
+		}
+		out["matches"] = matches
+	}
+	return out, nil
+}
+
+// globArgs accepts a single glob or a list of them.
+func globArgs(args map[string]any, key string) []string {
+	if s := getString(args, key, ""); s != "" {
+		return []string{s}
+	}
+	return getStringSlice(args, key)
+}
+
+// grepWalk lists the files under root that pass the include, exclude and
+// .gitignore filters, in walk order.
+func grepWalk(root string, opts grepOptions) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+	files := make([]string, 0)
+	err = walkWorkspace(opts.workspaceAbs, root, opts.noIgnore, func(p, rel string, d os.DirEntry) error {
+		if d.IsDir() {
+			if matchGlobs(opts.exclude, rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || matchGlobs(opts.exclude, rel, false) {
+			return nil
+		}
+		if len(opts.include) > 0 && !matchGlobs(opts.include, rel, false) {
+			return nil
+		}
+		files = append(files, p)
+		return nil
+	})
+	return files, err
+}
+
+// grepFiles searches files in parallel batches and stops after the batch in
+// which maxResults is reached, keeping results in walk order. It also
+// returns the number of binary files skipped and the paths of files too
+// large to search.
+func grepFiles(ctx context.Context, files []string, opts grepOptions) ([]grepFile, int, []string, bool) {
+	workers := max(1, min(goruntime.NumCPU(), 8))
+	batch := workers * 8
+	out := make([]grepFile, 0)
+	tooLarge := make([]string, 0)
+	found, skipped := 0, 0
+	for start := 0; start < len(files); start += batch {
+		if ctx.Err() != nil {
+			return out, skipped, tooLarge, true
+		}
+		chunk := files[start:min(start+batch, len(files))]
+		results := make([]grepFile, len(chunk))
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					results[i] = grepFileMatches(chunk[i], opts)
+				}
+			}()
+		}
+		for i := range chunk {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+
+		for _, r := range results {
+			if r.binary {
+				skipped++
+				continue
+			}
+			if r.tooLarge {
+				tooLarge = append(tooLarge, r.path)
+				continue
+			}
+			if r.count == 0 {
+				continue
+			}
+			n := r.count
+			if opts.mode != "content" {
+				n = 1
+			}
+			if found+n > opts.maxResults {
+				if opts.mode == "content" && found < opts.maxResults {
+					r.matches = r.matches[:opts.maxResults-found]
+					out = append(out, r)
+				}
+				return out, skipped, tooLarge, true
+			}
+			found += n
+			out = append(out, r)
+		}
+	}
+	return out, skipped, tooLarge, false
+}
+
+func grepFileMatches(path string, opts grepOptions) grepFile {
+	res := grepFile{path: path}
+	f, err := os.Open(path)
+	if err != nil {
+		return res
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.Size() > maxGrepFileBytes {
+		res.tooLarge = true
+		return res
+	}
+	data, err := io.ReadAll(io.LimitReader(f, maxGrepFileBytes+1))
+	if err != nil {
+		return res
+	}
+	if len(data) > maxGrepFileBytes {
+		res.tooLarge = true
+		return res
+	}
+	if bytes.IndexByte(data[:min(len(data), binarySniffBytes)], 0) >= 0 {
+		res.binary = true
+		return res
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	for i, line := range lines {
+		if !opts.re.MatchString(line) {
+			continue
+		}
+		res.count++
+		if opts.mode != "content" {
+			continue
+		}
+		match := map[string]any{"path": path, "line": i + 1, "content": clipLine(line)}
+		if opts.before > 0 {
+			match["before"] = clipLines(lines[max(0, i-opts.before):i])
+		}
+		if opts.after > 0 {
+			match["after"] = clipLines(lines[i+1 : min(len(lines), i+1+opts.after)])
+		}
+		res.matches = append(res.matches, match)
+	}
+	return res
+}
+
+func clipLine(line string) string {
+	line = strings.TrimSuffix(line, "\r")
+	if len(line) <= maxGrepLineBytes {
+		return line
+	}
+	return line[:runtime.RuneBoundary(line, maxGrepLineBytes)] + "…"
+}
+
+func clipLines(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = clipLine(l)
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"backboard-swarm/be/internal/backboard"
+)
+
+func TestGrepFiltersAndModes(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":         "build/\n*.log\n!keep.log\n",
+		"main.go":            "package main\n\n// TODO: wire flags\nfunc main() {}\n",
+		"pkg/util.go":        "package pkg\n\n// todo: cache\n" + strings.Repeat("x", 70000) + " TODO long\n",
+		"pkg/.gitignore":     "generated.go\n",
+		"pkg/generated.go":   "// TODO generated\n",
+		"pkg/util_test.go":   "package pkg\n// TODO test\n",
+		"build/out.go":       "// TODO built\n",
+		"debug.log":          "TODO log\n",
+		"keep.log":           "TODO kept\n",
+		"docs/notes.md":      "TODO docs\n",
+		"assets/image.bin":   "TODO\x00binary",
+		"vendor/lib/lib.txt": "TODO vendored\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	grep := func(args string) map[string]any {
+		out, _, _, err := r.Execute(context.Background(), backboard.ToolCall{
+			ID:       "grep",
+			Function: backboard.ToolCallFunction{Name: "grep", ParsedArguments: []byte(args)},
+		}, &ExecutionContext{WorkspaceRoot: root})
+		if err != nil {
+			t.Fatalf("grep %s failed: %v", args, err)
+		}
+		var payload struct {
+			Result map[string]any `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(out.Output), &payload); err != nil {
+			t.Fatalf("invalid output %s", out.Output)
+		}
+		return payload.Result
+	}
+	rel := func(values []any) string {
+		out := []string{}
+		for _, v := range values {
+			p, _ := filepath.Rel(root, v.(string))
+			out = append(out, filepath.ToSlash(p))
+		}
+		return strings.Join(out, ",")
+	}
+
+	res := grep(`{"pattern":"TODO","mode":"files","exclude":["vendor/"]}`)
+	if got := rel(res["files"].([]any)); got != "docs/notes.md,keep.log,main.go,pkg/util.go,pkg/util_test.go" {
+		t.Fatalf("unexpected files %s", got)
+	}
+	if res["binary_skipped"] != float64(1) {
+		t.Fatalf("expected the binary file to be skipped, got %v", res)
+	}
+
+	res = grep(`{"pattern":"todo","ignore_case":true,"path":"pkg","include":["*.go"],"exclude":["*_test.go"],"context":1}`)
+	matches := res["matches"].([]any)
+	if len(matches) != 2 {
+		t.Fatalf("expected both matches in util.go, got %v", matches)
+	}
+	if res["pattern"] != "todo" {
+		t.Fatalf("expected the pattern as given, got %v", res["pattern"])
+	}
+	first := matches[0].(map[string]any)
+	if first["line"] != float64(3) || first["before"].([]any)[0] != "" || !strings.HasPrefix(first["after"].([]any)[0].(string), "xxx") {
+		t.Fatalf("expected context around the first match, got %v", first)
+	}
+	if long := matches[1].(map[string]any)["content"].(string); len(long) > maxGrepLineBytes+4 {
+		t.Fatalf("expected long line to be clipped, got %d bytes", len(long))
+	}
+
+	res = grep(`{"pattern":"TODO","mode":"count","include":["**/*.go"]}`)
+	if res["total"] != float64(3) {
+		t.Fatalf("expected 3 matches in go files, got %v", res)
+	}
+
+	res = grep(`{"pattern":"TODO","max_results":2}`)
+	if len(res["matches"].([]any)) != 2 || res["truncated"] != true {
+		t.Fatalf("expected results capped at 2, got %v", res)
+	}
+
+	res = grep(`{"pattern":"TODO","mode":"files","no_ignore":true,"include":["*.go"]}`)
+	if got := rel(res["files"].([]any)); !strings.Contains(got, "build/out.go") || !strings.Contains(got, "pkg/generated.go") {
+		t.Fatalf("expected ignored files with no_ignore, got %s", got)
+	}
+}
+
+func TestGrepReportsFilesTooLargeToSearch(t *testing.T) {
+	root := t.TempDir()
+	big := append([]byte("TODO at the start\n"), make([]byte, maxGrepFileBytes)...)
+	for i := 18; i < len(big); i++ {
+		big[i] = 'x'
+	}
+	if err := os.WriteFile(filepath.Join(root, "big.txt"), big, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "small.txt"), []byte("TODO small\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := grepTool(context.Background(), map[string]any{"pattern": "TODO", "mode": "files"}, &ExecutionContext{WorkspaceRoot: root})
+	if err != nil {
+		t.Fatalf("grep failed: %v", err)
+	}
+	res := out.(map[string]any)
+	large := res["skipped_too_large"].([]string)
+	if len(large) != 1 || filepath.Base(large[0]) != "big.txt" {
+		t.Fatalf("expected big.txt reported as too large, got %v", res)
+	}
+	if files := res["files"].([]string); len(files) != 1 || filepath.Base(files[0]) != "small.txt" {
+		t.Fatalf("expected only small.txt searched, got %v", files)
+	}
+}
@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// matchPath reports whether the slash-separated name matches pattern, where
+// "**" as a whole segment matches any number of segments and every other
+// segment follows path.Match.
+func matchPath(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for len(pattern) > 1 && pattern[1] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchGlobs matches rel against patterns the way ripgrep's --glob does:
+// patterns without a slash are compared with the base name only.
+func matchGlobs(patterns []string, rel string, isDir bool) bool {
+	for _, p := range patterns {
+		p = strings.TrimPrefix(p, "./")
+		if strings.HasSuffix(p, "/") {
+			if !isDir {
+				continue
+			}
+			p = strings.TrimSuffix(p, "/")
+		}
+		target := rel
+		if !strings.Contains(p, "/") {
+			target = path.Base(rel)
+		}
+		if matchPath(p, target) {
+			return true
+		}
+	}
+	return false
+}
+
+type ignoreRule struct {
+	base     string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ignoreRules holds the .gitignore rules seen so far, in the order git
+// evaluates them: the last matching rule decides.
+type ignoreRules []ignoreRule
+
+// withDir returns the rules extended by dir's .gitignore. dir and the
+// returned rules use paths relative to the workspace root.
+func (r ignoreRules) withDir(root, dir string) ignoreRules {
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(dir), ".gitignore"))
+	if err != nil {
+		return r
+	}
+	defer f.Close()
+	out := append(ignoreRules{}, r...)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: dir}
+		if strings.HasPrefix(line, "!") {
+			rule.negate, line = true, line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly, line = true, strings.TrimSuffix(line, "/")
+		}
+		rule.anchored = strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		if rule.pattern != "" {
+			out = append(out, rule)
+		}
+	}
+	return out
+}
+
+func (r ignoreRules) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range r {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if rule.base != "." && rule.base != "" {
+			var ok bool
+			if sub, ok = strings.CutPrefix(rel, rule.base+"/"); !ok {
+				continue
+			}
+		}
+		target := sub
+		if !rule.anchored {
+			target = path.Base(sub)
+		}
+		if matchPath(rule.pattern, target) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// walkWorkspace walks root, a directory inside the workspace, and calls
+// visit for every entry below it with its slash-separated path relative to
+// the workspace. .git and node_modules are always skipped and, unless
+// noIgnore is set, so is anything a .gitignore between the workspace and
+// the entry excludes. visit may return filepath.SkipDir for directories.
+func walkWorkspace(workspace, root string, noIgnore bool, visit func(path, rel string, d os.DirEntry) error) error {
+	rules := ignoreRules{}
+	if !noIgnore {
+		dir := "."
+		rules = rules.withDir(workspace, dir)
+		if rel, err := filepath.Rel(workspace, root); err == nil && rel != "." {
+			for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+				dir = strings.TrimPrefix(dir+"/"+part, "./")
+				rules = rules.withDir(workspace, dir)
+			}
+		}
+	}
+	dirRules := map[string]ignoreRules{root: rules}
+	return filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || p == root {
+			return nil
+		}
+		rel, _ := filepath.Rel(workspace, p)
+		rel = filepath.ToSlash(rel)
+		parent := dirRules[filepath.Dir(p)]
+		if d.IsDir() {
+			if d.Name() == ".git" || d.Name() == "node_modules" || (!noIgnore && parent.ignored(rel, true)) {
+				return filepath.SkipDir
+			}
+			if err := visit(p, rel, d); err != nil {
+				return err
+			}
+			if noIgnore {
+				dirRules[p] = parent
+			} else {
+				dirRules[p] = parent.withDir(workspace, rel)
+			}
+			return nil
+		}
+		if !noIgnore && parent.ignored(rel, false) {
+			return nil
+		}
+		return visit(p, rel, d)
+	})
+}
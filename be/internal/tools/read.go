@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"backboard-swarm/be/internal/runtime"
+)
+
+const (
+	defaultReadBytes = 20000
+	defaultReadLines = 2000
+	maxReadPaths     = 20
+	maxReadFileBytes = 50 << 20
+)
+
+type readOptions struct {
+	offset      int
+	limit       int
+	maxBytes    int
+	lineNumbers bool
+}
+
+// readTool reads one file, or several when paths is given. A failure to read
+// one of several files is reported in its entry instead of failing the call.
+func readTool(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	opts := readOptions{
+		offset:      getInt(args, "offset_line", 1),
+		limit:       getInt(args, "limit_lines", defaultReadLines),
+		maxBytes:    getInt(args, "max_bytes", defaultReadBytes),
+		lineNumbers: getBool(args, "line_numbers", true),
+	}
+	paths := getStringSlice(args, "paths")
+	if len(paths) == 0 {
+		p := getString(args, "path", "")
+		if p == "" {
+			return nil, errors.New("path or paths is required")
+		}
+		return readFile(execCtx, p, opts)
+	}
+	if len(paths) > maxReadPaths {
+		return nil, fmt.Errorf("at most %d paths per read", maxReadPaths)
+	}
+	files := make([]map[string]any, 0, len(paths))
+	for _, p := range paths {
+		file, err := readFile(execCtx, p, opts)
+		if err != nil {
+			file = map[string]any{"path": p, "error": err.Error()}
+		}
+		files = append(files, file)
+	}
+	return map[string]any{"files": files}, nil
+}
+
+func readFile(execCtx *ExecutionContext, input string, opts readOptions) (map[string]any, error) {
+	p, err := resolvePath(execCtx.WorkspaceRoot, input)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory; use ls", p)
+	}
+	if info.Size() > maxReadFileBytes {
+		return nil, fmt.Errorf("%s is %d bytes, larger than the %d byte read limit; use grep to find the lines you need", p, info.Size(), maxReadFileBytes)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	text, encoding, err := decodeText(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	total := len(lines)
+	out := map[string]any{"path": p, "total_lines": total, "encoding": encoding}
+	if opts.offset > total && total > 0 {
+		return nil, fmt.Errorf("offset_line %d is past the end of %s (%d lines)", opts.offset, p, total)
+	}
+
+	var content strings.Builder
+	start := min(opts.offset, total+1)
+	end := start - 1
+	truncated := false
+	for i := start - 1; i < total && i < start-1+opts.limit; i++ {
+		line := lines[i]
+		if opts.lineNumbers {
+			line = strconv.Itoa(i+1) + "\t" + line
+		}
+		if content.Len()+len(line) > opts.maxBytes {
+			// Always return something, cutting an overlong first line at a
+			// rune boundary.
+			if content.Len() == 0 {
+				content.WriteString(line[:runtime.RuneBoundary(line, opts.maxBytes)])
+				end = i + 1
+			}
+			truncated = true
+			break
+		}
+		content.WriteString(line)
+		end = i + 1
+	}
+	if end < total {
+		truncated = true
+		out["next_offset_line"] = end + 1
+	}
+	out["content"] = content.String()
+	out["start_line"] = start
+	out["end_line"] = end
+	out["truncated"] = truncated
+	return out, nil
+}
+
+// decodeText returns the file as UTF-8 text and names its encoding. UTF-16
+// files with a byte order mark are converted, invalid UTF-8 sequences are
+// replaced, and binary files are refused.
+func decodeText(b []byte) (string, string, error) {
+	switch {
+	case bytes.HasPrefix(b, []byte{0xEF, 0xBB, 0xBF}):
+		return strings.ToValidUTF8(string(b[3:]), "\uFFFD"), "utf-8-bom", nil
+	case bytes.HasPrefix(b, []byte{0xFF, 0xFE}):
+		return decodeUTF16(b[2:], binary.LittleEndian), "utf-16le", nil
+	case bytes.HasPrefix(b, []byte{0xFE, 0xFF}):
+		return decodeUTF16(b[2:], binary.BigEndian), "utf-16be", nil
+	}
+	sniff := b[:min(len(b), binarySniffBytes)]
+	kind := http.DetectContentType(sniff)
+	if bytes.IndexByte(sniff, 0) >= 0 || (!strings.HasPrefix(kind, "text/") && !utf8.Valid(sniff)) {
+		return "", "", fmt.Errorf("binary file (%s, %d bytes) cannot be shown as text", kind, len(b))
+	}
+	if !utf8.Valid(b) {
+		return strings.ToValidUTF8(string(b), "\uFFFD"), "invalid-utf-8", nil
+	}
+	return string(b), "utf-8", nil
+}
+
+func decodeUTF16(b []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = order.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
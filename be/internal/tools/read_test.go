@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"backboard-swarm/be/internal/backboard"
+)
+
+func TestReadRangesFilesAndRefusesBinary(t *testing.T) {
+	root := t.TempDir()
+	var big strings.Builder
+	for i := 1; i <= 600; i++ {
+		fmt.Fprintf(&big, "line %d\n", i)
+	}
+	files := map[string]string{
+		"big.txt":   big.String(),
+		"small.txt": "héllo\nworld",
+		"runes.txt": strings.Repeat("é", 100),
+		"utf16.txt": "\xff\xfeh\x00i\x00\n\x00",
+		"image.png": "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	call := func(args string) (map[string]any, error) {
+		out, _, _, err := r.Execute(context.Background(), backboard.ToolCall{
+			ID:       "read",
+			Function: backboard.ToolCallFunction{Name: "read", ParsedArguments: []byte(args)},
+		}, &ExecutionContext{WorkspaceRoot: root})
+		if err != nil {
+			return nil, err
+		}
+		var payload struct {
+			Result map[string]any `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(out.Output), &payload); err != nil {
+			t.Fatalf("invalid output %s", out.Output)
+		}
+		return payload.Result, nil
+	}
+	read := func(args string) map[string]any {
+		result, err := call(args)
+		if err != nil {
+			t.Fatalf("read %s failed: %v", args, err)
+		}
+		return result
+	}
+
+	page := read(`{"path":"big.txt","offset_line":400,"limit_lines":3}`)
+	if page["content"] != "400\tline 400\n401\tline 401\n402\tline 402\n" || page["total_lines"] != float64(600) || page["truncated"] != true || page["next_offset_line"] != float64(403) {
+		t.Fatalf("unexpected page %v", page)
+	}
+	capped := read(`{"path":"big.txt","max_bytes":30}`)
+	if capped["content"] != "1\tline 1\n2\tline 2\n3\tline 3\n" || capped["end_line"] != float64(3) {
+		t.Fatalf("expected whole lines within max_bytes, got %q", capped["content"])
+	}
+	if runes := read(`{"path":"runes.txt","max_bytes":9,"line_numbers":false}`)["content"].(string); runes != "éééé" || !utf8.ValidString(runes) {
+		t.Fatalf("expected cut at a rune boundary, got %q", runes)
+	}
+	if whole := read(`{"path":"small.txt"}`); whole["content"] != "1\théllo\n2\tworld" || whole["truncated"] != false {
+		t.Fatalf("unexpected small read %v", whole)
+	}
+	if utf16 := read(`{"path":"utf16.txt","line_numbers":false}`); utf16["content"] != "hi\n" || utf16["encoding"] != "utf-16le" {
+		t.Fatalf("unexpected utf-16 read %v", utf16)
+	}
+	if _, err := call(`{"path":"image.png"}`); err == nil || !strings.Contains(err.Error(), "image/png") {
+		t.Fatalf("expected image to be refused, got %v", err)
+	}
+	if _, err := call(`{"path":"big.txt","offset_line":700}`); err == nil {
+		t.Fatal("expected offset past the end to fail")
+	}
+
+	multi := read(`{"paths":["small.txt","image.png","missing.txt"],"limit_lines":1}`)["files"].([]any)
+	if len(multi) != 3 || multi[0].(map[string]any)["content"] != "1\théllo\n" {
+		t.Fatalf("unexpected multi read %v", multi)
+	}
+	for _, f := range multi[1:] {
+		if f.(map[string]any)["error"] == nil {
+			t.Fatalf("expected per-file error, got %v", f)
+		}
+	}
+}
@@ -3,12 +3,15 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"backboard-swarm/be/internal/backboard"
+	"backboard-swarm/be/internal/fetch"
 	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/search"
 	"backboard-swarm/be/internal/types"
 )
 
@@ -22,25 +25,49 @@ type ExecutionContext struct {
 	Role           types.Role
 	WorkspaceRoot  string
 	JinaAPIKey     string
+	Search         search.Provider
+	Fetcher        fetch.Fetcher
 	RequestTimeout time.Duration
 	Todos          *runtime.TodoStore
+	Outputs        *runtime.OutputStore
+	Approvals      *runtime.ApprovalStore
+	Inputs         *runtime.InputStore
+	Sources        *runtime.SourceStore
+	Board          *runtime.BoardStore
+	AskUserTimeout time.Duration
+	Delegator      Delegator
 	Emitter        EventEmitter
+	// ReadOnly limits the agent to tools registered as ReadOnly.
+	ReadOnly bool
+
+	FinishMode           types.FinishMode
+	SkipFinishValidation bool
 
 	FinishSummary string
+	FinishPayload json.RawMessage
+	// OutputBudget is the serialized output cap of the running tool, set
+	// by Execute; zero means unlimited.
+	OutputBudget int
 }
 
 type ToolFunc func(ctx context.Context, args map[string]any, execCtx *ExecutionContext) (any, error)
 
 type Registration struct {
-	Name        string
-	Description string
-	Parameters  map[string]any
-	Handler     ToolFunc
+	Name           string
+	Description    string
+	Parameters     map[string]any
+	Handler        ToolFunc
+	MaxOutputBytes int
+	// ReadOnly marks tools with no side effects beyond reading the
+	// workspace or the web, the only ones read-only agents may call.
+	ReadOnly bool
 }
 
 type Registry struct {
-	mu       sync.RWMutex
-	handlers map[string]Registration
+	mu             sync.RWMutex
+	handlers       map[string]Registration
+	maxOutputBytes int
+	approvals      ApprovalPolicy
 }
 
 func NewRegistry() *Registry {
@@ -59,6 +86,20 @@ func (r *Registry) RegisterPlugin(reg Registration) {
 	r.handlers[reg.Name] = reg
 }
 
+// SetOutputLimit sets the global cap on a serialized tool output. Tools may
+// declare a tighter MaxOutputBytes; the smaller of the two wins.
+func (r *Registry) SetOutputLimit(maxBytes int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxOutputBytes = maxBytes
+}
+
+func (r *Registry) SetApprovalPolicy(policy ApprovalPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.approvals = policy
+}
+
 func (r *Registry) Definitions() []backboard.ToolDefinition {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -85,15 +126,39 @@ func (r *Registry) Execute(ctx context.Context, call backboard.ToolCall, execCtx
 		return backboard.ToolOutput{ToolCallID: call.ID, Output: out}, false, "", fmt.Errorf("tool %q is not allowlisted", call.Function.Name)
 	}
 
+	if execCtx.ReadOnly && !reg.ReadOnly {
+		err := fmt.Errorf("tool %q is not available to read-only agents", call.Function.Name)
+		return backboard.ToolOutput{ToolCallID: call.ID, Output: jsonError(err)}, false, "", err
+	}
+
 	args, err := call.ArgumentsMap()
 	if err != nil {
 		out := jsonError(fmt.Errorf("invalid arguments for %s: %w", call.Function.Name, err))
 		return backboard.ToolOutput{ToolCallID: call.ID, Output: out}, false, "", err
 	}
 
+	args, err = r.approve(ctx, call, args, execCtx)
+	if err != nil {
+		return backboard.ToolOutput{ToolCallID: call.ID, Output: r.limitOutput(reg, jsonError(err), false, execCtx)}, false, "", err
+	}
+
+	if call.Function.Name == "finish" && !execCtx.SkipFinishValidation {
+		if err := validateFinish(execCtx.FinishMode, args); err != nil {
+			payload := map[string]any{
+				"ok":              false,
+				"error":           err.Error(),
+				"retry":           true,
+				"expected_schema": finishSchemas[execCtx.FinishMode],
+			}
+			b, _ := json.Marshal(payload)
+			return backboard.ToolOutput{ToolCallID: call.ID, Output: string(b)}, false, "", err
+		}
+	}
+
+	execCtx.OutputBudget = r.outputLimit(reg)
 	result, execErr := reg.Handler(ctx, args, execCtx)
 	if execErr != nil {
-		out := jsonError(execErr)
+		out := r.limitOutput(reg, jsonError(execErr), false, execCtx)
 		return backboard.ToolOutput{ToolCallID: call.ID, Output: out}, false, "", execErr
 	}
 
@@ -106,11 +171,60 @@ func (r *Registry) Execute(ctx context.Context, call backboard.ToolCall, execCtx
 
 	payload := map[string]any{"ok": true, "result": result}
 	b, _ := json.Marshal(payload)
-	return backboard.ToolOutput{ToolCallID: call.ID, Output: string(b)}, finished, execCtx.FinishSummary, nil
+	out := r.limitOutput(reg, string(b), true, execCtx)
+	return backboard.ToolOutput{ToolCallID: call.ID, Output: out}, finished, execCtx.FinishSummary, nil
+}
+
+func (r *Registry) outputLimit(reg Registration) int {
+	r.mu.RLock()
+	limit := r.maxOutputBytes
+	r.mu.RUnlock()
+	if reg.MaxOutputBytes > 0 && (limit <= 0 || reg.MaxOutputBytes < limit) {
+		limit = reg.MaxOutputBytes
+	}
+	return limit
+}
+
+// limitOutput moves outputs above the tool's budget into the run's output
+// store and returns a handle the agent can page with read_output. ok is
+// carried over from the original payload. Escaping can make the preview
+// grow when it is marshalled again, so it is shrunk until the wrapped
+// payload fits.
+func (r *Registry) limitOutput(reg Registration, out string, ok bool, execCtx *ExecutionContext) string {
+	limit := r.outputLimit(reg)
+	if limit <= 0 || len(out) <= limit {
+		return out
+	}
+	payload := map[string]any{
+		"ok":          ok,
+		"truncated":   true,
+		"total_bytes": len(out),
+	}
+	if execCtx.Outputs != nil {
+		payload["output_id"] = execCtx.Outputs.Put(execCtx.RunID, out)
+		payload["hint"] = "output exceeded the tool budget; call read_output with output_id and offset=next_offset to page through the rest"
+	}
+	previewLen := runtime.RuneBoundary(out, limit/2)
+	for {
+		payload["preview"] = out[:previewLen]
+		if execCtx.Outputs != nil {
+			payload["next_offset"] = previewLen
+		}
+		b, _ := json.Marshal(payload)
+		if len(b) <= limit || previewLen == 0 {
+			return string(b)
+		}
+		previewLen = runtime.RuneBoundary(out, max(previewLen-(len(b)-limit), 0))
+	}
 }
 
 func jsonError(err error) string {
 	payload := map[string]any{"ok": false, "error": err.Error()}
+	var denied approvalDenied
+	if errors.As(err, &denied) {
+		payload["denied"] = true
+		payload["reason"] = denied.reason
+	}
 	b, _ := json.Marshal(payload)
 	return string(b)
 }
@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -77,6 +78,36 @@ func TestRegistryPluginRegistration(t *testing.T) {
 	}
 }
 
+func TestRegistryReadOnlyAgentsOnlyGetReadOnlyTools(t *testing.T) {
+	tmp := t.TempDir()
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	r.RegisterPlugin(Registration{
+		Name:       "write_note",
+		Parameters: objectSchema(map[string]any{}, nil),
+		Handler: func(_ context.Context, _ map[string]any, _ *ExecutionContext) (any, error) {
+			return "written", nil
+		},
+	})
+	execCtx := &ExecutionContext{WorkspaceRoot: tmp, Todos: runtime.NewTodoStore(), Role: types.RoleFactChecker, ReadOnly: true}
+
+	for _, name := range []string{"write_note", "todo_create", "board_post"} {
+		_, _, _, err := r.Execute(context.Background(), backboard.ToolCall{
+			ID:       "1",
+			Function: backboard.ToolCallFunction{Name: name, ParsedArguments: []byte(`{"title":"x","content":"x"}`)},
+		}, execCtx)
+		if err == nil || !strings.Contains(err.Error(), "read-only") {
+			t.Fatalf("expected %s to be refused, got %v", name, err)
+		}
+	}
+	if _, _, _, err := r.Execute(context.Background(), backboard.ToolCall{
+		ID:       "2",
+		Function: backboard.ToolCallFunction{Name: "ls", ParsedArguments: []byte(`{}`)},
+	}, execCtx); err != nil {
+		t.Fatalf("expected ls to run: %v", err)
+	}
+}
+
 func TestRegistryMessageAndFinishOutputsAreMinimal(t *testing.T) {
 	r := NewRegistry()
 	RegisterBuiltins(r)
@@ -121,3 +152,173 @@ func TestRegistryMessageAndFinishOutputsAreMinimal(t *testing.T) {
 		t.Fatalf("finish output leaked summary: %s", finishOut.Output)
 	}
 }
+
+func TestRegistryOutputBudgetStashesOverflow(t *testing.T) {
+	tmp := t.TempDir()
+	content := strings.Repeat("0123456789", 400)
+	if err := os.WriteFile(filepath.Join(tmp, "big.txt"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	r.SetOutputLimit(1000)
+	execCtx := &ExecutionContext{RunID: "run-1", WorkspaceRoot: tmp, Outputs: runtime.NewOutputStore(), Role: types.RoleCoder}
+
+	out, _, _, err := r.Execute(context.Background(), backboard.ToolCall{
+		ID:       "1",
+		Function: backboard.ToolCallFunction{Name: "read", ParsedArguments: []byte(`{"path":"big.txt"}`)},
+	}, execCtx)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if len(out.Output) > 1000 {
+		t.Fatalf("expected output within budget, got %d bytes", len(out.Output))
+	}
+	var handle struct {
+		OutputID   string `json:"output_id"`
+		NextOffset int    `json:"next_offset"`
+		TotalBytes int    `json:"total_bytes"`
+		Preview    string `json:"preview"`
+	}
+	if err := json.Unmarshal([]byte(out.Output), &handle); err != nil || handle.OutputID == "" {
+		t.Fatalf("expected output handle, got %s", out.Output)
+	}
+
+	full := handle.Preview
+	offset := handle.NextOffset
+	for i := 0; i < 20; i++ {
+		args, _ := json.Marshal(map[string]any{"output_id": handle.OutputID, "offset": offset, "limit": 900})
+		pageOut, _, _, err := r.Execute(context.Background(), backboard.ToolCall{
+			ID:       "2",
+			Function: backboard.ToolCallFunction{Name: "read_output", ParsedArguments: args},
+		}, execCtx)
+		if err != nil {
+			t.Fatalf("read_output failed: %v", err)
+		}
+		var page struct {
+			Result runtime.OutputPage `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(pageOut.Output), &page); err != nil {
+			t.Fatalf("unexpected read_output payload: %s", pageOut.Output)
+		}
+		full += page.Result.Content
+		offset = page.Result.NextOffset
+		if page.Result.Done {
+			break
+		}
+	}
+	if len(full) != handle.TotalBytes || !strings.Contains(full, content) {
+		t.Fatalf("expected paged output to reassemble %d bytes, got %d", handle.TotalBytes, len(full))
+	}
+}
+
+func TestRegistryFinishValidatesModeSchema(t *testing.T) {
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	finish := func(args string) backboard.ToolCall {
+		return backboard.ToolCall{ID: "f1", Function: backboard.ToolCallFunction{Name: "finish", ParsedArguments: []byte(args)}}
+	}
+
+	execCtx := &ExecutionContext{FinishMode: types.FinishPlan, Role: types.RoleOrchestrator}
+	out, finished, _, err := r.Execute(context.Background(), finish(`{"summary":"{\"subtasks\":[]}"}`), execCtx)
+	if !errors.Is(err, ErrInvalidFinish) || finished {
+		t.Fatalf("expected invalid plan to be rejected, got finished=%v err=%v", finished, err)
+	}
+	if !strings.Contains(out.Output, `"retry":true`) || !strings.Contains(out.Output, "subtasks is required") {
+		t.Fatalf("expected retry hint naming the missing field, got %s", out.Output)
+	}
+
+	execCtx = &ExecutionContext{FinishMode: types.FinishPlan, Role: types.RoleOrchestrator}
+	_, finished, _, err = r.Execute(context.Background(), finish(`{"subtasks":[{"role":"researcher","task":"find docs"}]}`), execCtx)
+	if err != nil || !finished {
+		t.Fatalf("expected valid plan to finish, got finished=%v err=%v", finished, err)
+	}
+	if !strings.Contains(string(execCtx.FinishPayload), "find docs") {
+		t.Fatalf("expected structured payload, got %s", execCtx.FinishPayload)
+	}
+
+	execCtx = &ExecutionContext{FinishMode: types.FinishDecision, Role: types.RoleOrchestrator}
+	_, _, _, err = r.Execute(context.Background(), finish(`{"action":"finalize"}`), execCtx)
+	if !errors.Is(err, ErrInvalidFinish) {
+		t.Fatalf("expected finalize without summary to be rejected, got %v", err)
+	}
+
+	execCtx = &ExecutionContext{FinishMode: types.FinishReport, Role: types.RoleResearcher}
+	_, _, _, err = r.Execute(context.Background(), finish(`{"summary":"ok","findings":["a"],"confidence":1.5}`), execCtx)
+	if !errors.Is(err, ErrInvalidFinish) {
+		t.Fatalf("expected out of range confidence to be rejected, got %v", err)
+	}
+
+	execCtx = &ExecutionContext{FinishMode: types.FinishReport, SkipFinishValidation: true, Role: types.RoleResearcher}
+	_, finished, summary, err := r.Execute(context.Background(), finish(`{"summary":"best effort"}`), execCtx)
+	if err != nil || !finished || summary != "best effort" || execCtx.FinishPayload != nil {
+		t.Fatalf("expected unvalidated finish to fall back to summary, got finished=%v summary=%q err=%v", finished, summary, err)
+	}
+}
+
+func TestRegistryOutputBudgetHoldsForEscapedAndErrorOutputs(t *testing.T) {
+	escaped := strings.Repeat(`"<\`, 1500)
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	r.RegisterBuiltin(Registration{
+		Name:       "quotes",
+		Parameters: objectSchema(map[string]any{}, nil),
+		Handler: func(context.Context, map[string]any, *ExecutionContext) (any, error) {
+			return escaped, nil
+		},
+	})
+	r.RegisterBuiltin(Registration{
+		Name:       "fails",
+		Parameters: objectSchema(map[string]any{}, nil),
+		Handler: func(context.Context, map[string]any, *ExecutionContext) (any, error) {
+			return nil, errors.New(escaped)
+		},
+	})
+	r.SetOutputLimit(1000)
+	execCtx := &ExecutionContext{RunID: "run-1", Outputs: runtime.NewOutputStore(), Role: types.RoleCoder}
+	call := func(name, args string) string {
+		out, _, _, _ := r.Execute(context.Background(), backboard.ToolCall{
+			ID:       name,
+			Function: backboard.ToolCallFunction{Name: name, ParsedArguments: []byte(args)},
+		}, execCtx)
+		if len(out.Output) > 1000 {
+			t.Fatalf("%s: expected output within budget, got %d bytes", name, len(out.Output))
+		}
+		return out.Output
+	}
+
+	var handle struct {
+		OK         bool   `json:"ok"`
+		OutputID   string `json:"output_id"`
+		NextOffset int    `json:"next_offset"`
+		Preview    string `json:"preview"`
+	}
+	if err := json.Unmarshal([]byte(call("fails", `{}`)), &handle); err != nil || handle.OK || handle.OutputID == "" {
+		t.Fatalf("expected a failed output handle, got %+v", handle)
+	}
+	if err := json.Unmarshal([]byte(call("quotes", `{}`)), &handle); err != nil || !handle.OK || handle.OutputID == "" {
+		t.Fatalf("expected output handle, got %+v", handle)
+	}
+
+	full := handle.Preview
+	offset := handle.NextOffset
+	for i := 0; i < 100; i++ {
+		args, _ := json.Marshal(map[string]any{"output_id": handle.OutputID, "offset": offset, "limit": 900})
+		var page struct {
+			Result runtime.OutputPage `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(call("read_output", string(args))), &page); err != nil {
+			t.Fatalf("unexpected read_output payload: %v", err)
+		}
+		full += page.Result.Content
+		offset = page.Result.NextOffset
+		if page.Result.Done {
+			break
+		}
+	}
+	want, _ := json.Marshal(map[string]any{"ok": true, "result": escaped})
+	if full != string(want) {
+		t.Fatalf("expected paged output to reassemble %d bytes, got %d", len(want), len(full))
+	}
+}
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateSchema checks v against the subset of JSON Schema used by tool
+// definitions: type, properties, required, enum, items, minItems, minLength,
+// minimum and maximum.
+func validateSchema(schema map[string]any, v any, path string) error {
+	if path == "" {
+		path = "$"
+	}
+	if t, ok := schema["type"].(string); ok {
+		if err := checkType(t, v, path); err != nil {
+			return err
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if e == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s must be one of %v", path, enum)
+		}
+	}
+
+	switch t := v.(type) {
+	case map[string]any:
+		for _, key := range requiredKeys(schema) {
+			if _, ok := t[key]; !ok {
+				return fmt.Errorf("%s.%s is required", path, key)
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for key, val := range t {
+			propSchema, ok := props[key].(map[string]any)
+			if !ok {
+				continue
+			}
+			if err := validateSchema(propSchema, val, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if min, ok := schemaNumber(schema, "minItems"); ok && float64(len(t)) < min {
+			return fmt.Errorf("%s must have at least %d item(s)", path, int(min))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range t {
+				if err := validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		if min, ok := schemaNumber(schema, "minLength"); ok && float64(len(strings.TrimSpace(t))) < min {
+			return fmt.Errorf("%s must not be empty", path)
+		}
+	case float64:
+		if min, ok := schemaNumber(schema, "minimum"); ok && t < min {
+			return fmt.Errorf("%s must be >= %v", path, min)
+		}
+		if max, ok := schemaNumber(schema, "maximum"); ok && t > max {
+			return fmt.Errorf("%s must be <= %v", path, max)
+		}
+	}
+	return nil
+}
+
+func checkType(t string, v any, path string) error {
+	ok := false
+	switch t {
+	case "object":
+		_, ok = v.(map[string]any)
+	case "array":
+		_, ok = v.([]any)
+	case "string":
+		_, ok = v.(string)
+	case "number":
+		_, ok = v.(float64)
+	case "integer":
+		f, isNum := v.(float64)
+		ok = isNum && f == float64(int64(f))
+	case "boolean":
+		_, ok = v.(bool)
+	default:
+		ok = true
+	}
+	if !ok {
+		return fmt.Errorf("%s must be of type %s", path, t)
+	}
+	return nil
+}
+
+func requiredKeys(schema map[string]any) []string {
+	switch t := schema["required"].(type) {
+	case []string:
+		return t
+	case []any:
+		out := make([]string, 0, len(t))
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func schemaNumber(schema map[string]any, key string) (float64, bool) {
+	switch t := schema[key].(type) {
+	case int:
+		return float64(t), true
+	case float64:
+		return t, true
+	}
+	return 0, false
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+
+	"backboard-swarm/be/internal/search"
+	"backboard-swarm/be/internal/types"
+)
+
+var bareURLRe = regexp.MustCompile(`https?://[^\s)\]>"']+`)
+
+const maxSERPSources = 10
+
+// registerSERPSources records the result URLs found in search output. It
+// understands the numbered Jina SERP layout and falls back to bare URLs.
+func registerSERPSources(execCtx *ExecutionContext, tool, content string) []types.Source {
+	results := search.ParseSERP(content)
+	if len(results) == 0 {
+		for _, u := range bareURLRe.FindAllString(content, -1) {
+			results = append(results, search.Result{URL: strings.TrimRight(u, ".,;")})
+		}
+	}
+	return registerResultSources(execCtx, tool, results)
+}
+
+func registerResultSources(execCtx *ExecutionContext, tool string, results []search.Result) []types.Source {
+	if execCtx.Sources == nil {
+		return nil
+	}
+	out := make([]types.Source, 0)
+	seen := map[string]bool{}
+	for _, r := range results {
+		if len(out) >= maxSERPSources {
+			break
+		}
+		if seen[r.URL] {
+			continue
+		}
+		if _, err := validateHTTPURL(r.URL); err != nil {
+			continue
+		}
+		seen[r.URL] = true
+		out = append(out, execCtx.Sources.Register(execCtx.RunID, types.Source{URL: r.URL, Title: r.Title, Tool: tool, AgentID: execCtx.AgentID}))
+	}
+	return out
+}
+
+func registerPage(execCtx *ExecutionContext, tool, rawURL, title string) []types.Source {
+	if execCtx.Sources == nil {
+		return nil
+	}
+	return []types.Source{execCtx.Sources.Register(execCtx.RunID, types.Source{URL: rawURL, Title: title, Tool: tool, AgentID: execCtx.AgentID})}
+}
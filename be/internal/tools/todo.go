@@ -0,0 +1,214 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/types"
+)
+
+var todoStatusSchema = map[string]any{"type": "string", "enum": []any{"pending", "in_progress", "blocked", "done", "cancelled"}}
+
+var todoFields = map[string]any{
+	"title":      map[string]any{"type": "string"},
+	"status":     todoStatusSchema,
+	"notes":      map[string]any{"type": "string"},
+	"order":      map[string]any{"type": "integer", "description": "Position among siblings; defaults to the end"},
+	"parent_id":  map[string]any{"type": "string", "description": "Nest under this todo"},
+	"depends_on": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Todo ids that must be done or cancelled before this one starts"},
+}
+
+func todoCreateParameters() map[string]any {
+	props := withTodoFields(map[string]any{})
+	props["items"] = map[string]any{
+		"type":        "array",
+		"description": "Create several todos at once instead of a single one",
+		"items": objectSchema(withTodoFields(map[string]any{
+			"key": map[string]any{"type": "string", "description": "Name later items of this batch can use in parent_id and depends_on"},
+		}), []string{"title"}),
+	}
+	return objectSchema(props, nil)
+}
+
+func todoUpdateParameters() map[string]any {
+	props := withTodoFields(map[string]any{"id": map[string]any{"type": "string"}})
+	props["updates"] = map[string]any{
+		"type":        "array",
+		"description": "Apply several updates at once; either all succeed or none do",
+		"items":       objectSchema(withTodoFields(map[string]any{"id": map[string]any{"type": "string"}}), []string{"id"}),
+	}
+	return objectSchema(props, nil)
+}
+
+func withTodoFields(props map[string]any) map[string]any {
+	for k, v := range todoFields {
+		props[k] = v
+	}
+	return props
+}
+
+func todoCreate(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	if execCtx.Todos == nil {
+		return nil, errors.New("todo store unavailable")
+	}
+	specs := batchArgs(args, "items")
+	items := make([]runtime.TodoItem, 0, len(specs))
+	for _, spec := range specs {
+		items = append(items, runtime.TodoItem{
+			Title:     getString(spec, "title", ""),
+			Status:    runtime.TodoStatus(getString(spec, "status", "")),
+			Notes:     getString(spec, "notes", ""),
+			Order:     getInt(spec, "order", 0),
+			ParentID:  getString(spec, "parent_id", ""),
+			DependsOn: getStringSlice(spec, "depends_on"),
+			Key:       getString(spec, "key", ""),
+		})
+	}
+	created, err := execCtx.Todos.CreateAll(execCtx.RunID, execCtx.AgentID, items)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range created {
+		emitTodoChanged(execCtx, "created", item)
+	}
+	if _, batch := args["items"]; !batch {
+		return created[0], nil
+	}
+	return map[string]any{"created": created}, nil
+}
+
+func todoUpdate(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	if execCtx.Todos == nil {
+		return nil, errors.New("todo store unavailable")
+	}
+	specs := batchArgs(args, "updates")
+	updates := make([]runtime.TodoUpdate, 0, len(specs))
+	for _, spec := range specs {
+		id := getString(spec, "id", "")
+		if id == "" {
+			return nil, errors.New("id is required")
+		}
+		updates = append(updates, todoUpdateFromArgs(id, spec))
+	}
+	updated, err := execCtx.Todos.UpdateAll(execCtx.RunID, execCtx.AgentID, updates)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range updated {
+		emitTodoChanged(execCtx, "updated", item)
+	}
+	if _, batch := args["updates"]; !batch {
+		return updated[0], nil
+	}
+	return map[string]any{"updated": updated}, nil
+}
+
+func todoUpdateFromArgs(id string, spec map[string]any) runtime.TodoUpdate {
+	u := runtime.TodoUpdate{ID: id}
+	if v, ok := spec["title"].(string); ok {
+		u.Title = &v
+	}
+	if v, ok := spec["status"].(string); ok {
+		status := runtime.TodoStatus(v)
+		u.Status = &status
+	}
+	if v, ok := spec["notes"].(string); ok {
+		u.Notes = &v
+	}
+	if v, ok := spec["order"].(float64); ok {
+		order := int(v)
+		u.Order = &order
+	}
+	if v, ok := spec["parent_id"].(string); ok {
+		u.ParentID = &v
+	}
+	if _, ok := spec["depends_on"].([]any); ok {
+		deps := getStringSlice(spec, "depends_on")
+		u.DependsOn = &deps
+	}
+	return u
+}
+
+// batchArgs returns the objects under key, or args itself when the call
+// describes a single item.
+func batchArgs(args map[string]any, key string) []map[string]any {
+	raw, ok := args[key].([]any)
+	if !ok {
+		return []map[string]any{args}
+	}
+	out := make([]map[string]any, 0, len(raw))
+	for _, v := range raw {
+		if m, ok := v.(map[string]any); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func todoDelete(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	if execCtx.Todos == nil {
+		return nil, errors.New("todo store unavailable")
+	}
+	id := getString(args, "id", "")
+	if id == "" {
+		return nil, errors.New("id is required")
+	}
+	if !execCtx.Todos.Delete(execCtx.RunID, execCtx.AgentID, id) {
+		return nil, fmt.Errorf("todo %s not found", id)
+	}
+	emitTodoChanged(execCtx, "deleted", runtime.TodoItem{ID: id, AgentID: execCtx.AgentID})
+	return map[string]any{"deleted": id}, nil
+}
+
+func todoList(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	if execCtx.Todos == nil {
+		return nil, errors.New("todo store unavailable")
+	}
+	items := execCtx.Todos.List(execCtx.RunID, execCtx.AgentID)
+	status := runtime.TodoStatus(getString(args, "status", ""))
+	if status == "" {
+		return items, nil
+	}
+	out := make([]runtime.TodoItem, 0, len(items))
+	for _, item := range items {
+		if item.Status == status {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+func todoComplete(_ context.Context, args map[string]any, execCtx *ExecutionContext) (any, error) {
+	if execCtx.Todos == nil {
+		return nil, errors.New("todo store unavailable")
+	}
+	id := getString(args, "id", "")
+	if id == "" {
+		return nil, errors.New("id is required")
+	}
+	item, err := execCtx.Todos.Complete(execCtx.RunID, execCtx.AgentID, id)
+	if err != nil {
+		return nil, err
+	}
+	emitTodoChanged(execCtx, "completed", item)
+	return item, nil
+}
+
+func emitTodoChanged(execCtx *ExecutionContext, action string, item runtime.TodoItem) {
+	if execCtx.Emitter == nil {
+		return
+	}
+	execCtx.Emitter.Emit(types.Event{
+		Type:      "todo_changed",
+		RunID:     execCtx.RunID,
+		AgentID:   execCtx.AgentID,
+		Role:      execCtx.Role,
+		Status:    action,
+		Message:   item.Title,
+		Timestamp: time.Now().UTC(),
+		Meta:      map[string]any{"todo": item},
+	})
+}
@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"backboard-swarm/be/internal/backboard"
+	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/types"
+)
+
+func TestTodosAreScopedToRunAndPersisted(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "todos")
+	store, err := runtime.NewPersistentTodoStore(dir)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	emitter := &recordingEmitter{}
+	call := func(runID, name, args string) {
+		_, _, _, err := r.Execute(context.Background(), backboard.ToolCall{
+			ID:       name,
+			Function: backboard.ToolCallFunction{Name: name, ParsedArguments: []byte(args)},
+		}, &ExecutionContext{RunID: runID, AgentID: "agent-1", Role: types.RoleCoder, Todos: store, Emitter: emitter})
+		if err != nil {
+			t.Fatalf("%s failed: %v", name, err)
+		}
+	}
+
+	call("run-1", "todo_create", `{"title":"write parser"}`)
+	call("run-2", "todo_create", `{"title":"other run"}`)
+	call("run-1", "todo_complete", `{"id":"todo-1"}`)
+
+	if got := store.ListRun("run-1"); len(got) != 1 || !got[0].Completed || got[0].AgentID != "agent-1" {
+		t.Fatalf("expected one completed todo in run-1, got %+v", got)
+	}
+	if len(emitter.events) != 3 || emitter.events[0].Type != "todo_changed" || emitter.events[2].Status != "completed" {
+		t.Fatalf("expected todo_changed events, got %+v", emitter.events)
+	}
+
+	for _, run := range []string{"run-1", "run-2"} {
+		if _, err := os.Stat(filepath.Join(dir, run+".json")); err != nil {
+			t.Fatalf("expected a file per run: %v", err)
+		}
+	}
+	reloaded, err := runtime.NewPersistentTodoStore(dir)
+	if err != nil {
+		t.Fatalf("reload store: %v", err)
+	}
+	if got := reloaded.List("run-2", "agent-1"); len(got) != 1 || got[0].Title != "other run" {
+		t.Fatalf("expected run-2 todo after reload, got %+v", got)
+	}
+	item, err := reloaded.Create("run-1", "agent-1", runtime.TodoItem{Title: "next"})
+	if err != nil || item.ID != "todo-3" {
+		t.Fatalf("expected ids to continue after reload, got %s (%v)", item.ID, err)
+	}
+}
+
+func TestTodoBatchesKeepPlanOrderAndDependencies(t *testing.T) {
+	store := runtime.NewTodoStore()
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	call := func(name, args string) (string, error) {
+		out, _, _, err := r.Execute(context.Background(), backboard.ToolCall{
+			ID:       name,
+			Function: backboard.ToolCallFunction{Name: name, ParsedArguments: []byte(args)},
+		}, &ExecutionContext{RunID: "run-1", AgentID: "agent-1", Role: types.RoleCoder, Todos: store})
+		return out.Output, err
+	}
+
+	if _, err := call("todo_create", `{"items":[{"title":"design","key":"d"},{"title":"implement","key":"i","depends_on":["d"]},{"title":"tests","parent_id":"i"},{"title":"spike","order":1}]}`); err != nil {
+		t.Fatalf("batch create failed: %v", err)
+	}
+	titles := func() []string {
+		out := []string{}
+		for _, item := range store.List("run-1", "agent-1") {
+			out = append(out, item.Title)
+		}
+		return out
+	}
+	if got := strings.Join(titles(), ","); got != "design,spike,implement,tests" {
+		t.Fatalf("unexpected plan order %s", got)
+	}
+
+	out, _ := call("todo_update", `{"updates":[{"id":"todo-1","status":"done"},{"id":"todo-2","status":"in_progress"},{"id":"todo-4","depends_on":["todo-2"],"status":"in_progress"}]}`)
+	if !strings.Contains(out, "dependency todo-2") {
+		t.Fatalf("expected batch to fail on unfinished dependency, got %s", out)
+	}
+	if item := store.List("run-1", "agent-1")[0]; item.Status != runtime.TodoPending {
+		t.Fatalf("expected failed batch to leave todos unchanged, got %+v", item)
+	}
+
+	if _, err := call("todo_update", `{"updates":[{"id":"todo-1","status":"done"},{"id":"todo-2","status":"in_progress","notes":"halfway"}]}`); err != nil {
+		t.Fatalf("batch update failed: %v", err)
+	}
+	if out, _ := call("todo_update", `{"id":"todo-1","depends_on":["todo-2"]}`); !strings.Contains(out, "cycle") {
+		t.Fatalf("expected dependency cycle to be rejected, got %s", out)
+	}
+	if !store.Delete("run-1", "agent-1", "todo-2") {
+		t.Fatal("expected delete to succeed")
+	}
+	items := store.List("run-1", "agent-1")
+	if got := strings.Join(titles(), ","); got != "design,spike,tests" || items[2].ParentID != "" {
+		t.Fatalf("expected child to move up after delete, got %s %+v", got, items)
+	}
+}
+
+func TestTodoBatchKeysResolveToAssignedIDs(t *testing.T) {
+	store := runtime.NewTodoStore()
+	if _, err := store.CreateAll("run-0", "agent-9", []runtime.TodoItem{{Title: "earlier"}, {Title: "work"}}); err != nil {
+		t.Fatal(err)
+	}
+	created, err := store.CreateAll("run-1", "agent-1", []runtime.TodoItem{
+		{Title: "design", Key: "design"},
+		{Title: "build", Key: "build", DependsOn: []string{"design"}},
+		{Title: "test", ParentID: "build"},
+	})
+	if err != nil {
+		t.Fatalf("batch create failed: %v", err)
+	}
+	if created[0].ID != "todo-3" || created[1].DependsOn[0] != created[0].ID || created[2].ParentID != created[1].ID {
+		t.Fatalf("expected keys to resolve to the assigned ids, got %+v", created)
+	}
+	if stored := store.List("run-1", "agent-1")[0]; stored.Key != "" {
+		t.Fatalf("expected keys not to be stored, got %+v", stored)
+	}
+	if _, err := store.CreateAll("run-1", "agent-1", []runtime.TodoItem{{Title: "a", Key: "x"}, {Title: "b", Key: "x"}}); err == nil {
+		t.Fatal("expected duplicate keys to be rejected")
+	}
+}
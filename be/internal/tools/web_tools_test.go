@@ -2,8 +2,15 @@ package tools
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"backboard-swarm/be/internal/fetch"
+	"backboard-swarm/be/internal/runtime"
+	"backboard-swarm/be/internal/search"
+	"backboard-swarm/be/internal/types"
 )
 
 func TestWebSearchToolValidation(t *testing.T) {
@@ -19,7 +26,7 @@ func TestWebSearchToolValidation(t *testing.T) {
 }
 
 func TestWebFetchToolValidation(t *testing.T) {
-	_, err := webFetchTool(context.Background(), map[string]any{"url": "https://example.com"}, &ExecutionContext{})
+	_, err := webFetchTool(context.Background(), map[string]any{"url": "https://example.com"}, &ExecutionContext{Fetcher: fetch.NewJina("", 0)})
 	if err == nil || !strings.Contains(err.Error(), "JINA_API_KEY") {
 		t.Fatalf("expected api key validation error, got %v", err)
 	}
@@ -29,3 +36,63 @@ func TestWebFetchToolValidation(t *testing.T) {
 		t.Fatalf("expected url scheme validation error, got %v", err)
 	}
 }
+
+func TestRegisterSERPSourcesAssignsStableIDs(t *testing.T) {
+	store := runtime.NewSourceStore()
+	execCtx := &ExecutionContext{RunID: "run-1", AgentID: "agent-1", Sources: store}
+	serp := "[1] Title: Go 1.22 Release Notes\n[1] URL Source: https://go.dev/doc/go1.22\n[1] Description: notes\n[2] Title: Blog\n[2] URL Source: https://go.dev/blog/go1.22\n"
+
+	first := registerSERPSources(execCtx, "websearch", serp)
+	if len(first) != 2 || first[0].ID != "S1" || first[0].Title != "Go 1.22 Release Notes" || first[1].ID != "S2" {
+		t.Fatalf("unexpected sources: %+v", first)
+	}
+
+	again := registerPage(execCtx, "web_fetch", "https://GO.dev/doc/go1.22/", "Go 1.22")
+	if len(again) != 1 || again[0].ID != "S1" {
+		t.Fatalf("expected refetch of the same url to reuse S1, got %+v", again)
+	}
+	if got := store.List("run-1"); len(got) != 2 {
+		t.Fatalf("expected 2 distinct sources, got %+v", got)
+	}
+}
+
+func TestWebSearchUsesConfiguredProvider(t *testing.T) {
+	execCtx := &ExecutionContext{
+		RunID:   "run-1",
+		Sources: runtime.NewSourceStore(),
+		Search: search.Fallback{
+			search.NewJina("", 0),
+			search.NewFixture(map[string][]search.Result{"go release": {{Title: "Go 1.22", URL: "https://go.dev/doc/go1.22"}, {Title: "Bad", URL: "ftp://x"}}}),
+		},
+	}
+	out, err := webSearchTool(context.Background(), map[string]any{"query": "Go release"}, execCtx)
+	if err != nil {
+		t.Fatalf("expected fixture fallback, got %v", err)
+	}
+	result := out.(map[string]any)
+	sources := result["sources"].([]types.Source)
+	if result["provider"] != "fixture" || len(sources) != 1 || sources[0].ID != "S1" || sources[0].Title != "Go 1.22" {
+		t.Fatalf("unexpected search result %v", result)
+	}
+}
+
+func TestWebFetchRegistersPageTitle(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Spec</title></head><body><main><p>See <a href="/ref">the reference</a>.</p></main></body></html>`))
+	}))
+	defer srv.Close()
+
+	fetcher := fetch.NewNative(0)
+	fetcher.SetAllowPrivate(true)
+	execCtx := &ExecutionContext{RunID: "run-1", Sources: runtime.NewSourceStore(), Fetcher: fetcher}
+	out, err := webFetchTool(context.Background(), map[string]any{"url": srv.URL + "/spec"}, execCtx)
+	if err != nil {
+		t.Fatalf("web_fetch failed: %v", err)
+	}
+	result := out.(map[string]any)
+	sources := result["sources"].([]types.Source)
+	if result["markdown"] != "See [the reference]("+srv.URL+"/ref)." || len(sources) != 1 || sources[0].Title != "Spec" {
+		t.Fatalf("unexpected fetch result %v", result)
+	}
+}
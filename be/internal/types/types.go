@@ -35,10 +35,80 @@ type Event struct {
 type Subtask struct {
 	Role Role   `json:"role"`
 	Task string `json:"task"`
+	// Ensemble runs the subtask on that many agents and reconciles their
+	// answers.
+	Ensemble int `json:"ensemble,omitempty"`
+	// Retries and EscalateRole override the configured retry policy for
+	// this subtask.
+	Retries      *int `json:"retries,omitempty"`
+	EscalateRole Role `json:"escalate_role,omitempty"`
+	// TimeoutSeconds and MaxIterations bound a single attempt; zero means
+	// the role default.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	MaxIterations  int `json:"max_iterations,omitempty"`
 }
 
 type SubtaskResult struct {
-	Subtask Subtask `json:"subtask"`
-	Summary string  `json:"summary"`
-	Error   string  `json:"error,omitempty"`
+	Subtask    Subtask  `json:"subtask"`
+	AgentID    string   `json:"agent_id,omitempty"`
+	Summary    string   `json:"summary"`
+	Findings   []string `json:"findings,omitempty"`
+	Confidence float64  `json:"confidence,omitempty"`
+	Sources    []Source `json:"sources,omitempty"`
+	Unsourced  []string `json:"unsourced,omitempty"`
+	Members    int      `json:"members,omitempty"`
+	Agreement  float64  `json:"agreement,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	// Failure classifies Error; Attempts counts runs including retries.
+	Failure  FailureKind `json:"failure,omitempty"`
+	Attempts int         `json:"attempts,omitempty"`
+	// Partial marks a failed result whose Summary and Findings hold the
+	// progress made before the subtask was cut off.
+	Partial bool `json:"partial,omitempty"`
+}
+
+const (
+	VerdictSupported  = "supported"
+	VerdictRefuted    = "refuted"
+	VerdictUnverified = "unverified"
+)
+
+type FailureKind string
+
+const (
+	FailureToolError     FailureKind = "tool_error"
+	FailureMaxIterations FailureKind = "max_iterations"
+	FailureBackboard     FailureKind = "backboard_outage"
+	FailureTimeout       FailureKind = "timeout"
+	FailureAgent         FailureKind = "agent_error"
+)
+
+type ClaimVerdict struct {
+	Claim    string   `json:"claim"`
+	Verdict  string   `json:"verdict"`
+	Evidence string   `json:"evidence,omitempty"`
+	Sources  []string `json:"sources,omitempty"`
+}
+
+type ApprovalRule struct {
+	Tool     string `json:"tool"`
+	PathGlob string `json:"path_glob,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+}
+
+type FinishMode string
+
+const (
+	FinishPlan     FinishMode = "plan"
+	FinishDecision FinishMode = "decision"
+	FinishReport   FinishMode = "report"
+)
+
+type Source struct {
+	ID      string    `json:"id"`
+	URL     string    `json:"url"`
+	Title   string    `json:"title,omitempty"`
+	Tool    string    `json:"tool,omitempty"`
+	AgentID string    `json:"agent_id,omitempty"`
+	AddedAt time.Time `json:"added_at"`
 }
@@ -10,10 +10,15 @@ import (
 	"backboard-swarm/be/internal/types"
 )
 
+// MessageHandler receives messages sent by websocket clients. A returned
+// error is reported back to the sending client only.
+type MessageHandler func(msg []byte) error
+
 type Hub struct {
 	mu       sync.RWMutex
 	clients  map[*websocket.Conn]*clientConn
 	upgrader websocket.Upgrader
+	handler  MessageHandler
 }
 
 type clientConn struct {
@@ -42,6 +47,12 @@ func (h *Hub) HandleWS(w http.ResponseWriter, r *http.Request) {
 	go h.readLoop(conn)
 }
 
+func (h *Hub) SetHandler(fn MessageHandler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handler = fn
+}
+
 func (h *Hub) Emit(evt types.Event) {
 	b, err := json.Marshal(evt)
 	if err != nil {
@@ -68,9 +79,23 @@ func (h *Hub) Emit(evt types.Event) {
 func (h *Hub) readLoop(conn *websocket.Conn) {
 	defer h.remove(conn)
 	for {
-		if _, _, err := conn.ReadMessage(); err != nil {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
 			return
 		}
+		h.mu.RLock()
+		handler := h.handler
+		c := h.clients[conn]
+		h.mu.RUnlock()
+		if handler == nil || c == nil {
+			continue
+		}
+		if err := handler(msg); err != nil {
+			b, _ := json.Marshal(map[string]any{"type": "error", "message": err.Error()})
+			c.writeMu.Lock()
+			_ = c.conn.WriteMessage(websocket.TextMessage, b)
+			c.writeMu.Unlock()
+		}
 	}
 }
 